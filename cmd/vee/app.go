@@ -3,32 +3,33 @@ package main
 import (
 	"crypto/rand"
 	"fmt"
+	"os"
 	"sync"
 	"time"
+
+	"github.com/lthms/vee/internal/api"
+	"github.com/lthms/vee/internal/history"
 )
 
 // AppConfig stores configuration that _new-pane fetches via /api/config.
-type AppConfig struct {
-	VeePath       string   `json:"vee_path"`
-	Port          int      `json:"port"`
-	Passthrough   []string `json:"passthrough"`
-	ProjectConfig string   `json:"project_config"`
-	IdentityRule  string   `json:"identity_rule"`
-	PlatformsRule string   `json:"platforms_rule"`
-	MaxExamples   int      `json:"max_examples"`
+type AppConfig = api.AppConfig
+
+// configTmuxSocket returns the name of the tmux socket of the instance.
+func configTmuxSocket(c *AppConfig) string {
+	if c.TmuxSocket == "" {
+		return tmuxSocketName
+	}
+	return c.TmuxSocket
 }
 
 // IndexingTask represents a background processing operation.
-type IndexingTask struct {
-	TaskID    string    `json:"task_id"`
-	Title     string    `json:"title"`
-	StartedAt time.Time `json:"started_at"`
-}
+type IndexingTask = api.IndexingTask
 
 // indexingStore is a thread-safe store for active indexing tasks.
 type indexingStore struct {
-	mu    sync.RWMutex
-	tasks map[string]*IndexingTask
+	mu     sync.RWMutex
+	tasks  map[string]*IndexingTask
+	events *eventBus
 }
 
 func newIndexingStore() *indexingStore {
@@ -45,17 +46,30 @@ func (s *indexingStore) add(taskID string, title string) {
 		Title:     title,
 		StartedAt: time.Now(),
 	}
+	s.publish()
 }
 
 func (s *indexingStore) remove(taskID string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	delete(s.tasks, taskID)
+	s.publish()
+}
+
+// publish sends the tasks left as an indexing progress event. Callers hold
+// s.mu.
+func (s *indexingStore) publish() {
+	s.events.publish(evIndexingProgress, map[string]any{"tasks": s.sorted()})
 }
 
 func (s *indexingStore) list() []IndexingTask {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	return s.sorted()
+}
+
+// sorted returns copies of the tasks, oldest first. Callers hold s.mu.
+func (s *indexingStore) sorted() []IndexingTask {
 	result := make([]IndexingTask, 0, len(s.tasks))
 	for _, t := range s.tasks {
 		result = append(result, *t)
@@ -71,18 +85,33 @@ func (s *indexingStore) list() []IndexingTask {
 
 // App holds the shared application state passed to all subsystems.
 type App struct {
-	Sessions *sessionStore
-	Indexing *indexingStore
+	Sessions  *sessionStore
+	Indexing  *indexingStore
+	Jobs      *jobQueue
+	Schedules *scheduler
+	Notifier  *notifier
+	Events    *eventBus
 
-	mu     sync.RWMutex
-	config *AppConfig
+	mu       sync.RWMutex
+	config   *AppConfig
+	profiles map[string]Profile
 }
 
 func newApp() *App {
-	return &App{
-		Sessions: newSessionStore(),
-		Indexing: newIndexingStore(),
+	app := &App{
+		Sessions:  newSessionStore(),
+		Indexing:  newIndexingStore(),
+		Jobs:      newJobQueue(defaultJobConcurrency),
+		Schedules: newScheduler(),
+		Notifier:  newNotifier(),
+		Events:    newEventBus(defaultEventBacklog),
 	}
+	app.Sessions.events = app.Events
+	app.Indexing.events = app.Events
+	app.Jobs.events = app.Events
+	app.Schedules.events = app.Events
+	app.Notifier.tmuxSocket = app.tmuxSocket
+	return app
 }
 
 // SetConfig stores the project configuration for retrieval by _new-pane.
@@ -99,28 +128,48 @@ func (a *App) Config() *AppConfig {
 	return a.config
 }
 
+// SetProfiles stores the profiles of the instance, by name.
+func (a *App) SetProfiles(profiles map[string]Profile) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.profiles = profiles
+}
+
+// Profiles returns the profiles of the instance, by name. The map is
+// replaced, never modified, so callers may keep reading it.
+func (a *App) Profiles() map[string]Profile {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.profiles
+}
+
+// projectDir returns the directory of the project the instance serves:
+// the daemon's own, unless the configuration names another one.
+func (a *App) projectDir() string {
+	if cfg := a.Config(); cfg != nil && cfg.ProjectDir != "" {
+		return cfg.ProjectDir
+	}
+	dir, _ := os.Getwd()
+	return dir
+}
+
+// tmuxSocket returns the name of the tmux socket of the instance.
+func (a *App) tmuxSocket() string {
+	if cfg := a.Config(); cfg != nil {
+		return configTmuxSocket(cfg)
+	}
+	return tmuxSocketName
+}
+
 // Session represents a Claude Code session (active or suspended).
-type Session struct {
-	ID              string    `json:"id"`
-	Profile         string    `json:"profile"`
-	Indicator       string    `json:"indicator"`
-	StartedAt       time.Time `json:"started_at"`
-	Preview         string    `json:"preview"`
-	Status          string    `json:"status"`        // "active", "suspended", or "completed"
-	WindowTarget    string    `json:"window_target"` // tmux window ID (e.g. "@3")
-	Ephemeral       bool      `json:"ephemeral"`
-	ComposePath     string    `json:"compose_path,omitempty"`
-	ComposeProject  string    `json:"compose_project,omitempty"`
-	Working         bool      `json:"working"`
-	HasNotification bool      `json:"has_notification"`
-	PermissionMode  string    `json:"permission_mode"`
-	SystemPrompt    string    `json:"-"`
-}
-
-// sessionStore is an in-memory store of sessions keyed by ID.
+type Session = api.Session
+
+// sessionStore is an in-memory store of sessions keyed by ID. Changes are
+// published on events, when set.
 type sessionStore struct {
 	mu       sync.RWMutex
 	sessions map[string]*Session
+	events   *eventBus
 }
 
 func newSessionStore() *sessionStore {
@@ -129,14 +178,16 @@ func newSessionStore() *sessionStore {
 	}
 }
 
-func (s *sessionStore) create(id, profile, indicator, preview, windowTarget string, ephemeral bool, composePath, composeProject, systemPrompt string) *Session {
+func (s *sessionStore) create(id, profile, indicator, preview, windowTarget string, ephemeral bool, composePath, composeProject, systemPrompt string, feedbackIDs, groups []string, perms ToolPermissions) *Session {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	now := time.Now()
 	sess := &Session{
 		ID:             id,
 		Profile:        profile,
 		Indicator:      indicator,
-		StartedAt:      time.Now(),
+		StartedAt:      now,
+		LastActivity:   now,
 		Preview:        preview,
 		Status:         "active",
 		WindowTarget:   windowTarget,
@@ -144,8 +195,12 @@ func (s *sessionStore) create(id, profile, indicator, preview, windowTarget stri
 		ComposePath:    composePath,
 		ComposeProject: composeProject,
 		SystemPrompt:   systemPrompt,
+		FeedbackIDs:    feedbackIDs,
+		Groups:         groups,
+		Permissions:    perms,
 	}
 	s.sessions[id] = sess
+	s.events.publish(evSessionCreated, sess)
 	return sess
 }
 
@@ -160,13 +215,17 @@ func (s *sessionStore) setStatus(id, status string) {
 	defer s.mu.Unlock()
 	if sess, ok := s.sessions[id]; ok {
 		sess.Status = status
+		s.events.publish(evSessionStatus, sess)
 	}
 }
 
 func (s *sessionStore) drop(id string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	delete(s.sessions, id)
+	if _, ok := s.sessions[id]; ok {
+		delete(s.sessions, id)
+		s.events.publish(evSessionRemoved, map[string]string{"id": id})
+	}
 }
 
 // suspended returns all sessions with status "suspended", ordered by start time.
@@ -232,18 +291,23 @@ func (s *sessionStore) findByWindowTarget(target string) *Session {
 }
 
 // setWindowState updates the dynamic window state fields for a session.
-// Pointer bools so callers only update the fields they care about.
-func (s *sessionStore) setWindowState(id string, working, notif *bool, permMode, preview string) {
+// Pointer bools so callers only update the fields they care about. It
+// reports whether the session finished a turn, and whether it raised a
+// notification.
+func (s *sessionStore) setWindowState(id string, working, notif *bool, permMode, preview string) (finished, notified bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	sess, ok := s.sessions[id]
 	if !ok {
-		return
+		return false, false
 	}
+	sess.LastActivity = time.Now()
 	if working != nil {
+		finished = sess.Working && !*working
 		sess.Working = *working
 	}
 	if notif != nil {
+		notified = !sess.HasNotification && *notif
 		sess.HasNotification = *notif
 	}
 	if permMode != "" {
@@ -252,6 +316,8 @@ func (s *sessionStore) setWindowState(id string, working, notif *bool, permMode,
 	if preview != "" {
 		sess.Preview = preview
 	}
+	s.events.publish(evSessionWindow, sess)
+	return finished, notified
 }
 
 // setPreview updates the preview text for a session.
@@ -260,6 +326,95 @@ func (s *sessionStore) setPreview(id, preview string) {
 	defer s.mu.Unlock()
 	if sess, ok := s.sessions[id]; ok {
 		sess.Preview = preview
+		s.events.publish(evSessionPreview, sess)
+	}
+}
+
+// setTranscriptPath records where Claude writes the session transcript.
+func (s *sessionStore) setTranscriptPath(id, path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess, ok := s.sessions[id]; ok {
+		sess.TranscriptPath = path
+	}
+}
+
+// setUsage records the tokens a session used so far.
+func (s *sessionStore) setUsage(id string, usage history.Usage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess, ok := s.sessions[id]; ok {
+		sess.Usage = usage
+		s.events.publish(evSessionUpdated, sess)
+	}
+}
+
+// setSchedule records the schedule that started a session.
+func (s *sessionStore) setSchedule(id, schedule string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess, ok := s.sessions[id]; ok {
+		sess.Schedule = schedule
+		s.events.publish(evSessionUpdated, sess)
+	}
+}
+
+// setParent records the session a fork was started from.
+func (s *sessionStore) setParent(id, parentID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess, ok := s.sessions[id]; ok {
+		sess.ParentID = parentID
+		s.events.publish(evSessionUpdated, sess)
+	}
+}
+
+// scheduleActive reports whether a session started by a schedule is still
+// active.
+func (s *sessionStore) scheduleActive(schedule string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, sess := range s.sessions {
+		if sess.Schedule == schedule && sess.Status == "active" {
+			return true
+		}
+	}
+	return false
+}
+
+// setPipeline records where a session stands in a profile pipeline.
+func (s *sessionStore) setPipeline(id, promptArg, next, chain string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess, ok := s.sessions[id]; ok {
+		sess.PromptArg = promptArg
+		sess.Next = next
+		sess.Chain = chain
+		s.events.publish(evSessionUpdated, sess)
+	}
+}
+
+// claimNext marks the next stage of a completed session as started, and
+// returns the session. It returns false when the session has no next stage
+// to start, or when it was already claimed, so a stage is only started once.
+func (s *sessionStore) claimNext(id string) (*Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id]
+	if !ok || sess.Status != "completed" || sess.Next == "" || sess.NextStarted {
+		return nil, false
+	}
+	sess.NextStarted = true
+	s.events.publish(evSessionUpdated, sess)
+	return sess, true
+}
+
+// touch records activity on a session, postponing its idle timeout.
+func (s *sessionStore) touch(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess, ok := s.sessions[id]; ok {
+		sess.LastActivity = time.Now()
 	}
 }
 
@@ -269,6 +424,7 @@ func (s *sessionStore) setWindowTarget(id, target string) {
 	defer s.mu.Unlock()
 	if sess, ok := s.sessions[id]; ok {
 		sess.WindowTarget = target
+		s.events.publish(evSessionUpdated, sess)
 	}
 }
 
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/lthms/vee/internal/api"
+	"github.com/lthms/vee/internal/history"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxToolResultLen bounds the result summary kept for a tool call.
+const maxToolResultLen = 200
+
+// auditTools records every tool call a session makes through its MCP server
+// in the history store: arguments, result summary and latency. Calls the
+// profile may not make are recorded too, with the error they got.
+func auditTools(server *mcp.Server, hstore *history.Store, sessionID, profile string) {
+	server.AddReceivingMiddleware(func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			params, ok := req.GetParams().(*mcp.CallToolParamsRaw)
+			if method != "tools/call" || !ok {
+				return next(ctx, method, req)
+			}
+
+			start := time.Now()
+			res, err := next(ctx, method, req)
+			call := history.ToolCall{
+				SessionID:  sessionID,
+				Profile:    profile,
+				Tool:       params.Name,
+				Arguments:  string(params.Arguments),
+				DurationMS: time.Since(start).Milliseconds(),
+				CalledAt:   start.UTC().Format("2006-01-02T15:04:05Z"),
+			}
+			call.Result, call.IsError = summarizeToolResult(res, err)
+			if err := hstore.RecordToolCall(call); err != nil {
+				slog.Warn("failed to record tool call", "session", sessionID, "tool", params.Name, "error", err)
+			}
+			return res, err
+		}
+	})
+}
+
+// summarizeToolResult returns the first line of a tool call's text result,
+// or of its error, and whether the call failed.
+func summarizeToolResult(res mcp.Result, err error) (string, bool) {
+	if err != nil {
+		return truncateRunes(firstLine(err.Error()), maxToolResultLen), true
+	}
+	r, ok := res.(*mcp.CallToolResult)
+	if !ok {
+		return "", false
+	}
+	for _, c := range r.Content {
+		if text, ok := c.(*mcp.TextContent); ok {
+			return truncateRunes(firstLine(text.Text), maxToolResultLen), r.IsError
+		}
+	}
+	return "", r.IsError
+}
+
+// handleSessionToolCalls handles GET /api/session/tool-calls?id=<id> (or
+// ?window=<window_id>) to return the calls a session made to Vee's tools.
+func handleSessionToolCalls(app *App, hstore *history.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := r.URL.Query().Get("id")
+		var profile, indicator string
+		if window := r.URL.Query().Get("window"); id == "" && window != "" {
+			sess := app.Sessions.findByWindowTarget(window)
+			if sess == nil {
+				http.Error(w, "session not found", http.StatusNotFound)
+				return
+			}
+			id, profile, indicator = sess.ID, sess.Profile, sess.Indicator
+		} else if sess := app.Sessions.get(id); sess != nil {
+			profile, indicator = sess.Profile, sess.Indicator
+		}
+		if id == "" {
+			http.Error(w, "missing id or window query parameter", http.StatusBadRequest)
+			return
+		}
+
+		calls, err := hstore.ToolCalls(id)
+		if err != nil {
+			http.Error(w, "tool calls: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if calls == nil {
+			calls = []history.ToolCall{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(api.SessionToolCalls{
+			SessionID: id,
+			Profile:   profile,
+			Indicator: indicator,
+			Calls:     calls,
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/lthms/vee/internal/history"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestAuditTools(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	hstore, err := history.Open(filepath.Join(dir, "history.db"), filepath.Join(dir, "transcripts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hstore.Close()
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "vee", Version: "1.0.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "kb_query"}, func(ctx context.Context, req *mcp.CallToolRequest, args kbQueryArgs) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "Found 1 statement\n- tests run with go test"}}}, nil, nil
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "kb_touch"}, func(ctx context.Context, req *mcp.CallToolRequest, args struct{}) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{}, nil, nil
+	})
+	restrictTools(server, "s1", "normal", ToolPermissions{KB: kbRead})
+	auditTools(server, hstore, "s1", "normal")
+
+	clientT, serverT := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverT, nil); err != nil {
+		t.Fatal(err)
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "claude", Version: "1.0.0"}, nil)
+	cs, err := client.Connect(ctx, clientT, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+
+	if _, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "kb_query", Arguments: map[string]any{"query": "tests"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "kb_touch", Arguments: map[string]any{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	calls, err := hstore.ToolCalls("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("recorded %d calls, want 2: %+v", len(calls), calls)
+	}
+	if c := calls[0]; c.Tool != "kb_query" || c.Profile != "normal" || c.Arguments != `{"query":"tests"}` || c.Result != "Found 1 statement" || c.IsError {
+		t.Errorf("kb_query call = %+v", c)
+	}
+	if c := calls[1]; c.Tool != "kb_touch" || !c.IsError || c.Result != "The kb_touch tool is not available to the normal profile." {
+		t.Errorf("denied kb_touch call = %+v", c)
+	}
+}
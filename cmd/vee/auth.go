@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lthms/vee/internal/api"
+)
+
+// The daemon API is authenticated with bearer tokens. The daemon generates
+// a secret when it starts and writes it to the runtime directory, which
+// only the user can read: host commands find it there from the daemon's
+// port, and it opens every endpoint.
+//
+// Sessions get their own token, minted from the secret and the session ID
+// when the session's MCP config and hooks are written. A session token only
+// opens the MCP endpoint and the hooks of its own session, and GPG signing,
+// so that a container can't drive the rest of the instance.
+//
+// Host commands and sessions reach the daemon on its Unix socket instead,
+// which only the user can open: requests made on it need no token. TCP is
+// only left for containers, and for host commands when the socket is gone.
+
+// newInstanceSecret returns a random secret for a daemon run.
+func newInstanceSecret() string {
+	var b [32]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// instanceSecretPath returns where the daemon listening on port keeps its
+// secret.
+func instanceSecretPath(port int) string {
+	return filepath.Join(veeRuntimeDir(), fmt.Sprintf("daemon-%d.secret", port))
+}
+
+// writeInstanceSecret stores the secret of the daemon listening on port,
+// readable by the user only. The returned function removes it.
+func writeInstanceSecret(port int, secret string) (func(), error) {
+	if err := ensureRuntimeDir(); err != nil {
+		return nil, fmt.Errorf("create runtime dir: %w", err)
+	}
+	path := instanceSecretPath(port)
+	if err := os.WriteFile(path, []byte(secret), 0600); err != nil {
+		return nil, fmt.Errorf("write instance secret: %w", err)
+	}
+	return func() { os.Remove(path) }, nil
+}
+
+var instanceSecrets sync.Map // port → secret
+
+// readInstanceSecret returns the secret of the daemon listening on port.
+func readInstanceSecret(port int) (string, error) {
+	if secret, ok := instanceSecrets.Load(port); ok {
+		return secret.(string), nil
+	}
+	data, err := os.ReadFile(instanceSecretPath(port))
+	if err != nil {
+		return "", fmt.Errorf("read instance secret: %w", err)
+	}
+	secret := strings.TrimSpace(string(data))
+	instanceSecrets.Store(port, secret)
+	return secret, nil
+}
+
+// mintSessionToken returns the token of a session: its ID, and a MAC of it
+// under the instance secret.
+func mintSessionToken(secret, sessionID string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(sessionID))
+	return sessionID + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifySessionToken returns the session a token was minted for.
+func verifySessionToken(secret, token string) (string, bool) {
+	i := strings.LastIndexByte(token, '.')
+	if i <= 0 {
+		return "", false
+	}
+	sessionID := token[:i]
+	if !hmac.Equal([]byte(mintSessionToken(secret, sessionID)), []byte(token)) {
+		return "", false
+	}
+	return sessionID, true
+}
+
+// sessionToken mints the token of a session from a host command, with the
+// secret of the daemon listening on port.
+func sessionToken(port int, sessionID string) string {
+	secret, err := readInstanceSecret(port)
+	if err != nil {
+		slog.Warn("cannot mint session token, the session won't reach the daemon", "session", sessionID, "error", err)
+		return ""
+	}
+	return mintSessionToken(secret, sessionID)
+}
+
+// sessionScoped reports whether a request may be made with the token of
+// sessionID. Requests naming a session must name this one. MCP messages are
+// posted to the endpoint of an MCP session (?sessionid=), only known to
+// the client holding its stream.
+func sessionScoped(r *http.Request, sessionID string) bool {
+	switch {
+	case r.URL.Path == "/sse" && r.Method == http.MethodPost:
+		return true
+	case r.URL.Path == "/sse", strings.HasPrefix(r.URL.Path, "/api/hook/"):
+		return r.URL.Query().Get("session") == sessionID
+	case r.URL.Path == "/api/gpg/sign":
+		return true
+	}
+	return false
+}
+
+// daemonSocketPath returns the Unix socket of the daemon listening on port.
+func daemonSocketPath(port int) string {
+	return filepath.Join(veeRuntimeDir(), fmt.Sprintf("daemon-%d.sock", port))
+}
+
+// listenDaemonSocket listens on the Unix socket of the daemon listening on
+// port, readable and writable by the user only.
+func listenDaemonSocket(port int) (net.Listener, error) {
+	if err := ensureRuntimeDir(); err != nil {
+		return nil, fmt.Errorf("create runtime dir: %w", err)
+	}
+	path := daemonSocketPath(port)
+	os.Remove(path) // left behind by a daemon that didn't exit cleanly
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("restrict %s: %w", path, err)
+	}
+	return ln, nil
+}
+
+type socketConnKey struct{}
+
+// markSocketConns tags the requests received on the Unix socket, for
+// requireAuth. It is the http.Server ConnContext of the daemon.
+func markSocketConns(ctx context.Context, c net.Conn) context.Context {
+	if _, ok := c.(*net.UnixConn); ok {
+		return context.WithValue(ctx, socketConnKey{}, true)
+	}
+	return ctx
+}
+
+// newDaemonServer returns the HTTP server of the daemon, authenticating
+// the requests it receives over TCP.
+func newDaemonServer(secret string, mux http.Handler) *http.Server {
+	return &http.Server{
+		Handler:     requireAuth(secret, mux),
+		ConnContext: markSocketConns,
+	}
+}
+
+// requireAuth rejects requests without the instance secret, or a session
+// token valid for what they ask. Requests received on the Unix socket are
+// let through.
+func requireAuth(secret string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(socketConnKey{}) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		sessionID, ok := verifySessionToken(secret, token)
+		if !ok {
+			slog.Warn("rejected request with an invalid token", "path", r.URL.Path, "remote", r.RemoteAddr)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !sessionScoped(r, sessionID) {
+			slog.Warn("rejected request outside the session's scope", "path", r.URL.Path, "session", sessionID, "remote", r.RemoteAddr)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// daemonTransport sends the requests of host commands to the daemon
+// listening on the request's port over its Unix socket. Without a socket,
+// they go over TCP, authenticated with the daemon's secret. A rejected
+// secret is read again on the next request, in case another daemon took the
+// port.
+type daemonTransport struct {
+	base   http.RoundTripper
+	socket http.RoundTripper
+}
+
+func (t daemonTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" {
+		return t.base.RoundTrip(req)
+	}
+	port, err := strconv.Atoi(req.URL.Port())
+	if err != nil {
+		return t.base.RoundTrip(req)
+	}
+	if req.URL.Hostname() == "127.0.0.1" {
+		if _, err := os.Stat(daemonSocketPath(port)); err == nil {
+			return t.socket.RoundTrip(req)
+		}
+	}
+	if secret, err := readInstanceSecret(port); err == nil {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+secret)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		instanceSecrets.Delete(port)
+	}
+	return resp, err
+}
+
+// dialDaemonSocket connects to the Unix socket of the daemon whose loopback
+// address is addr.
+func dialDaemonSocket(ctx context.Context, _, addr string) (net.Conn, error) {
+	_, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("bad daemon port %q", portStr)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "unix", daemonSocketPath(port))
+}
+
+// daemonClient is the HTTP client host commands use to call the daemon.
+var daemonClient = &http.Client{Transport: daemonTransport{
+	base:   http.DefaultTransport,
+	socket: &http.Transport{DialContext: dialDaemonSocket},
+}}
+
+// daemonAPI returns a client of the API of the daemon listening on port.
+func daemonAPI(port int) *api.Client {
+	return api.New(fmt.Sprintf("http://127.0.0.1:%d", port), daemonClient)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestSessionToken(t *testing.T) {
+	token := mintSessionToken("secret", "s1")
+	if id, ok := verifySessionToken("secret", token); !ok || id != "s1" {
+		t.Errorf("verify(own token) = %q, %v", id, ok)
+	}
+	for _, bad := range []string{
+		mintSessionToken("other", "s1"),
+		"s2" + token[2:],
+		"s1",
+		"",
+	} {
+		if _, ok := verifySessionToken("secret", bad); ok {
+			t.Errorf("verify(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestRequireAuth(t *testing.T) {
+	handler := requireAuth("secret", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	s1 := mintSessionToken("secret", "s1")
+
+	tests := []struct {
+		method, target, token string
+		want                  int
+	}{
+		{"GET", "/api/state", "", http.StatusUnauthorized},
+		{"GET", "/api/state", "wrong", http.StatusUnauthorized},
+		{"GET", "/api/state", "secret", http.StatusOK},
+		{"POST", "/api/gpg/sign", "secret", http.StatusOK},
+		{"GET", "/sse?session=s2", "secret", http.StatusOK},
+
+		// Session tokens only open their own session's MCP stream and hooks
+		{"GET", "/sse?session=s1", s1, http.StatusOK},
+		{"GET", "/sse?session=s2", s1, http.StatusForbidden},
+		{"POST", "/sse?sessionid=abc", s1, http.StatusOK},
+		{"POST", "/api/hook/window-state?session=s1", s1, http.StatusOK},
+		{"POST", "/api/hook/transcript?session=s2", s1, http.StatusForbidden},
+		{"POST", "/api/gpg/sign?key=ABC", s1, http.StatusOK},
+		{"GET", "/api/state", s1, http.StatusForbidden},
+		{"GET", "/api/session/prompt?window=@1", s1, http.StatusForbidden},
+		{"POST", "/api/suspend", s1, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, nil)
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s with %q: got %d, want %d", tt.method, tt.target, tt.token, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestDaemonClientAuthenticates(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	var got string
+	srv := httptest.NewServer(requireAuth("s3cret", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+	})))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+	defer instanceSecrets.Delete(port)
+
+	resp, err := daemonClient.Get(srv.URL + "/api/state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("without a secret file: got %d, want 401", resp.StatusCode)
+	}
+
+	remove, err := writeInstanceSecret(port, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remove()
+
+	resp, err = daemonClient.Get(srv.URL + "/api/state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || got != "Bearer s3cret" {
+		t.Errorf("with the secret file: got %d, Authorization %q", resp.StatusCode, got)
+	}
+	if token := sessionToken(port, "s1"); token != mintSessionToken("s3cret", "s1") {
+		t.Errorf("sessionToken = %q", token)
+	}
+}
+
+func TestDaemonClientUsesSocket(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	const port = 1 // nothing listens there over TCP
+	ln, err := listenDaemonSocket(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newDaemonServer("s3cret", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	if info, err := os.Stat(daemonSocketPath(port)); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("socket: %v, %v", info, err)
+	}
+
+	// Requests on the socket need no token
+	resp, err := daemonClient.Get("http://127.0.0.1:1/api/state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("over the socket: got %d, want 200", resp.StatusCode)
+	}
+
+	// Requests over TCP still do
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(tcp)
+	resp, err = http.Get("http://" + tcp.Addr().String() + "/api/state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("over tcp: got %d, want 401", resp.StatusCode)
+	}
+}
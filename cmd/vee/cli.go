@@ -0,0 +1,313 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Public commands driving the Vee instance of the current directory from
+// scripts and editors, without going through tmux keybindings.
+
+// discoverInstance finds the Vee instance running for the current
+// directory, the way StartCmd does, and returns its daemon port.
+func discoverInstance() (int, error) {
+	tmuxSocketName = instanceSocket()
+	if !tmuxSessionExists() {
+		return 0, fmt.Errorf("no Vee instance is running in this directory (start one with vee start)")
+	}
+	port, err := discoverDaemonPort()
+	if err != nil || !daemonAlive(port) {
+		return 0, fmt.Errorf("the Vee daemon of this directory is not responding")
+	}
+	return port, nil
+}
+
+// listSessions returns the sessions known to the daemon, oldest first.
+func listSessions(port int) ([]*Session, error) {
+	state, err := daemonAPI(port).State()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := append(append(state.Active, state.Suspended...), state.Completed...)
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].StartedAt.Before(sessions[j].StartedAt)
+	})
+	return sessions, nil
+}
+
+// resolveSession returns the session whose ID starts with prefix, so the
+// short IDs shown by vee ls can be used.
+func resolveSession(sessions []*Session, prefix string) (*Session, error) {
+	var matches []*Session
+	for _, sess := range sessions {
+		if sess.ID == prefix {
+			return sess, nil
+		}
+		if strings.HasPrefix(sess.ID, prefix) {
+			matches = append(matches, sess)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no session matches %q", prefix)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("session prefix %q is ambiguous", prefix)
+	}
+}
+
+// findSession discovers the instance and resolves a session ID prefix.
+func findSession(prefix string) (int, *Session, error) {
+	port, err := discoverInstance()
+	if err != nil {
+		return 0, nil, err
+	}
+	sessions, err := listSessions(port)
+	if err != nil {
+		return 0, nil, fmt.Errorf("list sessions: %w", err)
+	}
+	sess, err := resolveSession(sessions, prefix)
+	if err != nil {
+		return 0, nil, err
+	}
+	return port, sess, nil
+}
+
+// LsCmd lists the sessions of the current directory's instance.
+type LsCmd struct {
+	JSON bool `name:"json" help:"Print the sessions as JSON."`
+	All  bool `short:"a" name:"all" help:"List the sessions of every project served by the user-level daemon."`
+}
+
+// projectSessions are the sessions of a project, for vee ls --all.
+type projectSessions struct {
+	Dir      string     `json:"dir"`
+	Port     int        `json:"port"`
+	Sessions []*Session `json:"sessions"`
+}
+
+// Run prints the sessions, oldest first.
+func (cmd *LsCmd) Run() error {
+	if cmd.All {
+		return cmd.runAll()
+	}
+	port, err := discoverInstance()
+	if err != nil {
+		return err
+	}
+	sessions, err := listSessions(port)
+	if err != nil {
+		return fmt.Errorf("list sessions: %w", err)
+	}
+
+	if cmd.JSON {
+		if sessions == nil {
+			sessions = []*Session{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(sessions)
+	}
+
+	if len(sessions) == 0 {
+		fmt.Println("No sessions.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, sess := range sessions {
+		fmt.Fprintln(tw, formatSessionRow(sess))
+	}
+	return tw.Flush()
+}
+
+// runAll prints the sessions of every project served by the user-level
+// daemon, project by project.
+func (cmd *LsCmd) runAll() error {
+	projects, err := listProjects()
+	if err != nil {
+		return err
+	}
+	all := make([]projectSessions, 0, len(projects))
+	for _, p := range projects {
+		sessions, err := listSessions(p.Port)
+		if err != nil {
+			return fmt.Errorf("list sessions of %s: %w", p.Dir, err)
+		}
+		if sessions == nil {
+			sessions = []*Session{}
+		}
+		all = append(all, projectSessions{Dir: p.Dir, Port: p.Port, Sessions: sessions})
+	}
+
+	if cmd.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(all)
+	}
+
+	if len(all) == 0 {
+		fmt.Println("No projects.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, p := range all {
+		project := shortenHome(p.Dir)
+		if len(p.Sessions) == 0 {
+			fmt.Fprintf(tw, "%s\t\t(no sessions)\n", project)
+		}
+		for _, sess := range p.Sessions {
+			fmt.Fprintf(tw, "%s\t%s\n", project, formatSessionRow(sess))
+		}
+	}
+	return tw.Flush()
+}
+
+// formatSessionRow formats a session as tab-separated columns: short ID,
+// status, profile, age and preview.
+func formatSessionRow(sess *Session) string {
+	profile := sess.Indicator + " " + sess.Profile
+	if sess.Ephemeral {
+		profile += " ⏣"
+	}
+	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s", shortID(sess.ID), sess.Status, profile,
+		formatAge(time.Since(sess.StartedAt)), firstLine(sess.Preview))
+}
+
+// NewCmd starts a session in the current directory's instance and prints
+// its ID.
+type NewCmd struct {
+	Profile   string `required:"" short:"p" help:"Profile of the session."`
+	Prompt    string `help:"Initial prompt, expanded by the profile's prompt template."`
+	Ephemeral bool   `help:"Run the session in an ephemeral Docker container."`
+	Job       bool   `help:"Queue the session as a headless background job."`
+}
+
+// Run creates the session through _new-pane, like the picker does.
+func (cmd *NewCmd) Run() error {
+	port, err := discoverInstance()
+	if err != nil {
+		return err
+	}
+	cfg, err := fetchAppConfig(port)
+	if err != nil {
+		return fmt.Errorf("failed to fetch config from daemon: %w", err)
+	}
+	if err := initProfileRegistry(cfg.VeePath); err != nil {
+		return fmt.Errorf("failed to init profile registry: %w", err)
+	}
+	profile, ok := profileRegistry[cmd.Profile]
+	if !ok {
+		return fmt.Errorf("unknown profile: %s", cmd.Profile)
+	}
+
+	sessionID := newUUID()
+	pane := &NewPaneCmd{
+		VeePath:    cfg.VeePath,
+		Port:       port,
+		Profile:    profile.Name,
+		Prompt:     expandPrompt(profile.DefaultPrompt, cmd.Prompt),
+		Arg:        cmd.Prompt,
+		Ephemeral:  cmd.Ephemeral,
+		Job:        cmd.Job || (profile.Headless && !cmd.Ephemeral && cmd.Prompt != ""),
+		SessionID:  sessionID,
+		TmuxSocket: tmuxSocketName,
+	}
+	if err := pane.Run(claudeArgs(cfg.Passthrough)); err != nil {
+		return err
+	}
+
+	fmt.Println(sessionID)
+	return nil
+}
+
+// SuspendCmd suspends a session of the current directory's instance.
+type SuspendCmd struct {
+	ID string `arg:"" help:"Session ID (or unique prefix)."`
+}
+
+// Run suspends the session, as Ctrl-b q does. Ephemeral sessions are
+// completed instead.
+func (cmd *SuspendCmd) Run() error {
+	port, sess, err := findSession(cmd.ID)
+	if err != nil {
+		return err
+	}
+	if sess.Status != "active" {
+		return fmt.Errorf("session %s is %s", shortID(sess.ID), sess.Status)
+	}
+	_, err = daemonAPI(port).Suspend(sess.WindowTarget)
+	return err
+}
+
+// KillCmd completes a session of the current directory's instance.
+type KillCmd struct {
+	ID string `arg:"" help:"Session ID (or unique prefix)."`
+}
+
+// Run completes the session, as Ctrl-b k does.
+func (cmd *KillCmd) Run() error {
+	port, sess, err := findSession(cmd.ID)
+	if err != nil {
+		return err
+	}
+	if sess.Status != "active" {
+		return fmt.Errorf("session %s is %s", shortID(sess.ID), sess.Status)
+	}
+	_, err = daemonAPI(port).Complete(sess.WindowTarget)
+	return err
+}
+
+// ResumeCmd resumes a suspended session of the current directory's
+// instance.
+type ResumeCmd struct {
+	ID string `arg:"" help:"Session ID (or unique prefix)."`
+}
+
+// Run resumes the session in a new window, as Ctrl-b r does.
+func (cmd *ResumeCmd) Run() error {
+	port, sess, err := findSession(cmd.ID)
+	if err != nil {
+		return err
+	}
+	if sess.Status != "suspended" {
+		return fmt.Errorf("session %s is %s", shortID(sess.ID), sess.Status)
+	}
+	resume := &ResumeSessionCmd{
+		Port:       port,
+		SessionID:  sess.ID,
+		Profile:    sess.Profile,
+		TmuxSocket: tmuxSocketName,
+	}
+	return resume.Run()
+}
+
+// AttachCmd attaches the terminal to the window of a session.
+type AttachCmd struct {
+	ID string `arg:"" help:"Session ID (or unique prefix)."`
+}
+
+// Run selects the session's window and attaches to the instance.
+func (cmd *AttachCmd) Run() error {
+	_, sess, err := findSession(cmd.ID)
+	if err != nil {
+		return err
+	}
+	if sess.Status != "active" {
+		return fmt.Errorf("session %s is %s (resume it first)", shortID(sess.ID), sess.Status)
+	}
+	if _, err := tmuxRun("select-window", "-t", sess.WindowTarget); err != nil {
+		return fmt.Errorf("select window: %w", err)
+	}
+	err = tmuxAttach()
+	fmt.Print("\033[H\033[2J")
+	return err
+}
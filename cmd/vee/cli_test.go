@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestResolveSession(t *testing.T) {
+	sessions := []*Session{
+		{ID: "3f2a9c10-aaaa"},
+		{ID: "3f2b0d44-bbbb"},
+		{ID: "3f2b0d44-cccc"},
+		{ID: "3f2b"},
+	}
+
+	tests := []struct {
+		prefix  string
+		want    string
+		wantErr bool
+	}{
+		{"3f2a", "3f2a9c10-aaaa", false},
+		{"3f2b0d44-b", "3f2b0d44-bbbb", false},
+		{"3f2b", "3f2b", false}, // an exact ID wins over longer matches
+		{"3f2", "", true},       // ambiguous
+		{"ffff", "", true},      // unknown
+	}
+	for _, tt := range tests {
+		sess, err := resolveSession(sessions, tt.prefix)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("resolveSession(%q): expected an error, got %s", tt.prefix, sess.ID)
+			}
+			continue
+		}
+		if err != nil || sess.ID != tt.want {
+			t.Errorf("resolveSession(%q) = %v, %v, want %s", tt.prefix, sess, err, tt.want)
+		}
+	}
+}
@@ -11,10 +11,14 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	gcfg "github.com/go-git/gcfg/v2"
+	"github.com/lthms/vee/internal/feedback"
+	"github.com/lthms/vee/internal/history"
 	"github.com/lthms/vee/internal/kb"
 )
 
@@ -23,13 +27,106 @@ type UserConfig struct {
 	Embedding EmbeddingConfig
 	Identity  *IdentityConfig
 	Feedback  FeedbackConfig
+	Pipeline  PipelineConfig
+	Jobs      JobsConfig
+	Sessions  SessionsConfig
+	Notify    NotifyConfig
+	Daemon    DaemonConfig
 }
 
 // FeedbackConfig configures profile feedback sampling.
 type FeedbackConfig struct {
 	MaxExamples int
+	// Relevance weighs prompt similarity against recency when sampling
+	// (0 = recency only, 1 = similarity only).
+	Relevance float64
+	// GroupExamples and GlobalExamples are the quotas for examples shared
+	// with the profile's groups and with all profiles; MaxExamples covers
+	// examples recorded for the profile itself.
+	GroupExamples  int
+	GlobalExamples int
+	// MaxPinned is the separate budget for pinned examples.
+	MaxPinned int
+	// Seeded derives the sampling seed from the session ID, so a session
+	// always gets the same examples.
+	Seeded bool
 }
 
+// PipelineConfig configures how profile pipelines advance.
+type PipelineConfig struct {
+	// Autostart starts the next stage as soon as a session completes,
+	// instead of offering it.
+	Autostart bool
+}
+
+// DaemonConfig configures where the daemon of an instance runs.
+type DaemonConfig struct {
+	// Shared serves the instance from the user-level daemon, shared by
+	// every project, instead of a daemon of its own.
+	Shared bool
+}
+
+// JobsConfig configures background jobs.
+type JobsConfig struct {
+	// Concurrency is how many jobs may run at once; the others wait in
+	// the queue.
+	Concurrency int
+}
+
+// SessionsConfig configures session time limits. A zero duration disables
+// the corresponding limit.
+type SessionsConfig struct {
+	// IdleTimeout suspends a non-ephemeral session that has seen no
+	// activity for this long.
+	IdleTimeout time.Duration
+	// EphemeralMaxDuration completes an ephemeral session, removing its
+	// container, this long after it started.
+	EphemeralMaxDuration time.Duration
+	// EphemeralWarning is how long before the end of an ephemeral session
+	// a warning is shown.
+	EphemeralWarning time.Duration
+}
+
+// NotifyConfig configures notifications sent outside tmux when a session
+// needs attention.
+type NotifyConfig struct {
+	// Sinks are the enabled sinks: "desktop", "bell", "webhook" and
+	// "command".
+	Sinks []string
+	// Webhook is the URL the webhook sink POSTs to.
+	Webhook string
+	// Command is the shell command run by the command sink.
+	Command string
+	// Events are the session events notified: "notification", "turn" and
+	// "ended".
+	Events []string
+	// Debounce is the minimum delay between two notifications of the same
+	// event for a session.
+	Debounce time.Duration
+	// Profiles overrides the sinks and events for a profile, from
+	// [notify "profile"] sections.
+	Profiles map[string]NotifyRule
+}
+
+// NotifyRule overrides the notification settings for a profile. A nil list
+// keeps the global setting; "none" gives an empty one.
+type NotifyRule struct {
+	Sinks  []string
+	Events []string
+}
+
+// defaultNotifyDebounce is the minimum delay between two identical
+// notifications, unless configured otherwise.
+const defaultNotifyDebounce = 30 * time.Second
+
+// defaultEphemeralWarning is how long before an ephemeral session reaches
+// its maximum duration the warning is shown, unless configured otherwise.
+const defaultEphemeralWarning = 5 * time.Minute
+
+// defaultJobConcurrency is how many background jobs run at once unless
+// configured otherwise.
+const defaultJobConcurrency = 2
+
 // IdentityConfig configures the assistant's identity (name + git author).
 type IdentityConfig struct {
 	Name    string
@@ -48,11 +145,27 @@ type ProjectConfig struct {
 	Ephemeral *EphemeralConfig
 	Identity  *IdentityConfig
 	Platforms *PlatformsConfig
+	Schedules []ScheduleConfig
+}
+
+// ScheduleConfig holds a [schedule "name"] section of .vee/config: a
+// session the daemon starts on a cron schedule.
+type ScheduleConfig struct {
+	Name      string
+	Cron      string
+	Profile   string
+	Prompt    string
+	Ephemeral bool
 }
 
 // readProjectTOML reads and parses .vee/config from the current directory.
 func readProjectTOML() (*ProjectConfig, error) {
-	m, err := parseConfig(".vee/config", nil)
+	return readProjectTOMLIn(".")
+}
+
+// readProjectTOMLIn reads and parses .vee/config from the project in dir.
+func readProjectTOMLIn(dir string) (*ProjectConfig, error) {
+	m, err := parseConfig(filepath.Join(dir, ".vee", "config"), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -60,8 +173,10 @@ func readProjectTOML() (*ProjectConfig, error) {
 }
 
 // parseConfig reads a git-config-format file and returns a flat map of
-// "section.key" → []string values. It handles [include] and [includeIf]
-// directives recursively. The seen map prevents infinite include cycles.
+// "section.key" → []string values; keys of a [section "sub"] are stored as
+// "section.sub.key", keeping the subsection's case. It handles [include] and
+// [includeIf] directives recursively. The seen map prevents infinite include
+// cycles.
 func parseConfig(path string, seen map[string]bool) (map[string][]string, error) {
 	if seen == nil {
 		seen = make(map[string]bool)
@@ -132,6 +247,9 @@ func parseConfig(path string, seen map[string]bool) (map[string][]string, error)
 		}
 
 		mapKey := sec + "." + strings.ToLower(key)
+		if sub != "" {
+			mapKey = sec + "." + sub + "." + strings.ToLower(key)
+		}
 		if blank {
 			return nil
 		}
@@ -319,6 +437,9 @@ func hydrateProjectConfig(m map[string][]string) *ProjectConfig {
 		cfg.Identity.Disable = disable == "true"
 	}
 
+	// [schedule "name"]
+	cfg.Schedules = hydrateSchedules(m)
+
 	// [platforms]
 	if forge := lastValue(m, "platforms.forge"); forge != "" {
 		if cfg.Platforms == nil {
@@ -336,6 +457,45 @@ func hydrateProjectConfig(m map[string][]string) *ProjectConfig {
 	return cfg
 }
 
+// hydrateSchedules collects the [schedule "name"] sections, sorted by name.
+// Sections without a cron expression or a profile are skipped.
+func hydrateSchedules(m map[string][]string) []ScheduleConfig {
+	byName := make(map[string]*ScheduleConfig)
+	var names []string
+	for key := range m {
+		rest, ok := strings.CutPrefix(key, "schedule.")
+		if !ok {
+			continue
+		}
+		i := strings.LastIndex(rest, ".")
+		if i < 0 {
+			continue
+		}
+		name := rest[:i]
+		if _, ok := byName[name]; !ok {
+			byName[name] = &ScheduleConfig{Name: name}
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var schedules []ScheduleConfig
+	for _, name := range names {
+		sc := byName[name]
+		prefix := "schedule." + name + "."
+		sc.Cron = lastValue(m, prefix+"cron")
+		sc.Profile = lastValue(m, prefix+"profile")
+		sc.Prompt = lastValue(m, prefix+"prompt")
+		sc.Ephemeral = lastValue(m, prefix+"ephemeral") == "true"
+		if sc.Cron == "" || sc.Profile == "" {
+			slog.Warn("schedule needs cron and profile, skipping", "schedule", name)
+			continue
+		}
+		schedules = append(schedules, *sc)
+	}
+	return schedules
+}
+
 // parseMountSpec parses a "source:target[:mode]" string into a MountSpec.
 func parseMountSpec(s string) (MountSpec, error) {
 	parts := strings.SplitN(s, ":", 3)
@@ -363,7 +523,21 @@ func hydrateUserConfig(m map[string][]string) *UserConfig {
 			DupThreshold: 0.85,
 		},
 		Feedback: FeedbackConfig{
-			MaxExamples: 5,
+			MaxExamples:    5,
+			Relevance:      0.5,
+			MaxPinned:      5,
+			GroupExamples:  2,
+			GlobalExamples: 2,
+		},
+		Jobs: JobsConfig{
+			Concurrency: defaultJobConcurrency,
+		},
+		Sessions: SessionsConfig{
+			EphemeralWarning: defaultEphemeralWarning,
+		},
+		Notify: NotifyConfig{
+			Events:   []string{"notification", "ended"},
+			Debounce: defaultNotifyDebounce,
 		},
 	}
 
@@ -416,10 +590,140 @@ func hydrateUserConfig(m map[string][]string) *UserConfig {
 			cfg.Feedback.MaxExamples = v
 		}
 	}
+	if rel := lastValue(m, "feedback.relevance"); rel != "" {
+		if v, err := strconv.ParseFloat(rel, 64); err == nil {
+			cfg.Feedback.Relevance = v
+		}
+	}
+	if ge := lastValue(m, "feedback.groupexamples"); ge != "" {
+		if v, err := strconv.Atoi(ge); err == nil {
+			cfg.Feedback.GroupExamples = v
+		}
+	}
+	if gl := lastValue(m, "feedback.globalexamples"); gl != "" {
+		if v, err := strconv.Atoi(gl); err == nil {
+			cfg.Feedback.GlobalExamples = v
+		}
+	}
+	if mp := lastValue(m, "feedback.maxpinned"); mp != "" {
+		if v, err := strconv.Atoi(mp); err == nil {
+			cfg.Feedback.MaxPinned = v
+		}
+	}
+	if seeded := lastValue(m, "feedback.seeded"); seeded != "" {
+		cfg.Feedback.Seeded = seeded == "true"
+	}
+
+	// [pipeline]
+	if auto := lastValue(m, "pipeline.autostart"); auto != "" {
+		cfg.Pipeline.Autostart = auto == "true"
+	}
+
+	// [daemon]
+	if shared := lastValue(m, "daemon.shared"); shared != "" {
+		cfg.Daemon.Shared = shared == "true"
+	}
+
+	// [jobs]
+	if c := lastValue(m, "jobs.concurrency"); c != "" {
+		if v, err := strconv.Atoi(c); err == nil && v > 0 {
+			cfg.Jobs.Concurrency = v
+		}
+	}
+
+	// [sessions]
+	if d := lastValue(m, "sessions.idletimeout"); d != "" {
+		if v, err := time.ParseDuration(d); err == nil && v >= 0 {
+			cfg.Sessions.IdleTimeout = v
+		} else {
+			slog.Warn("invalid sessions.idletimeout, ignoring", "value", d)
+		}
+	}
+	if d := lastValue(m, "sessions.ephemeralmaxduration"); d != "" {
+		if v, err := time.ParseDuration(d); err == nil && v >= 0 {
+			cfg.Sessions.EphemeralMaxDuration = v
+		} else {
+			slog.Warn("invalid sessions.ephemeralmaxduration, ignoring", "value", d)
+		}
+	}
+	if d := lastValue(m, "sessions.ephemeralwarning"); d != "" {
+		if v, err := time.ParseDuration(d); err == nil && v >= 0 {
+			cfg.Sessions.EphemeralWarning = v
+		} else {
+			slog.Warn("invalid sessions.ephemeralwarning, ignoring", "value", d)
+		}
+	}
+
+	// [notify] and [notify "profile"]
+	if sinks := listValue(m, "notify.sinks"); sinks != nil {
+		cfg.Notify.Sinks = sinks
+	}
+	cfg.Notify.Webhook = lastValue(m, "notify.webhook")
+	cfg.Notify.Command = lastValue(m, "notify.command")
+	if events := listValue(m, "notify.events"); events != nil {
+		cfg.Notify.Events = events
+	}
+	if d := lastValue(m, "notify.debounce"); d != "" {
+		if v, err := time.ParseDuration(d); err == nil && v >= 0 {
+			cfg.Notify.Debounce = v
+		} else {
+			slog.Warn("invalid notify.debounce, ignoring", "value", d)
+		}
+	}
+	cfg.Notify.Profiles = hydrateNotifyRules(m)
 
 	return cfg
 }
 
+// hydrateNotifyRules collects the [notify "profile"] sections.
+func hydrateNotifyRules(m map[string][]string) map[string]NotifyRule {
+	rules := make(map[string]NotifyRule)
+	for key := range m {
+		rest, ok := strings.CutPrefix(key, "notify.")
+		if !ok {
+			continue
+		}
+		i := strings.LastIndex(rest, ".")
+		if i < 0 {
+			continue
+		}
+		profile := rest[:i]
+		if _, ok := rules[profile]; ok {
+			continue
+		}
+		prefix := "notify." + profile + "."
+		rules[profile] = NotifyRule{
+			Sinks:  listValue(m, prefix+"sinks"),
+			Events: listValue(m, prefix+"events"),
+		}
+	}
+	return rules
+}
+
+// listValue returns the comma-separated values of a key, over all its
+// occurrences. It returns nil if the key is absent, and an empty list for
+// "none".
+func listValue(m map[string][]string, key string) []string {
+	vals, ok := m[key]
+	if !ok {
+		return nil
+	}
+	list := []string{}
+	for _, v := range vals {
+		for item := range strings.SplitSeq(v, ",") {
+			item = strings.ToLower(strings.TrimSpace(item))
+			if item == "none" {
+				list = list[:0]
+				continue
+			}
+			if item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
 // resolveIdentity merges user-level and project-level identity configs.
 // Project disable=true suppresses identity entirely.
 // Field-level merge: user provides defaults, project overrides non-empty fields.
@@ -601,12 +905,9 @@ func ensureOllamaModel(baseURL, model string) error {
 }
 
 // openKB creates the embedding model (Ollama), ensures the model is available,
-// and opens the knowledge base.
-func openKB(userCfg *UserConfig) (*kb.KnowledgeBase, error) {
-	embedModel := &OllamaModel{
-		URL:   userCfg.Embedding.URL,
-		Model: userCfg.Embedding.Model,
-	}
+// and opens the knowledge base. onEvent receives the progress of its worker.
+func openKB(userCfg *UserConfig, onEvent func(kb.Event)) (*kb.KnowledgeBase, error) {
+	embedModel := newEmbeddingModel(userCfg)
 
 	if err := ensureOllamaModel(embedModel.URL, userCfg.Embedding.Model); err != nil {
 		return nil, fmt.Errorf("ensure ollama embedding model: %w", err)
@@ -624,6 +925,7 @@ func openKB(userCfg *UserConfig) (*kb.KnowledgeBase, error) {
 		Threshold:      userCfg.Embedding.Threshold,
 		MaxResults:     userCfg.Embedding.MaxResults,
 		DupThreshold:   userCfg.Embedding.DupThreshold,
+		OnEvent:        onEvent,
 	})
 	if err != nil {
 		return nil, err
@@ -632,6 +934,42 @@ func openKB(userCfg *UserConfig) (*kb.KnowledgeBase, error) {
 	return kbase, nil
 }
 
+// newEmbeddingModel returns the embedding backend configured in [embedding].
+func newEmbeddingModel(userCfg *UserConfig) *OllamaModel {
+	return &OllamaModel{
+		URL:   userCfg.Embedding.URL,
+		Model: userCfg.Embedding.Model,
+	}
+}
+
+// openFeedbackStore opens the feedback store in the state directory, with
+// relevance sampling backed by the configured embedding model.
+func openFeedbackStore(userCfg *UserConfig) (*feedback.Store, error) {
+	stDir, err := stateDir()
+	if err != nil {
+		return nil, fmt.Errorf("state dir: %w", err)
+	}
+	fstore, err := feedback.Open(filepath.Join(stDir, "feedback.db"))
+	if err != nil {
+		return nil, fmt.Errorf("open feedback store: %w", err)
+	}
+	fstore.EnableRelevance(newEmbeddingModel(userCfg), userCfg.Embedding.Model, userCfg.Feedback.Relevance)
+	return fstore, nil
+}
+
+// openHistoryStore opens the session archive in the state directory.
+func openHistoryStore() (*history.Store, error) {
+	stDir, err := stateDir()
+	if err != nil {
+		return nil, fmt.Errorf("state dir: %w", err)
+	}
+	hstore, err := history.Open(filepath.Join(stDir, "history.db"), filepath.Join(stDir, "transcripts"))
+	if err != nil {
+		return nil, fmt.Errorf("open history store: %w", err)
+	}
+	return hstore, nil
+}
+
 func stateDir() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
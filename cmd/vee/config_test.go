@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseConfigBasic(t *testing.T) {
@@ -353,13 +354,53 @@ func TestHydrateProjectConfigComposeOnly(t *testing.T) {
 	}
 }
 
+func TestHydrateProjectConfigSchedules(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config")
+	os.WriteFile(path, []byte(`[schedule "weekly.kb-audit"]
+	cron = @weekly
+	profile = vibe
+	prompt = Audit the KB for stale facts
+[schedule "nightly-triage"]
+	cron = 0 2 * * *
+	profile = issue
+	prompt = Triage new issues
+	ephemeral = true
+[schedule "incomplete"]
+	cron = @daily
+`), 0600)
+
+	m, err := parseConfig(path, nil)
+	if err != nil {
+		t.Fatalf("parseConfig: %v", err)
+	}
+
+	cfg := hydrateProjectConfig(m)
+	if len(cfg.Schedules) != 2 {
+		t.Fatalf("expected 2 schedules, got %+v", cfg.Schedules)
+	}
+	triage := cfg.Schedules[0]
+	if triage.Name != "nightly-triage" || triage.Cron != "0 2 * * *" || triage.Profile != "issue" || !triage.Ephemeral {
+		t.Errorf("unexpected first schedule: %+v", triage)
+	}
+	audit := cfg.Schedules[1]
+	if audit.Name != "weekly.kb-audit" || audit.Prompt != "Audit the KB for stale facts" || audit.Ephemeral {
+		t.Errorf("unexpected second schedule: %+v", audit)
+	}
+}
+
 func TestHydrateUserConfig(t *testing.T) {
 	m := map[string][]string{
-		"embedding.model":      {"mxbai-embed-large"},
-		"embedding.threshold":  {"0.5"},
-		"embedding.maxresults": {"20"},
-		"identity.name":        {"Vee"},
-		"identity.email":       {"vee@example.com"},
+		"embedding.model":               {"mxbai-embed-large"},
+		"embedding.threshold":           {"0.5"},
+		"embedding.maxresults":          {"20"},
+		"identity.name":                 {"Vee"},
+		"identity.email":                {"vee@example.com"},
+		"pipeline.autostart":            {"true"},
+		"daemon.shared":                 {"true"},
+		"jobs.concurrency":              {"4"},
+		"sessions.idletimeout":          {"48h"},
+		"sessions.ephemeralmaxduration": {"4h"},
 	}
 
 	cfg := hydrateUserConfig(m)
@@ -378,6 +419,24 @@ func TestHydrateUserConfig(t *testing.T) {
 	if cfg.Identity == nil || cfg.Identity.Name != "Vee" {
 		t.Errorf("Identity.Name = %v", cfg.Identity)
 	}
+	if !cfg.Pipeline.Autostart {
+		t.Error("Pipeline.Autostart = false, want true")
+	}
+	if !cfg.Daemon.Shared {
+		t.Error("Daemon.Shared = false, want true")
+	}
+	if cfg.Jobs.Concurrency != 4 {
+		t.Errorf("Jobs.Concurrency = %d, want 4", cfg.Jobs.Concurrency)
+	}
+	if cfg.Sessions.IdleTimeout != 48*time.Hour {
+		t.Errorf("Sessions.IdleTimeout = %v, want 48h", cfg.Sessions.IdleTimeout)
+	}
+	if cfg.Sessions.EphemeralMaxDuration != 4*time.Hour {
+		t.Errorf("Sessions.EphemeralMaxDuration = %v, want 4h", cfg.Sessions.EphemeralMaxDuration)
+	}
+	if cfg.Sessions.EphemeralWarning != defaultEphemeralWarning {
+		t.Errorf("Sessions.EphemeralWarning = %v, want default", cfg.Sessions.EphemeralWarning)
+	}
 }
 
 func TestHydrateUserConfigDefaults(t *testing.T) {
@@ -397,6 +456,43 @@ func TestHydrateUserConfigDefaults(t *testing.T) {
 	if cfg.Embedding.DupThreshold != 0.85 {
 		t.Errorf("default DupThreshold = %f", cfg.Embedding.DupThreshold)
 	}
+	if cfg.Jobs.Concurrency != defaultJobConcurrency {
+		t.Errorf("default Jobs.Concurrency = %d", cfg.Jobs.Concurrency)
+	}
+	if cfg.Sessions.IdleTimeout != 0 || cfg.Sessions.EphemeralMaxDuration != 0 {
+		t.Errorf("default session limits = %+v, want disabled", cfg.Sessions)
+	}
+}
+
+func TestHydrateUserConfigNotify(t *testing.T) {
+	m := map[string][]string{
+		"notify.sinks":           {"desktop, webhook", "bell"},
+		"notify.webhook":         {"https://example.com/hook"},
+		"notify.debounce":        {"1m"},
+		"notify.vibe.events":     {"turn,ended"},
+		"notify.implement.sinks": {"none"},
+	}
+
+	cfg := hydrateUserConfig(m)
+	if got := strings.Join(cfg.Notify.Sinks, ","); got != "desktop,webhook,bell" {
+		t.Errorf("Notify.Sinks = %q", got)
+	}
+	if cfg.Notify.Webhook != "https://example.com/hook" {
+		t.Errorf("Notify.Webhook = %q", cfg.Notify.Webhook)
+	}
+	if got := strings.Join(cfg.Notify.Events, ","); got != "notification,ended" {
+		t.Errorf("default Notify.Events = %q", got)
+	}
+	if cfg.Notify.Debounce != time.Minute {
+		t.Errorf("Notify.Debounce = %v, want 1m", cfg.Notify.Debounce)
+	}
+	vibe := cfg.Notify.Profiles["vibe"]
+	if strings.Join(vibe.Events, ",") != "turn,ended" || vibe.Sinks != nil {
+		t.Errorf("vibe rule = %+v", vibe)
+	}
+	if implement := cfg.Notify.Profiles["implement"]; implement.Sinks == nil || len(implement.Sinks) != 0 {
+		t.Errorf("implement rule = %+v, want no sinks", implement)
+	}
 }
 
 func TestResolveIdentity(t *testing.T) {
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -10,18 +11,23 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/lthms/vee/internal/api"
 	"github.com/lthms/vee/internal/feedback"
+	"github.com/lthms/vee/internal/history"
 	"github.com/lthms/vee/internal/kb"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-// DaemonCmd runs the Vee daemon (MCP server + API).
-type DaemonCmd struct{}
+// DaemonCmd runs the Vee daemon (MCP server + API), or the user-level daemon
+// serving every project.
+type DaemonCmd struct {
+	User bool `name:"user" help:"Run the user-level daemon serving every project (see [daemon] shared)."`
+}
 
 // MCP tool args
 
@@ -45,17 +51,26 @@ type feedbackRecordArgs struct {
 	Kind      string `json:"kind" jsonschema:"Whether this is a good or bad example (good or bad)"`
 	Statement string `json:"statement" jsonschema:"The example or counter-example statement"`
 	Scope     string `json:"scope" jsonschema:"Scope: user (all projects) or project (this project only)"`
+	Pinned    bool   `json:"pinned,omitempty" jsonschema:"Always include this example in future prompts instead of sampling it"`
+	Target    string `json:"target,omitempty" jsonschema:"Which profiles the example applies to: profile (default, the current profile only), group:<name> (every profile in a group the current profile belongs to), or all (every profile)"`
+	Force     bool   `json:"force,omitempty" jsonschema:"Record the example even if it contradicts existing examples of the opposite kind"`
 }
 
-// newMCPServer creates a fresh MCP server with all tools registered.
-// Called once per SSE connection so each session gets its own initialization lifecycle.
-// sessionID scopes request_suspend to a specific session.
-func newMCPServer(app *App, kbase *kb.KnowledgeBase, fstore *feedback.Store, sessionID string) *mcp.Server {
+// newMCPServer creates a fresh MCP server with the tools the session's
+// profile allows. Called once per SSE connection so each session gets its own
+// initialization lifecycle. sessionID scopes request_suspend to a specific
+// session. Tool calls are recorded in hstore.
+func newMCPServer(app *App, kbase *kb.KnowledgeBase, fstore *feedback.Store, hstore *history.Store, sessionID string) *mcp.Server {
 	server := mcp.NewServer(&mcp.Implementation{
 		Name:    "vee",
 		Version: "1.0.0",
 	}, nil)
 
+	profile, perms, ok := sessionPermissions(app, sessionID, mcpRegistrationWait)
+	if !ok {
+		slog.Warn("mcp connection from an unknown session, no tools available", "session", sessionID)
+	}
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "request_suspend",
 		Description: "Request that the current Vee session be suspended so it can be resumed later.",
@@ -78,11 +93,13 @@ func newMCPServer(app *App, kbase *kb.KnowledgeBase, fstore *feedback.Store, ses
 		}
 		app.Sessions.setStatus(sess.ID, "suspended")
 		slog.Debug("session suspended", "session", sess.ID)
+		go archiveSession(hstore, sess, app.projectDir())
+		app.Notifier.notify(sess, eventEnded)
 		if sess.WindowTarget != "" {
 			go func() {
 				// Delay so the MCP response reaches Claude before we interrupt
 				time.Sleep(2 * time.Second)
-				tmuxGracefulClose(sess.WindowTarget)
+				tmuxGracefulClose(app.tmuxSocket(), sess.WindowTarget)
 			}()
 		}
 		return &mcp.CallToolResult{
@@ -92,7 +109,7 @@ func newMCPServer(app *App, kbase *kb.KnowledgeBase, fstore *feedback.Store, ses
 		}, nil, nil
 	})
 
-	// Knowledge base tools — available to all profiles
+	// Knowledge base tools — kb_query needs read access, the others write access
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "kb_remember",
 		Description: "Save a statement to the persistent knowledge base. The statement is queued for async duplicate detection and will be promoted to active once processed.",
@@ -180,63 +197,149 @@ func newMCPServer(app *App, kbase *kb.KnowledgeBase, fstore *feedback.Store, ses
 				}, nil, nil
 			}
 
-			project, _ := os.Getwd()
+			target, err := feedbackTarget(sess, args.Target)
+			if err != nil {
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{
+						&mcp.TextContent{Text: err.Error()},
+					},
+					IsError: true,
+				}, nil, nil
+			}
+
+			project := app.projectDir()
 
-			id, err := fstore.Record(sess.Profile, args.Kind, args.Statement, args.Scope, project)
+			result, err := fstore.RecordChecked(target, args.Kind, args.Statement, args.Scope, project, args.Force)
 			if err != nil {
 				return nil, nil, fmt.Errorf("feedback_record: %w", err)
 			}
+			if result.ID != "" && args.Pinned {
+				if err := fstore.SetPinned(result.ID, true); err != nil {
+					return nil, nil, fmt.Errorf("feedback_record: %w", err)
+				}
+			}
 
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
-					&mcp.TextContent{Text: fmt.Sprintf("Feedback recorded (id: %s, profile: %s, kind: %s, scope: %s)", id, sess.Profile, args.Kind, args.Scope)},
+					&mcp.TextContent{Text: formatRecordResult(result, target, args.Kind, args.Scope)},
 				},
 			}, nil, nil
 		})
 	}
 
+	restrictTools(server, sessionID, profile, perms)
+	auditTools(server, hstore, sessionID, profile)
 	return server
 }
 
+// feedbackTarget resolves the target argument of feedback_record to the
+// profile value stored with the entry: the session's profile, one of its
+// groups, or all profiles.
+func feedbackTarget(sess *Session, target string) (string, error) {
+	switch {
+	case target == "" || target == "profile":
+		return sess.Profile, nil
+	case target == "all":
+		return feedback.AllProfiles, nil
+	case strings.HasPrefix(target, "group:"):
+		group := strings.TrimPrefix(target, "group:")
+		if !slices.Contains(sess.Groups, group) {
+			if len(sess.Groups) == 0 {
+				return "", fmt.Errorf("profile %s does not belong to any group", sess.Profile)
+			}
+			return "", fmt.Errorf("profile %s is not in group %q (groups: %s)", sess.Profile, group, strings.Join(sess.Groups, ", "))
+		}
+		return feedback.GroupTarget(group), nil
+	default:
+		return "", fmt.Errorf("target must be 'profile', 'group:<name>' or 'all', got %q", target)
+	}
+}
+
+// formatRecordResult describes the outcome of feedback_record for the
+// assistant, listing the entries the statement collided with.
+func formatRecordResult(result feedback.RecordResult, target, kind, scope string) string {
+	var b strings.Builder
+	switch {
+	case result.ID == "":
+		b.WriteString("Feedback NOT recorded: the statement contradicts existing examples of the opposite kind.\n")
+	case result.Duplicate != nil:
+		d := result.Duplicate
+		fmt.Fprintf(&b, "Feedback merged into an existing near-identical example (id: %s, similarity: %.2f, scope: %s): %q\n", d.ID, d.Similarity, d.Scope, d.Statement)
+	default:
+		fmt.Fprintf(&b, "Feedback recorded (id: %s, profile: %s, kind: %s, scope: %s)\n", result.ID, target, kind, scope)
+	}
+
+	if len(result.Conflicts) > 0 {
+		b.WriteString("\nConflicting examples:\n")
+		for _, c := range result.Conflicts {
+			fmt.Fprintf(&b, "- [%s] %q (id: %s, similarity: %.2f)\n", c.Kind, c.Statement, c.ID, c.Similarity)
+		}
+		if result.ID == "" {
+			b.WriteString("\nRefine the statement with the user so it no longer contradicts these examples, or call feedback_record again with force set to record it anyway.\n")
+		} else {
+			b.WriteString("\nConsider refining or removing the conflicting examples with the user.\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
 // setupHTTPMux creates an http.ServeMux with all routes registered.
-func setupHTTPMux(app *App, kbase *kb.KnowledgeBase, fstore *feedback.Store) *http.ServeMux {
+func setupHTTPMux(app *App, kbase *kb.KnowledgeBase, fstore *feedback.Store, hstore *history.Store) *http.ServeMux {
 	sseHandler := mcp.NewSSEHandler(func(r *http.Request) *mcp.Server {
 		sessionID := r.URL.Query().Get("session")
-		return newMCPServer(app, kbase, fstore, sessionID)
+		return newMCPServer(app, kbase, fstore, hstore, sessionID)
 	}, nil)
 
 	mux := http.NewServeMux()
 	mux.Handle("/sse", sseWithKeepalive(sseHandler, defaultSSEKeepaliveInterval))
 	mux.HandleFunc("/api/state", handleState(app, kbase))
-	mux.HandleFunc("/api/sessions", handleSessions(app))
+	mux.HandleFunc("/api/events", handleEvents(app))
+	mux.HandleFunc("/api/sessions", handleSessions(app, fstore))
 	mux.HandleFunc("/api/config", handleConfig(app))
-	mux.HandleFunc("/api/suspend", handleSuspend(app))
-	mux.HandleFunc("/api/complete", handleComplete(app))
+	mux.HandleFunc("/api/suspend", handleSuspend(app, hstore))
+	mux.HandleFunc("/api/complete", handleComplete(app, hstore))
 	mux.HandleFunc("/api/activate", handleActivate(app))
 	mux.HandleFunc("/api/preview", handlePreview(app))
-	mux.HandleFunc("/api/window-state", handleWindowState(app))
-	mux.HandleFunc("/api/session-ended", handleSessionEnded(app))
+	mux.HandleFunc("/api/window-state", handleWindowState(app, hstore))
+	mux.HandleFunc("/api/session-ended", handleSessionEnded(app, hstore))
 	mux.HandleFunc("/api/hook/preview", handleHookPreview(app))
 	mux.HandleFunc("/api/hook/window-state", handleHookWindowState(app))
+	mux.HandleFunc("/api/hook/transcript", handleHookTranscript(app, hstore))
 	mux.HandleFunc("/api/session", handleSession(app))
+	mux.HandleFunc("/api/pipeline/next", handlePipelineNext(app))
+	mux.HandleFunc("/api/jobs", handleJobs(app, fstore))
 	mux.HandleFunc("/api/kb/query", handleKBQuery(kbase))
 	mux.HandleFunc("/api/kb/fetch", handleKBFetch(kbase))
 	mux.HandleFunc("/api/kb/issues", handleKBIssues(kbase))
-	mux.HandleFunc("/api/kb/issues/resolve", handleKBIssueResolve(kbase))
+	mux.HandleFunc("/api/kb/issues/resolve", handleKBIssueResolve(app, kbase))
 	if fstore != nil {
+		mux.HandleFunc("/api/feedback", handleFeedbackList(fstore))
 		mux.HandleFunc("/api/feedback/sample", handleFeedbackSample(fstore, app))
+		mux.HandleFunc("/api/feedback/edit", handleFeedbackEdit(fstore))
+		mux.HandleFunc("/api/feedback/scope", handleFeedbackScope(fstore))
+		mux.HandleFunc("/api/feedback/pin", handleFeedbackPin(fstore))
+		mux.HandleFunc("/api/feedback/delete", handleFeedbackDelete(fstore))
+		mux.HandleFunc("/api/feedback/ranking", handleFeedbackRanking(fstore))
+		mux.HandleFunc("/api/session/outcome", handleSessionOutcome(fstore))
 	}
-	mux.HandleFunc("/api/session/prompt", handleSessionPrompt(app))
+	mux.HandleFunc("/api/session/prompt", handleSessionPrompt(app, fstore))
+	mux.HandleFunc("/api/session/tool-calls", handleSessionToolCalls(app, hstore))
 	mux.HandleFunc("/api/gpg/sign", handleGPGSign())
+	mux.HandleFunc("/api/openapi.json", handleOpenAPI())
 	return mux
 }
 
+// handleState returns a snapshot of the daemon's state, with the ID of the
+// latest event it includes, from which /api/events can be followed.
 func handleState(app *App, kbase *kb.KnowledgeBase) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		eventID := app.Events.lastID()
 		activeSessions := app.Sessions.active()
 		suspendedSessions := app.Sessions.suspended()
 		completedSessions := app.Sessions.completed()
 		indexingTasks := app.Indexing.list()
+		jobs := app.Jobs.list()
+		schedules := app.Schedules.list()
 
 		issueCount := 0
 		if n, err := kbase.OpenIssueCount(); err == nil {
@@ -244,12 +347,15 @@ func handleState(app *App, kbase *kb.KnowledgeBase) http.HandlerFunc {
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]any{
-			"active_sessions":    activeSessions,
-			"suspended_sessions": suspendedSessions,
-			"completed_sessions": completedSessions,
-			"indexing_tasks":     indexingTasks,
-			"issue_count":        issueCount,
+		json.NewEncoder(w).Encode(api.State{
+			Active:     activeSessions,
+			Suspended:  suspendedSessions,
+			Completed:  completedSessions,
+			Indexing:   indexingTasks,
+			Jobs:       jobs,
+			Schedules:  schedules,
+			IssueCount: issueCount,
+			EventID:    eventID,
 		})
 	}
 }
@@ -282,37 +388,39 @@ func cleanupEphemeralSession(sess *Session) {
 }
 
 // handleSessions handles POST /api/sessions to register a new session.
-func handleSessions(app *App) http.HandlerFunc {
-	type createReq struct {
-		ID             string `json:"id"`
-		Profile        string `json:"profile"`
-		Indicator      string `json:"indicator"`
-		Preview        string `json:"preview"`
-		WindowTarget   string `json:"window_target"`
-		Ephemeral      bool   `json:"ephemeral"`
-		ComposePath    string `json:"compose_path"`
-		ComposeProject string `json:"compose_project"`
-		SystemPrompt   string `json:"system_prompt"`
-	}
-
+// The feedback entries injected into the session are recorded in fstore.
+func handleSessions(app *App, fstore *feedback.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		var req createReq
+		var req api.CreateSessionRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		app.Sessions.create(req.ID, req.Profile, req.Indicator, req.Preview, req.WindowTarget, req.Ephemeral, req.ComposePath, req.ComposeProject, req.SystemPrompt)
+		app.Sessions.create(req.ID, req.Profile, req.Indicator, req.Preview, req.WindowTarget, req.Ephemeral, req.ComposePath, req.ComposeProject, req.SystemPrompt, req.FeedbackIDs, req.Groups, req.Permissions)
+		app.Sessions.setPipeline(req.ID, req.PromptArg, req.Next, req.Chain)
+		if req.Schedule != "" {
+			app.Sessions.setSchedule(req.ID, req.Schedule)
+		}
+		if req.ParentID != "" {
+			app.Sessions.setParent(req.ID, req.ParentID)
+		}
 		slog.Debug("session registered via API", "id", req.ID, "profile", req.Profile, "window", req.WindowTarget, "ephemeral", req.Ephemeral)
 
+		if fstore != nil {
+			if err := fstore.RecordUsage(req.ID, req.FeedbackIDs); err != nil {
+				slog.Warn("failed to record feedback usage", "session", req.ID, "error", err)
+			}
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(map[string]string{"status": "created"})
+		json.NewEncoder(w).Encode(api.Status{Status: "created"})
 	}
 }
 
@@ -336,18 +444,14 @@ func handleConfig(app *App) http.HandlerFunc {
 }
 
 // handleSuspend handles POST /api/suspend to suspend a session by its tmux window target.
-func handleSuspend(app *App) http.HandlerFunc {
-	type suspendReq struct {
-		WindowTarget string `json:"window_target"`
-	}
-
+func handleSuspend(app *App, hstore *history.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		var req suspendReq
+		var req api.WindowRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
 			return
@@ -359,44 +463,43 @@ func handleSuspend(app *App) http.HandlerFunc {
 			return
 		}
 
-		// Ephemeral sessions cannot be suspended — mark completed instead
-		if sess.Ephemeral {
-			app.Sessions.setStatus(sess.ID, "completed")
-			slog.Debug("ephemeral session completed via suspend API", "id", sess.ID, "window", req.WindowTarget)
-			go cleanupEphemeralSession(sess)
-			if req.WindowTarget != "" {
-				go tmuxGracefulClose(req.WindowTarget)
-			}
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(map[string]string{"status": "completed", "session_id": sess.ID})
-			return
-		}
-
-		app.Sessions.setStatus(sess.ID, "suspended")
-		slog.Debug("session suspended via API", "id", sess.ID, "window", req.WindowTarget)
-
-		if req.WindowTarget != "" {
-			go tmuxGracefulClose(req.WindowTarget)
-		}
+		status := suspendSession(app, hstore, sess)
+		slog.Debug("session suspended via API", "id", sess.ID, "window", req.WindowTarget, "status", status)
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"status": "suspended", "session_id": sess.ID})
+		json.NewEncoder(w).Encode(api.Status{Status: status, SessionID: sess.ID})
 	}
 }
 
-// handleComplete handles POST /api/complete to mark a session as completed by its tmux window target.
-func handleComplete(app *App) http.HandlerFunc {
-	type completeReq struct {
-		WindowTarget string `json:"window_target"`
+// suspendSession suspends an active session and closes its window, and
+// returns its new status. Ephemeral sessions cannot be suspended: they are
+// completed instead, and their container is cleaned up.
+func suspendSession(app *App, hstore *history.Store, sess *Session) string {
+	status := "suspended"
+	if sess.Ephemeral {
+		status = "completed"
+	}
+	app.Sessions.setStatus(sess.ID, status)
+	go archiveSession(hstore, sess, app.projectDir())
+	if sess.Ephemeral {
+		go offerNextStage(app, sess)
+		go cleanupEphemeralSession(sess)
+	}
+	if sess.WindowTarget != "" {
+		go tmuxGracefulClose(app.tmuxSocket(), sess.WindowTarget)
 	}
+	return status
+}
 
+// handleComplete handles POST /api/complete to mark a session as completed by its tmux window target.
+func handleComplete(app *App, hstore *history.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		var req completeReq
+		var req api.WindowRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
 			return
@@ -410,34 +513,31 @@ func handleComplete(app *App) http.HandlerFunc {
 
 		app.Sessions.setStatus(sess.ID, "completed")
 		slog.Debug("session completed via API", "id", sess.ID, "window", req.WindowTarget)
+		go archiveSession(hstore, sess, app.projectDir())
+		go offerNextStage(app, sess)
 
 		if sess.Ephemeral {
 			go cleanupEphemeralSession(sess)
 		}
 
 		if req.WindowTarget != "" {
-			go tmuxGracefulClose(req.WindowTarget)
+			go tmuxGracefulClose(app.tmuxSocket(), req.WindowTarget)
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"status": "completed", "session_id": sess.ID})
+		json.NewEncoder(w).Encode(api.Status{Status: "completed", SessionID: sess.ID})
 	}
 }
 
 // handleActivate handles POST /api/activate to reactivate a suspended session with a new window.
 func handleActivate(app *App) http.HandlerFunc {
-	type activateReq struct {
-		SessionID    string `json:"session_id"`
-		WindowTarget string `json:"window_target"`
-	}
-
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		var req activateReq
+		var req api.ActivateRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
 			return
@@ -451,27 +551,23 @@ func handleActivate(app *App) http.HandlerFunc {
 
 		app.Sessions.setStatus(req.SessionID, "active")
 		app.Sessions.setWindowTarget(req.SessionID, req.WindowTarget)
+		app.Sessions.touch(req.SessionID)
 		slog.Debug("session activated via API", "id", req.SessionID, "window", req.WindowTarget)
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"status": "active"})
+		json.NewEncoder(w).Encode(api.Status{Status: "active"})
 	}
 }
 
 // handlePreview handles POST /api/preview to update a session's preview text.
 func handlePreview(app *App) http.HandlerFunc {
-	type previewReq struct {
-		SessionID string `json:"session_id"`
-		Preview   string `json:"preview"`
-	}
-
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		var req previewReq
+		var req api.PreviewRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
 			return
@@ -481,24 +577,20 @@ func handlePreview(app *App) http.HandlerFunc {
 		slog.Debug("preview updated", "session", req.SessionID, "preview", req.Preview)
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+		json.NewEncoder(w).Encode(api.Status{Status: "ok"})
 	}
 }
 
 // handleSessionEnded handles POST /api/session-ended, called when a Claude process exits.
 // If the session is still "active", marks it "completed". Leaves "suspended" sessions alone.
-func handleSessionEnded(app *App) http.HandlerFunc {
-	type endedReq struct {
-		SessionID string `json:"session_id"`
-	}
-
+func handleSessionEnded(app *App, hstore *history.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		var req endedReq
+		var req api.SessionEndedRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
 			return
@@ -513,13 +605,16 @@ func handleSessionEnded(app *App) http.HandlerFunc {
 		if sess.Status == "active" {
 			app.Sessions.setStatus(req.SessionID, "completed")
 			slog.Debug("session ended (process exited)", "id", req.SessionID)
+			app.Notifier.notify(sess, eventEnded)
+			go archiveSession(hstore, sess, app.projectDir())
+			go offerNextStage(app, sess)
 			if sess.Ephemeral {
 				go cleanupEphemeralSession(sess)
 			}
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"status": sess.Status})
+		json.NewEncoder(w).Encode(api.Status{Status: sess.Status})
 	}
 }
 
@@ -540,9 +635,7 @@ func handleHookPreview(app *App) http.HandlerFunc {
 			return
 		}
 
-		var hookData struct {
-			Prompt string `json:"prompt"`
-		}
+		var hookData api.HookPreviewRequest
 		if err := json.NewDecoder(r.Body).Decode(&hookData); err != nil {
 			http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
 			return
@@ -550,7 +643,7 @@ func handleHookPreview(app *App) http.HandlerFunc {
 
 		if hookData.Prompt == "" {
 			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+			json.NewEncoder(w).Encode(api.Status{Status: "ok"})
 			return
 		}
 
@@ -563,27 +656,21 @@ func handleHookPreview(app *App) http.HandlerFunc {
 		slog.Debug("hook preview updated", "session", sessionID, "preview", preview)
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+		json.NewEncoder(w).Encode(api.Status{Status: "ok"})
 	}
 }
 
-// handleWindowState handles POST /api/window-state to update dynamic window indicators.
-func handleWindowState(app *App) http.HandlerFunc {
-	type windowStateReq struct {
-		SessionID      string `json:"session_id"`
-		Working        *bool  `json:"working,omitempty"`
-		Notification   *bool  `json:"notification,omitempty"`
-		PermissionMode string `json:"permission_mode,omitempty"`
-		Preview        string `json:"preview,omitempty"`
-	}
-
+// handleWindowState handles POST /api/window-state to update dynamic window
+// indicators. When a turn finishes, the session's token usage is read again
+// from its transcript.
+func handleWindowState(app *App, hstore *history.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		var req windowStateReq
+		var req api.WindowStateRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
 			return
@@ -595,12 +682,19 @@ func handleWindowState(app *App) http.HandlerFunc {
 			return
 		}
 
-		app.Sessions.setWindowState(req.SessionID, req.Working, req.Notification, req.PermissionMode, req.Preview)
+		finished, notified := app.Sessions.setWindowState(req.SessionID, req.Working, req.Notification, req.PermissionMode, req.Preview)
+		if req.TranscriptPath != "" {
+			app.Sessions.setTranscriptPath(req.SessionID, req.TranscriptPath)
+		}
 
 		// Re-fetch after update to get the latest state for tmux sync
 		sess = app.Sessions.get(req.SessionID)
 		if sess != nil {
-			syncWindowOptions(sess)
+			syncWindowOptions(app.tmuxSocket(), sess)
+			notifyWindowState(app, sess, finished, notified)
+			if finished {
+				go updateSessionUsage(app, hstore, sess)
+			}
 		}
 
 		slog.Debug("window-state updated", "session", req.SessionID,
@@ -608,7 +702,17 @@ func handleWindowState(app *App) http.HandlerFunc {
 			"permission_mode", req.PermissionMode)
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+		json.NewEncoder(w).Encode(api.Status{Status: "ok"})
+	}
+}
+
+// notifyWindowState notifies the transitions of a window state update.
+func notifyWindowState(app *App, sess *Session, finished, notified bool) {
+	if notified {
+		app.Notifier.notify(sess, eventNotification)
+	}
+	if finished {
+		app.Notifier.notify(sess, eventTurn)
 	}
 }
 
@@ -617,14 +721,6 @@ func handleWindowState(app *App) http.HandlerFunc {
 // and prompt, and updates the session window state. Used by ephemeral sessions
 // where the vee binary is not available inside the container.
 func handleHookWindowState(app *App) http.HandlerFunc {
-	type hookWindowStateReq struct {
-		SessionID      string `json:"session_id"`
-		Working        *bool  `json:"working,omitempty"`
-		Notification   *bool  `json:"notification,omitempty"`
-		PermissionMode string `json:"permission_mode,omitempty"`
-		Prompt         string `json:"prompt,omitempty"`
-	}
-
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -637,7 +733,7 @@ func handleHookWindowState(app *App) http.HandlerFunc {
 			return
 		}
 
-		var req hookWindowStateReq
+		var req api.HookWindowStateRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
 			return
@@ -648,11 +744,12 @@ func handleHookWindowState(app *App) http.HandlerFunc {
 			preview = string(r[:200])
 		}
 
-		app.Sessions.setWindowState(sessionID, req.Working, req.Notification, req.PermissionMode, preview)
+		finished, notified := app.Sessions.setWindowState(sessionID, req.Working, req.Notification, req.PermissionMode, preview)
 
 		sess := app.Sessions.get(sessionID)
 		if sess != nil {
-			syncWindowOptions(sess)
+			syncWindowOptions(app.tmuxSocket(), sess)
+			notifyWindowState(app, sess, finished, notified)
 		}
 
 		slog.Debug("hook window-state updated", "session", sessionID,
@@ -660,7 +757,72 @@ func handleHookWindowState(app *App) http.HandlerFunc {
 			"permission_mode", req.PermissionMode)
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+		json.NewEncoder(w).Encode(api.Status{Status: "ok"})
+	}
+}
+
+// handleHookTranscript handles POST /api/hook/transcript?session=<id>.
+// Accepts the raw transcript of an ephemeral session, whose transcript file
+// lives inside the container and disappears with it, and stores it as the
+// session's archived copy. It is uploaded when a turn finishes, so the
+// session's running usage is updated from it.
+func handleHookTranscript(app *App, hstore *history.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		sessionID := r.URL.Query().Get("session")
+		if sessionID == "" {
+			http.Error(w, "missing session query parameter", http.StatusBadRequest)
+			return
+		}
+		if app.Sessions.get(sessionID) == nil {
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+
+		path, err := hstore.SaveTranscript(sessionID, r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		app.Sessions.setTranscriptPath(sessionID, path)
+		slog.Debug("hook transcript saved", "session", sessionID, "path", path)
+
+		// The Stop hooks run concurrently, so the window state may report
+		// the finished turn before its transcript arrives: the usage is read
+		// here rather than there
+		if sess := app.Sessions.get(sessionID); sess != nil {
+			go updateSessionUsage(app, hstore, sess)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(api.Status{Status: "ok"})
+	}
+}
+
+// archiveSession records a session that left the active state in the
+// history archive, along with its transcript when a hook reported one.
+func archiveSession(hstore *history.Store, sess *Session, project string) {
+	entry := history.Session{
+		ID:        sess.ID,
+		Profile:   sess.Profile,
+		Indicator: sess.Indicator,
+		Project:   project,
+		Preview:   sess.Preview,
+		Status:    sess.Status,
+		StartedAt: sess.StartedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		EndedAt:   time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+	}
+	if err := hstore.Archive(entry, sess.TranscriptPath); err != nil {
+		slog.Warn("failed to archive session", "id", sess.ID, "error", err)
+		return
+	}
+	slog.Debug("session archived", "id", sess.ID, "transcript", sess.TranscriptPath)
+	if _, err := recordSessionUsage(hstore, sess, project); err != nil {
+		slog.Warn("failed to record session usage", "id", sess.ID, "error", err)
 	}
 }
 
@@ -672,13 +834,15 @@ func handleSession(app *App) http.HandlerFunc {
 			return
 		}
 
-		id := r.URL.Query().Get("id")
-		if id == "" {
-			http.Error(w, "missing id query parameter", http.StatusBadRequest)
+		var sess *Session
+		if id := r.URL.Query().Get("id"); id != "" {
+			sess = app.Sessions.get(id)
+		} else if window := r.URL.Query().Get("window"); window != "" {
+			sess = app.Sessions.findByWindowTarget(window)
+		} else {
+			http.Error(w, "missing id or window query parameter", http.StatusBadRequest)
 			return
 		}
-
-		sess := app.Sessions.get(id)
 		if sess == nil {
 			http.Error(w, "session not found", http.StatusNotFound)
 			return
@@ -689,62 +853,159 @@ func handleSession(app *App) http.HandlerFunc {
 	}
 }
 
-// startHTTPServerInBackground starts the HTTP server on an OS-assigned port in a
-// goroutine and returns the *http.Server and actual port for later use.
-func startHTTPServerInBackground(app *App, kbase *kb.KnowledgeBase, fstore *feedback.Store) (*http.Server, int, error) {
-	mux := setupHTTPMux(app, kbase, fstore)
+// startHTTPServerInBackground starts the HTTP server on an OS-assigned port in
+// goroutines, behind authentication, and returns the actual port and a
+// function stopping the server.
+func startHTTPServerInBackground(app *App, kbase *kb.KnowledgeBase, fstore *feedback.Store, hstore *history.Store) (func(), int, error) {
+	mux := setupHTTPMux(app, kbase, fstore, hstore)
 
-	ln, err := net.Listen("tcp", "0.0.0.0:0")
+	listeners, port, err := listenDaemon()
 	if err != nil {
-		return nil, 0, fmt.Errorf("listen: %w", err)
+		return nil, 0, err
+	}
+	secret := newInstanceSecret()
+	removeSecret, err := writeInstanceSecret(port, secret)
+	if err != nil {
+		closeListeners(listeners)
+		return nil, 0, err
 	}
 
+	srv := newDaemonServer(secret, mux)
+	for _, ln := range listeners {
+		go func() {
+			slog.Info("http server listening", "addr", ln.Addr().String())
+			if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+				slog.Error("http server error", "error", err)
+			}
+		}()
+	}
+
+	stop := func() {
+		srv.Close()
+		removeSecret()
+	}
+	return stop, port, nil
+}
+
+// listenDaemon listens on an OS-assigned port of the loopback interface, on
+// the Unix socket named after it, for host commands and sessions, and on the
+// same port of the Docker bridge, for ephemeral containers
+// (host.docker.internal). Without a Docker bridge, only loopback is used.
+func listenDaemon() ([]net.Listener, int, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, 0, fmt.Errorf("listen: %w", err)
+	}
 	port := ln.Addr().(*net.TCPAddr).Port
-	srv := &http.Server{Handler: mux}
-	go func() {
-		slog.Info("http server listening", "addr", ln.Addr().String())
-		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
-			slog.Error("http server error", "error", err)
+	listeners := []net.Listener{ln}
+
+	if sock, err := listenDaemonSocket(port); err != nil {
+		slog.Warn("failed to listen on the unix socket, host commands will use tcp", "error", err)
+	} else {
+		listeners = append(listeners, sock)
+	}
+
+	bridge := dockerBridgeIP()
+	if bridge == nil {
+		slog.Info("no docker bridge found, ephemeral sessions won't reach the daemon")
+		return listeners, port, nil
+	}
+	bln, err := net.Listen("tcp", net.JoinHostPort(bridge.String(), strconv.Itoa(port)))
+	if err != nil {
+		slog.Warn("failed to listen on the docker bridge, ephemeral sessions won't reach the daemon", "addr", bridge, "error", err)
+		return listeners, port, nil
+	}
+	return append(listeners, bln), port, nil
+}
+
+// dockerBridgeIP returns the host's address on the default Docker bridge,
+// which host.docker.internal resolves to in containers (host-gateway), or
+// nil when there is none.
+func dockerBridgeIP() net.IP {
+	if iface, err := net.InterfaceByName("docker0"); err == nil {
+		if addrs, err := iface.Addrs(); err == nil {
+			for _, addr := range addrs {
+				if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
+					return ipnet.IP
+				}
+			}
 		}
-	}()
+	}
+	out, err := exec.Command("docker", "network", "inspect", "bridge",
+		"--format", "{{range .IPAM.Config}}{{.Gateway}}{{end}}").Output()
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(strings.TrimSpace(string(out)))
+}
 
-	return srv, port, nil
+func closeListeners(listeners []net.Listener) {
+	for _, ln := range listeners {
+		ln.Close()
+	}
 }
 
 // Run starts the daemon: MCP server (SSE) + API on an OS-assigned port.
 func (cmd *DaemonCmd) Run() error {
+	if cmd.User {
+		return runUserDaemon()
+	}
+
 	userCfg, err := loadUserConfig()
 	if err != nil {
 		slog.Warn("failed to load user config, using defaults", "error", err)
 		userCfg = hydrateUserConfig(nil)
 	}
 
-	kbase, err := openKB(userCfg)
+	app := newApp()
+
+	kbase, err := openKB(userCfg, func(e kb.Event) { publishKBEvent(app, e) })
 	if err != nil {
 		return fmt.Errorf("open knowledge base: %w", err)
 	}
 	defer kbase.Close()
 
-	stDir, err := stateDir()
+	fstore, err := openFeedbackStore(userCfg)
 	if err != nil {
-		return fmt.Errorf("state dir: %w", err)
+		return err
 	}
-	fstore, err := feedback.Open(filepath.Join(stDir, "feedback.db"))
+	defer fstore.Close()
+
+	hstore, err := openHistoryStore()
 	if err != nil {
-		return fmt.Errorf("open feedback store: %w", err)
+		return err
 	}
-	defer fstore.Close()
+	defer hstore.Close()
 
-	app := newApp()
-	mux := setupHTTPMux(app, kbase, fstore)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	app.Jobs.setLimit(userCfg.Jobs.Concurrency)
+	app.Jobs.start(ctx)
+	app.Notifier.configure(userCfg.Notify)
+	mux := setupHTTPMux(app, kbase, fstore, hstore)
 
-	ln, err := net.Listen("tcp", "0.0.0.0:0")
+	listeners, port, err := listenDaemon()
+	if err != nil {
+		return err
+	}
+	secret := newInstanceSecret()
+	removeSecret, err := writeInstanceSecret(port, secret)
 	if err != nil {
-		return fmt.Errorf("listen: %w", err)
+		closeListeners(listeners)
+		return err
 	}
+	defer removeSecret()
 
-	slog.Info("daemon listening", "addr", ln.Addr().String())
-	return http.Serve(ln, mux)
+	srv := newDaemonServer(secret, mux)
+	errs := make(chan error, len(listeners))
+	for _, ln := range listeners {
+		slog.Info("daemon listening", "addr", ln.Addr().String())
+		go func() { errs <- srv.Serve(ln) }()
+	}
+	err = <-errs
+	srv.Close()
+	return err
 }
 
 // handleKBQuery handles GET /api/kb/query?q=<query>.
@@ -827,11 +1088,7 @@ func handleKBIssues(kbase *kb.KnowledgeBase) http.HandlerFunc {
 }
 
 // handleKBIssueResolve handles POST /api/kb/issues/resolve?id=<id>.
-func handleKBIssueResolve(kbase *kb.KnowledgeBase) http.HandlerFunc {
-	type resolveReq struct {
-		Action string `json:"action"`
-	}
-
+func handleKBIssueResolve(app *App, kbase *kb.KnowledgeBase) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -844,7 +1101,7 @@ func handleKBIssueResolve(kbase *kb.KnowledgeBase) http.HandlerFunc {
 			return
 		}
 
-		var req resolveReq
+		var req api.ResolveIssueRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
 			return
@@ -854,15 +1111,19 @@ func handleKBIssueResolve(kbase *kb.KnowledgeBase) http.HandlerFunc {
 			http.Error(w, "resolve: "+err.Error(), http.StatusBadRequest)
 			return
 		}
+		if n, err := kbase.OpenIssueCount(); err == nil {
+			app.Events.publish(evIssueResolved, map[string]any{"id": issueID, "open_issues": n})
+		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"status": "resolved"})
+		json.NewEncoder(w).Encode(api.Status{Status: "resolved"})
 	}
 }
 
 // handleSessionPrompt handles GET /api/session/prompt?window=<window_id>.
-// Returns the system prompt for the session in the given window.
-func handleSessionPrompt(app *App) http.HandlerFunc {
+// Returns the system prompt for the session in the given window, along with
+// the feedback examples injected into it and the session's outcome.
+func handleSessionPrompt(app *App, fstore *feedback.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -881,16 +1142,40 @@ func handleSessionPrompt(app *App) http.HandlerFunc {
 			return
 		}
 
+		entries := []feedback.RankedEntry{}
+		var outcome string
+		if fstore != nil {
+			projectEntries, err := feedback.LoadProjectFile(app.projectDir())
+			if err != nil {
+				slog.Warn("ignoring project feedback file", "project", app.projectDir(), "error", err)
+			}
+			if used, err := fstore.SessionEntries(sess.ID, projectEntries); err != nil {
+				slog.Warn("failed to load session feedback", "session", sess.ID, "error", err)
+			} else if used != nil {
+				entries = used
+			}
+			if o, err := fstore.Outcome(sess.ID); err == nil {
+				outcome = o
+			}
+		}
+
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{
-			"profile":       sess.Profile,
-			"indicator":     sess.Indicator,
-			"system_prompt": sess.SystemPrompt,
+		json.NewEncoder(w).Encode(api.SessionPrompt{
+			SessionID:    sess.ID,
+			Profile:      sess.Profile,
+			Indicator:    sess.Indicator,
+			SystemPrompt: sess.SystemPrompt,
+			Feedback:     entries,
+			Outcome:      outcome,
 		})
 	}
 }
 
-// handleFeedbackSample handles GET /api/feedback/sample?profile=<profile>&project=<project>&n=<n>.
+// handleFeedbackSample handles GET /api/feedback/sample?profile=<profile>&project=<project>&n=<n>&pinned=<n>&prompt=<prompt>&seed=<seed>&group=<group>&group_n=<n>&global_n=<n>.
+// The optional prompt steers sampling towards relevant entries, pinned is the
+// budget for pinned entries, and seed makes the draw deterministic. group may
+// be repeated; group_n and global_n are the quotas for group-wide and global
+// entries.
 // Returns a JSON array of sampled feedback entries.
 func handleFeedbackSample(fstore *feedback.Store, app *App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -909,12 +1194,41 @@ func handleFeedbackSample(fstore *feedback.Store, app *App) http.HandlerFunc {
 
 		n := 5
 		if nStr := r.URL.Query().Get("n"); nStr != "" {
-			if v, err := strconv.Atoi(nStr); err == nil && v > 0 {
+			if v, err := strconv.Atoi(nStr); err == nil && v >= 0 {
 				n = v
 			}
 		}
 
-		entries, err := fstore.Sample(profile, project, n)
+		quota := func(name string) int {
+			if v, err := strconv.Atoi(r.URL.Query().Get(name)); err == nil && v > 0 {
+				return v
+			}
+			return 0
+		}
+
+		// Examples committed to the project's .vee/feedback.yaml are merged
+		// in as project-scope candidates.
+		var projectEntries []feedback.Entry
+		if project != "" {
+			var err error
+			if projectEntries, err = feedback.LoadProjectFile(project); err != nil {
+				slog.Warn("ignoring project feedback file", "project", project, "error", err)
+			}
+		}
+
+		entries, err := fstore.SampleWith(feedback.SampleOptions{
+			Profile:   profile,
+			Project:   project,
+			N:         n,
+			Prompt:    r.URL.Query().Get("prompt"),
+			MaxPinned: quota("pinned"),
+			Seed:      r.URL.Query().Get("seed"),
+			Groups:    r.URL.Query()["group"],
+			GroupN:    quota("group_n"),
+			GlobalN:   quota("global_n"),
+
+			ProjectEntries: projectEntries,
+		})
 		if err != nil {
 			http.Error(w, "sample failed: "+err.Error(), http.StatusInternalServerError)
 			return
@@ -929,6 +1243,229 @@ func handleFeedbackSample(fstore *feedback.Store, app *App) http.HandlerFunc {
 	}
 }
 
+// handleFeedbackList handles GET /api/feedback?profile=&scope=&project=&kind=.
+// Every filter is optional. Returns a JSON array of feedback entries.
+func handleFeedbackList(fstore *feedback.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		q := r.URL.Query()
+		entries, err := fstore.List(feedback.ListFilter{
+			Profile: q.Get("profile"),
+			Scope:   q.Get("scope"),
+			Project: q.Get("project"),
+			Kind:    q.Get("kind"),
+		})
+		if err != nil {
+			http.Error(w, "list failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if entries == nil {
+			entries = []feedback.Entry{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries)
+	}
+}
+
+// handleFeedbackEdit handles POST /api/feedback/edit?id=<id>.
+// Body: {"kind": "good"|"bad", "statement": "..."}.
+func handleFeedbackEdit(fstore *feedback.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			http.Error(w, "missing id query parameter", http.StatusBadRequest)
+			return
+		}
+
+		var req api.FeedbackEditRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := fstore.Update(id, req.Kind, req.Statement); err != nil {
+			writeFeedbackError(w, "edit", err)
+			return
+		}
+		slog.Info("feedback edited", "id", id, "kind", req.Kind)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(api.Status{Status: "updated"})
+	}
+}
+
+// handleFeedbackScope handles POST /api/feedback/scope?id=<id>.
+// Body: {"scope": "user"|"project", "project": "/abs/path"}.
+func handleFeedbackScope(fstore *feedback.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			http.Error(w, "missing id query parameter", http.StatusBadRequest)
+			return
+		}
+
+		var req api.FeedbackScopeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := fstore.SetScope(id, req.Scope, req.Project); err != nil {
+			writeFeedbackError(w, "scope", err)
+			return
+		}
+		slog.Info("feedback re-scoped", "id", id, "scope", req.Scope, "project", req.Project)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(api.Status{Status: "updated"})
+	}
+}
+
+// handleFeedbackPin handles POST /api/feedback/pin?id=<id>.
+// Body: {"pinned": true|false}.
+func handleFeedbackPin(fstore *feedback.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			http.Error(w, "missing id query parameter", http.StatusBadRequest)
+			return
+		}
+
+		var req api.FeedbackPinRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := fstore.SetPinned(id, req.Pinned); err != nil {
+			writeFeedbackError(w, "pin", err)
+			return
+		}
+		slog.Info("feedback pinned", "id", id, "pinned", req.Pinned)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(api.Status{Status: "updated"})
+	}
+}
+
+// handleFeedbackDelete handles POST /api/feedback/delete?id=<id>.
+func handleFeedbackDelete(fstore *feedback.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			http.Error(w, "missing id query parameter", http.StatusBadRequest)
+			return
+		}
+
+		if err := fstore.Delete(id); err != nil {
+			writeFeedbackError(w, "delete", err)
+			return
+		}
+		slog.Info("feedback deleted", "id", id)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(api.Status{Status: "deleted"})
+	}
+}
+
+// handleFeedbackRanking handles GET /api/feedback/ranking?profile=&scope=&project=&kind=.
+// Returns feedback entries with usage statistics, best-scoring first.
+func handleFeedbackRanking(fstore *feedback.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		q := r.URL.Query()
+		ranked, err := fstore.Ranked(feedback.ListFilter{
+			Profile: q.Get("profile"),
+			Scope:   q.Get("scope"),
+			Project: q.Get("project"),
+			Kind:    q.Get("kind"),
+		})
+		if err != nil {
+			http.Error(w, "ranking failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if ranked == nil {
+			ranked = []feedback.RankedEntry{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ranked)
+	}
+}
+
+// handleSessionOutcome handles POST /api/session/outcome?id=<session_id>.
+// Body: {"outcome": "good"|"bad"|""}. An empty outcome clears the mark.
+func handleSessionOutcome(fstore *feedback.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			http.Error(w, "missing id query parameter", http.StatusBadRequest)
+			return
+		}
+
+		var req api.OutcomeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := fstore.SetOutcome(id, req.Outcome); err != nil {
+			http.Error(w, "outcome: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		slog.Info("session outcome marked", "session", id, "outcome", req.Outcome)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(api.Status{Status: "updated"})
+	}
+}
+
+// writeFeedbackError maps a feedback store error to an HTTP status:
+// 404 for unknown IDs, 400 for everything else (validation failures).
+func writeFeedbackError(w http.ResponseWriter, op string, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, feedback.ErrNotFound) {
+		status = http.StatusNotFound
+	}
+	http.Error(w, op+": "+err.Error(), status)
+}
+
 // stripCodeFence extracts content from within a markdown code fence if present.
 // Handles ```json ... ``` as well as prose before/after the fence.
 // Returns the original string if no code fence is found.
@@ -950,6 +1487,25 @@ func stripCodeFence(s string) string {
 	return strings.TrimSpace(inner[:end])
 }
 
+// handleOpenAPI handles GET /api/openapi.json to return the OpenAPI
+// description of the daemon API.
+func handleOpenAPI() http.HandlerFunc {
+	doc, err := api.OpenAPI()
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err != nil {
+			http.Error(w, "openapi: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(doc)
+	}
+}
+
 // handleGPGSign handles POST /api/gpg/sign — signs data using the host's GPG.
 // Request body contains the data to sign. Query params: key (signing key ID).
 // Returns the detached armored signature.
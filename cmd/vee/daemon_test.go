@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lthms/vee/internal/api"
+	"github.com/lthms/vee/internal/feedback"
+	"github.com/lthms/vee/internal/history"
+)
+
+func TestRoutesServed(t *testing.T) {
+	fstore, err := feedback.Open(filepath.Join(t.TempDir(), "feedback.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fstore.Close()
+	hstore, err := history.Open(filepath.Join(t.TempDir(), "history.db"), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hstore.Close()
+	mux := setupHTTPMux(newApp(), nil, fstore, hstore)
+
+	for _, route := range api.Routes {
+		req := httptest.NewRequest(route.Method, route.Path, nil)
+		if _, pattern := mux.Handler(req); pattern != route.Path {
+			t.Errorf("%s %s is served by %q", route.Method, route.Path, pattern)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("GET /api/openapi.json = %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestHookTranscriptUpdatesUsage(t *testing.T) {
+	dir := t.TempDir()
+	hstore, err := history.Open(filepath.Join(dir, "history.db"), filepath.Join(dir, "transcripts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hstore.Close()
+
+	app := newApp()
+	app.Sessions.create("s1", "vibe", "🐸", "", "", true, "", "", "", nil, nil, ToolPermissions{})
+
+	transcript := `{"type":"assistant","timestamp":"2025-03-01T10:00:05Z","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":100,"output_tokens":10}}}` + "\n"
+	rec := httptest.NewRecorder()
+	handleHookTranscript(app, hstore)(rec, httptest.NewRequest(http.MethodPost, "/api/hook/transcript?session=s1", strings.NewReader(transcript)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /api/hook/transcript = %d %s", rec.Code, rec.Body)
+	}
+
+	usage := func() history.Usage {
+		app.Sessions.mu.RLock()
+		defer app.Sessions.mu.RUnlock()
+		return app.Sessions.sessions["s1"].Usage
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for usage().Output != 10 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the usage of the uploaded transcript, got %+v", usage())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
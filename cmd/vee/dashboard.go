@@ -3,19 +3,25 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"log/slog"
 	"os"
 	"os/signal"
+	"slices"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/lthms/vee/internal/api"
+	"github.com/lthms/vee/internal/history"
 	"golang.org/x/term"
 )
 
 // DashboardCmd is the internal subcommand that renders a session dashboard in the terminal.
 type DashboardCmd struct {
 	Port int `short:"p" default:"2700" name:"port"`
+
+	offline bool // the event stream is down, the state may be stale
 }
 
 // ANSI escape helpers — foreground only, no background overrides.
@@ -31,16 +37,24 @@ const (
 	ansiOrange = "\033[38;2;255;158;100m" // #ff9e64
 )
 
+// Reconnection delays of the dashboard's event stream.
+const (
+	dashboardReconnectMin = 500 * time.Millisecond
+	dashboardReconnectMax = 10 * time.Second
+)
+
 // dashboardState mirrors the /api/state JSON response.
-type dashboardState struct {
-	Active     []*Session     `json:"active_sessions"`
-	Suspended  []*Session     `json:"suspended_sessions"`
-	Completed  []*Session     `json:"completed_sessions"`
-	Indexing   []IndexingTask `json:"indexing_tasks"`
-	IssueCount int            `json:"issue_count"`
+type dashboardState api.State
+
+// dashboardMsg is sent by the event stream follower to the render loop:
+// an event, or a change of connection.
+type dashboardMsg struct {
+	event   *Event
+	offline bool
 }
 
-// Run starts the dashboard TUI loop.
+// Run starts the dashboard TUI loop. The dashboard fetches the state once,
+// then keeps it up to date from /api/events.
 func (cmd *DashboardCmd) Run() error {
 	// Raw mode so keystrokes don't echo on screen
 	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
@@ -65,36 +79,179 @@ func (cmd *DashboardCmd) Run() error {
 	winchCh := make(chan os.Signal, 1)
 	signal.Notify(winchCh, syscall.SIGWINCH)
 
+	// Only redraws, so that ages stay current
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
-	// Initial render
-	cmd.render(cmd.fetchState())
+	state := cmd.fetchState()
+	lastID := ""
+	if state != nil {
+		lastID = state.EventID
+	}
+	msgs := make(chan dashboardMsg, 16)
+	go cmd.follow(lastID, msgs)
+
+	cmd.render(state)
 
 	for {
 		select {
 		case <-sigCh:
 			return nil
 		case <-winchCh:
-			cmd.render(cmd.fetchState())
+			cmd.render(state)
 		case <-ticker.C:
-			cmd.render(cmd.fetchState())
+			cmd.render(state)
+		case msg := <-msgs:
+			if msg.event == nil {
+				cmd.offline = msg.offline
+			} else if state == nil || !state.apply(*msg.event) {
+				if fresh := cmd.fetchState(); fresh != nil {
+					state = fresh
+				}
+			}
+			cmd.render(state)
 		}
 	}
 }
 
+// follow reads /api/events forever, resuming after lastID, and sends what
+// it reads to msgs. It reconnects with a backoff when the stream breaks,
+// asking for the events it missed. A stream joined without a known
+// position starts with a resync, so that the state is fetched.
+func (cmd *DashboardCmd) follow(lastID string, msgs chan<- dashboardMsg) {
+	delay := dashboardReconnectMin
+	for {
+		if lastID == "" {
+			msgs <- dashboardMsg{event: &Event{Type: evResync}}
+		}
+		err := cmd.readEvents(lastID, func(ev Event) {
+			lastID = ev.ID
+			msgs <- dashboardMsg{event: &ev}
+		}, func() {
+			delay = dashboardReconnectMin
+			msgs <- dashboardMsg{offline: false}
+		})
+		slog.Debug("dashboard event stream closed", "error", err)
+		msgs <- dashboardMsg{offline: true}
+
+		time.Sleep(delay)
+		delay = min(delay*2, dashboardReconnectMax)
+	}
+}
+
+// readEvents reads the event stream until it breaks, calling onOpen once
+// connected and onEvent for every event.
+func (cmd *DashboardCmd) readEvents(lastID string, onEvent func(Event), onOpen func()) error {
+	return daemonAPI(cmd.Port).Events(lastID, onOpen, onEvent)
+}
+
 func (cmd *DashboardCmd) fetchState() *dashboardState {
-	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/api/state", cmd.Port))
+	state, err := daemonAPI(cmd.Port).State()
 	if err != nil {
 		return nil
 	}
-	defer resp.Body.Close()
+	sortByStart(state.Active)
+	return (*dashboardState)(state)
+}
 
-	var state dashboardState
-	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
-		return nil
+// apply updates the state with an event. It returns false when the event
+// can't be applied, and the state must be fetched again. Events the state
+// already includes are skipped.
+func (s *dashboardState) apply(ev Event) bool {
+	if ev.Type == evResync {
+		return false
+	}
+	if !eventAfter(ev.ID, s.EventID) {
+		return true
+	}
+	s.EventID = ev.ID
+
+	switch ev.Type {
+	case evSessionCreated, evSessionStatus, evSessionWindow, evSessionPreview, evSessionUpdated:
+		var sess Session
+		if err := json.Unmarshal(ev.Data, &sess); err != nil {
+			return false
+		}
+		s.removeSession(sess.ID)
+		switch sess.Status {
+		case "active":
+			s.Active = append(s.Active, &sess)
+			sortByStart(s.Active)
+		case "suspended":
+			s.Suspended = append(s.Suspended, &sess)
+			sortByStart(s.Suspended)
+		case "completed":
+			s.Completed = append(s.Completed, &sess)
+			sortByStart(s.Completed)
+		}
+	case evSessionRemoved:
+		var data struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(ev.Data, &data); err != nil {
+			return false
+		}
+		s.removeSession(data.ID)
+	case evIndexingProgress:
+		var data struct {
+			Tasks []IndexingTask `json:"tasks"`
+		}
+		if err := json.Unmarshal(ev.Data, &data); err != nil {
+			return false
+		}
+		s.Indexing = data.Tasks
+	case evJobs:
+		var data struct {
+			Jobs []api.Job `json:"jobs"`
+		}
+		if err := json.Unmarshal(ev.Data, &data); err != nil {
+			return false
+		}
+		s.Jobs = data.Jobs
+	case evSchedules:
+		var data struct {
+			Schedules []ScheduleStatus `json:"schedules"`
+		}
+		if err := json.Unmarshal(ev.Data, &data); err != nil {
+			return false
+		}
+		s.Schedules = data.Schedules
+	case evIssueOpened, evIssueResolved:
+		var data struct {
+			OpenIssues int `json:"open_issues"`
+		}
+		if err := json.Unmarshal(ev.Data, &data); err != nil {
+			return false
+		}
+		s.IssueCount = data.OpenIssues
 	}
-	return &state
+	return true
+}
+
+// removeSession drops a session from every list of the state.
+func (s *dashboardState) removeSession(id string) {
+	drop := func(sess *Session) bool { return sess.ID == id }
+	s.Active = slices.DeleteFunc(s.Active, drop)
+	s.Suspended = slices.DeleteFunc(s.Suspended, drop)
+	s.Completed = slices.DeleteFunc(s.Completed, drop)
+}
+
+// sortByStart orders sessions by start time, oldest first.
+func sortByStart(sessions []*Session) {
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].StartedAt.Before(sessions[j].StartedAt)
+	})
+}
+
+// eventAfter reports whether event id comes after ref. Events of another
+// daemon run always come after.
+func eventAfter(id, ref string) bool {
+	epoch, seq, ok := splitEventID(id)
+	refEpoch, refSeq, refOK := splitEventID(ref)
+	if !ok || !refOK || epoch != refEpoch {
+		return true
+	}
+	return seq > refSeq
 }
 
 func (cmd *DashboardCmd) render(state *dashboardState) {
@@ -129,6 +286,22 @@ func (cmd *DashboardCmd) render(state *dashboardState) {
 		sb.WriteString(ansiReset)
 	}
 
+	if state != nil && cmd.offline {
+		sb.WriteString("  ")
+		sb.WriteString(ansiMuted)
+		sb.WriteString("○ reconnecting…")
+		sb.WriteString(ansiReset)
+	}
+
+	if state != nil {
+		if total := sessionsUsage(state); total.Total() > 0 {
+			sb.WriteString("  ")
+			sb.WriteString(ansiMuted)
+			sb.WriteString(fmt.Sprintf("Σ %s tokens · %s", formatTokens(total.Total()), formatCost(total.Cost)))
+			sb.WriteString(ansiReset)
+		}
+	}
+
 	sb.WriteString("\r\n\r\n")
 
 	if state == nil {
@@ -138,12 +311,27 @@ func (cmd *DashboardCmd) render(state *dashboardState) {
 		sb.WriteString(ansiReset)
 		sb.WriteString("\r\n")
 	} else {
+		// Chained sessions are grouped by pipeline rather than by status
+		pipelines := splitPipelines(state)
+
 		// Active sessions
 		cmd.renderSection(&sb, "ACTIVE", state.Active, ansiGreen, termWidth)
+		// Pipelines (only shown when non-empty)
+		if len(pipelines) > 0 {
+			cmd.renderPipelinesSection(&sb, pipelines, termWidth)
+		}
 		// Indexing tasks (only shown when non-empty)
 		if len(state.Indexing) > 0 {
 			cmd.renderIndexingSection(&sb, state.Indexing, termWidth)
 		}
+		// Background jobs (only shown when non-empty)
+		if len(state.Jobs) > 0 {
+			cmd.renderJobsSection(&sb, state.Jobs, termWidth)
+		}
+		// Schedules (only shown when configured)
+		if len(state.Schedules) > 0 {
+			cmd.renderSchedulesSection(&sb, state.Schedules, termWidth)
+		}
 		// Suspended sessions
 		cmd.renderSection(&sb, "SUSPENDED", state.Suspended, ansiYellow, termWidth)
 		// Completed sessions
@@ -166,6 +354,10 @@ func (cmd *DashboardCmd) render(state *dashboardState) {
 	sb.WriteString(ansiReset)
 	sb.WriteString(" issues  ")
 	sb.WriteString(ansiMuted)
+	sb.WriteString("Ctrl-b j")
+	sb.WriteString(ansiReset)
+	sb.WriteString(" jobs  ")
+	sb.WriteString(ansiMuted)
 	sb.WriteString("Ctrl-b n/p")
 	sb.WriteString(ansiReset)
 	sb.WriteString(" next/prev  ")
@@ -196,65 +388,183 @@ func (cmd *DashboardCmd) renderSection(sb *strings.Builder, title string, sessio
 	}
 
 	for _, sess := range sessions {
-		age := formatAge(time.Since(sess.StartedAt))
+		cmd.renderSessionRow(sb, sess, color, 4, termWidth)
+	}
+	sb.WriteString("\r\n")
+}
 
-		// Layout: indent(4) + ⏣(1) + space(1) + indicator(2) + space(1) + profile + gap + preview + gap + age
-		const indent = 4
-		const badgeWidth = 1     // ephemeral(1)
-		const indicatorWidth = 2 // emoji
-		leftFixed := indent + badgeWidth + 1 + indicatorWidth + 1 + len(sess.Profile)
-		rightFixed := len(age) + 2 // +2 for right margin
+// renderSessionRow renders one session line, indented by indent columns.
+func (cmd *DashboardCmd) renderSessionRow(sb *strings.Builder, sess *Session, color string, indent int, termWidth int) {
+	age := formatAge(time.Since(sess.StartedAt))
 
-		sb.WriteString("    ")
+	// Layout: indent + ⏣(1) + space(1) + indicator(2) + space(1) + profile + gap + preview + gap + age
+	const badgeWidth = 1     // ephemeral(1)
+	const indicatorWidth = 2 // emoji
+	leftFixed := indent + badgeWidth + 1 + indicatorWidth + 1 + len(sess.Profile)
+	rightFixed := len(age) + 2 // +2 for right margin
 
-		// Ephemeral badge (always shown, colored when active, dim when not)
-		if sess.Ephemeral {
-			sb.WriteString(ansiYellow)
-		} else {
+	sb.WriteString(strings.Repeat(" ", indent))
+
+	// Ephemeral badge (always shown, colored when active, dim when not)
+	if sess.Ephemeral {
+		sb.WriteString(ansiYellow)
+	} else {
+		sb.WriteString(ansiDim)
+	}
+	sb.WriteString("⏣")
+	sb.WriteString(ansiReset)
+
+	// Indicator + profile name
+	sb.WriteString(" ")
+	sb.WriteString(color)
+	sb.WriteString(sess.Indicator)
+	sb.WriteString(" ")
+	sb.WriteString(ansiBold)
+	sb.WriteString(sess.Profile)
+	sb.WriteString(ansiReset)
+
+	usedWidth := leftFixed
+
+	// Fork marker, with the start of the parent session ID
+	if sess.ParentID != "" {
+		fork := "⑂ " + shortID(sess.ParentID)
+		sb.WriteString("  ")
+		sb.WriteString(ansiMuted)
+		sb.WriteString(fork)
+		sb.WriteString(ansiReset)
+		usedWidth += 2 + len([]rune(fork))
+	}
+
+	// Preview (between profile and age)
+	if sess.Preview != "" {
+		maxPreview := termWidth - usedWidth - rightFixed - 4
+		if maxPreview > 3 {
+			preview := sess.Preview
+			if len(preview) > maxPreview {
+				preview = preview[:maxPreview-3] + "..."
+			}
+			sb.WriteString("  ")
 			sb.WriteString(ansiDim)
+			sb.WriteString(ansiItalic)
+			sb.WriteString(preview)
+			sb.WriteString(ansiReset)
+			usedWidth += 2 + len(preview)
 		}
-		sb.WriteString("⏣")
-		sb.WriteString(ansiReset)
+	}
 
-		// Indicator + profile name
-		sb.WriteString(" ")
-		sb.WriteString(color)
-		sb.WriteString(sess.Indicator)
-		sb.WriteString(" ")
-		sb.WriteString(ansiBold)
-		sb.WriteString(sess.Profile)
-		sb.WriteString(ansiReset)
+	// Right-align age
+	padding := termWidth - usedWidth - rightFixed
+	if padding < 2 {
+		padding = 2
+	}
+	sb.WriteString(strings.Repeat(" ", padding))
+	sb.WriteString(ansiMuted)
+	sb.WriteString(age)
+	sb.WriteString(ansiReset)
 
-		usedWidth := leftFixed
+	sb.WriteString("\r\n")
+}
 
-		// Preview (between profile and age)
-		if sess.Preview != "" {
-			maxPreview := termWidth - leftFixed - rightFixed - 4
-			if maxPreview > 3 {
-				preview := sess.Preview
-				if len(preview) > maxPreview {
-					preview = preview[:maxPreview-3] + "..."
-				}
-				sb.WriteString("  ")
-				sb.WriteString(ansiDim)
-				sb.WriteString(ansiItalic)
-				sb.WriteString(preview)
-				sb.WriteString(ansiReset)
-				usedWidth += 2 + len(preview)
-			}
+// sessionsUsage sums the token usage of the sessions known to the daemon.
+func sessionsUsage(state *dashboardState) history.Usage {
+	var total history.Usage
+	for _, list := range [][]*Session{state.Active, state.Suspended, state.Completed} {
+		for _, sess := range list {
+			total = total.Add(sess.Usage)
 		}
+	}
+	return total
+}
 
-		// Right-align age
-		padding := termWidth - usedWidth - rightFixed
-		if padding < 2 {
-			padding = 2
+// pipeline is a group of chained sessions sharing a prompt argument (e.g.
+// an issue ID), in the order they were started.
+type pipeline struct {
+	Chain    string
+	Sessions []*Session
+}
+
+// pending returns the next stage offered by the last session of the
+// pipeline, or "" when there is none to start.
+func (p pipeline) pending() string {
+	last := p.Sessions[len(p.Sessions)-1]
+	if last.Status != "completed" || last.NextStarted {
+		return ""
+	}
+	return last.Next
+}
+
+// splitPipelines moves chained sessions out of the status sections and
+// groups them by pipeline, ordered by when each pipeline started.
+func splitPipelines(state *dashboardState) []pipeline {
+	var chained []*Session
+	take := func(sessions []*Session) []*Session {
+		var rest []*Session
+		for _, sess := range sessions {
+			if sess.Chain != "" {
+				chained = append(chained, sess)
+			} else {
+				rest = append(rest, sess)
+			}
 		}
-		sb.WriteString(strings.Repeat(" ", padding))
-		sb.WriteString(ansiMuted)
-		sb.WriteString(age)
-		sb.WriteString(ansiReset)
+		return rest
+	}
+	state.Active = take(state.Active)
+	state.Suspended = take(state.Suspended)
+	state.Completed = take(state.Completed)
+
+	sort.SliceStable(chained, func(i, j int) bool {
+		return chained[i].StartedAt.Before(chained[j].StartedAt)
+	})
+
+	var pipelines []pipeline
+	index := make(map[string]int)
+	for _, sess := range chained {
+		i, ok := index[sess.Chain]
+		if !ok {
+			i = len(pipelines)
+			index[sess.Chain] = i
+			pipelines = append(pipelines, pipeline{Chain: sess.Chain})
+		}
+		pipelines[i].Sessions = append(pipelines[i].Sessions, sess)
+	}
+	return pipelines
+}
 
+func (cmd *DashboardCmd) renderPipelinesSection(sb *strings.Builder, pipelines []pipeline, termWidth int) {
+	sb.WriteString("  ")
+	sb.WriteString(ansiMuted)
+	sb.WriteString("PIPELINES")
+	sb.WriteString(ansiReset)
+	sb.WriteString("\r\n")
+
+	for _, p := range pipelines {
+		sb.WriteString("    ")
+		sb.WriteString(ansiAccent)
+		sb.WriteString("▸ ")
+		sb.WriteString(ansiBold)
+		sb.WriteString(p.Chain)
+		sb.WriteString(ansiReset)
 		sb.WriteString("\r\n")
+
+		for _, sess := range p.Sessions {
+			color := ansiMuted
+			switch sess.Status {
+			case "active":
+				color = ansiGreen
+			case "suspended":
+				color = ansiYellow
+			}
+			cmd.renderSessionRow(sb, sess, color, 6, termWidth)
+		}
+
+		if next := p.pending(); next != "" {
+			sb.WriteString("      ")
+			sb.WriteString(ansiMuted)
+			sb.WriteString(ansiItalic)
+			sb.WriteString("⏭ next: " + next + " (Ctrl-b r to start)")
+			sb.WriteString(ansiReset)
+			sb.WriteString("\r\n")
+		}
 	}
 	sb.WriteString("\r\n")
 }
@@ -304,6 +614,153 @@ func (cmd *DashboardCmd) renderIndexingSection(sb *strings.Builder, tasks []Inde
 	sb.WriteString("\r\n")
 }
 
+func (cmd *DashboardCmd) renderJobsSection(sb *strings.Builder, jobs []api.Job, termWidth int) {
+	sb.WriteString("  ")
+	sb.WriteString(ansiMuted)
+	sb.WriteString("JOBS")
+	sb.WriteString(ansiReset)
+	sb.WriteString("\r\n")
+
+	for _, job := range jobs {
+		var color, age string
+		switch job.Status {
+		case "queued":
+			color = ansiMuted
+			age = "queued " + formatAge(time.Since(job.QueuedAt))
+		case "running":
+			color = ansiCyan
+			age = formatAge(time.Since(job.StartedAt))
+		case "succeeded":
+			color = ansiGreen
+			age = formatAge(time.Since(job.EndedAt))
+		default:
+			color = ansiOrange
+			age = formatAge(time.Since(job.EndedAt))
+		}
+
+		// Layout: indent(4) + icon(1) + space(1) + indicator(2) + space(1) + profile + gap + prompt + gap + age
+		const indent = 4
+		leftFixed := indent + 1 + 1 + 2 + 1 + len(job.Profile)
+		rightFixed := len(age) + 2
+
+		sb.WriteString("    ")
+		sb.WriteString(color)
+		sb.WriteString(jobStatusIcon(job.Status))
+		sb.WriteString(ansiReset)
+		sb.WriteString(" ")
+		sb.WriteString(job.Indicator)
+		sb.WriteString(" ")
+		sb.WriteString(ansiBold)
+		sb.WriteString(job.Profile)
+		sb.WriteString(ansiReset)
+
+		usedWidth := leftFixed
+		maxPrompt := termWidth - leftFixed - rightFixed - 4
+		if maxPrompt > 3 {
+			prompt := job.Prompt
+			if len(prompt) > maxPrompt {
+				prompt = prompt[:maxPrompt-3] + "..."
+			}
+			sb.WriteString("  ")
+			sb.WriteString(ansiDim)
+			sb.WriteString(ansiItalic)
+			sb.WriteString(prompt)
+			sb.WriteString(ansiReset)
+			usedWidth += 2 + len(prompt)
+		}
+
+		padding := termWidth - usedWidth - rightFixed
+		if padding < 2 {
+			padding = 2
+		}
+		sb.WriteString(strings.Repeat(" ", padding))
+		sb.WriteString(ansiMuted)
+		sb.WriteString(age)
+		sb.WriteString(ansiReset)
+		sb.WriteString("\r\n")
+	}
+	sb.WriteString("\r\n")
+}
+
+func (cmd *DashboardCmd) renderSchedulesSection(sb *strings.Builder, schedules []ScheduleStatus, termWidth int) {
+	sb.WriteString("  ")
+	sb.WriteString(ansiMuted)
+	sb.WriteString("SCHEDULED")
+	sb.WriteString(ansiReset)
+	sb.WriteString("\r\n")
+
+	for _, sc := range schedules {
+		var when string
+		switch {
+		case sc.Error != "":
+			when = "invalid cron"
+		case sc.NextRun.IsZero():
+			when = "never"
+		default:
+			when = sc.NextRun.Local().Format("Mon 15:04") + " (" + formatUntil(time.Until(sc.NextRun)) + ")"
+		}
+
+		// Layout: indent(4) + icon(1) + space(1) + name + gap + profile + gap + last result + gap + when
+		const indent = 4
+		leftFixed := indent + 2 + len(sc.Name) + 2 + len(sc.Profile)
+		rightFixed := len(when) + 2
+
+		sb.WriteString("    ")
+		if sc.Error != "" {
+			sb.WriteString(ansiOrange)
+		} else {
+			sb.WriteString(ansiTeal)
+		}
+		sb.WriteString("⏲")
+		sb.WriteString(ansiReset)
+		sb.WriteString(" ")
+		sb.WriteString(ansiBold)
+		sb.WriteString(sc.Name)
+		sb.WriteString(ansiReset)
+		sb.WriteString("  ")
+		sb.WriteString(ansiDim)
+		sb.WriteString(sc.Profile)
+		sb.WriteString(ansiReset)
+
+		usedWidth := leftFixed
+		if sc.LastResult == "skipped" || sc.LastResult == "failed" {
+			note := "last run " + sc.LastResult
+			sb.WriteString("  ")
+			sb.WriteString(ansiYellow)
+			sb.WriteString(ansiItalic)
+			sb.WriteString(note)
+			sb.WriteString(ansiReset)
+			usedWidth += 2 + len(note)
+		}
+
+		padding := termWidth - usedWidth - rightFixed
+		if padding < 2 {
+			padding = 2
+		}
+		sb.WriteString(strings.Repeat(" ", padding))
+		sb.WriteString(ansiMuted)
+		sb.WriteString(when)
+		sb.WriteString(ansiReset)
+		sb.WriteString("\r\n")
+	}
+	sb.WriteString("\r\n")
+}
+
+// formatUntil formats the time left before an upcoming event.
+func formatUntil(d time.Duration) string {
+	m := int(d.Minutes())
+	if m < 1 {
+		return "now"
+	}
+	if m < 60 {
+		return fmt.Sprintf("in %dm", m)
+	}
+	if m < 24*60 {
+		return fmt.Sprintf("in %dh%dm", m/60, m%60)
+	}
+	return fmt.Sprintf("in %dd%dh", m/(24*60), (m%(24*60))/60)
+}
+
 func formatAge(d time.Duration) string {
 	s := int(d.Seconds())
 	if s < 60 {
@@ -267,6 +267,12 @@ func buildEphemeralShellCmd(cfg *EphemeralConfig, sessionID string, profile Prof
 	runParts = append(runParts, "--name", shelljoin("vee-"+sessionID))
 	runParts = append(runParts, "--add-host", "host.docker.internal:host-gateway")
 
+	// Label the container so that vee gc can find it if this instance dies
+	projectDir, _ := filepath.Abs(".")
+	runParts = append(runParts, "--label", shelljoin(sessionLabel+"="+sessionID))
+	runParts = append(runParts, "--label", shelljoin(projectLabel+"="+projectDir))
+	runParts = append(runParts, "--label", shelljoin(profileLabel+"="+profile.Name))
+
 	// Connect to Compose network when compose is configured
 	if cfg.Compose != "" {
 		runParts = append(runParts, "--network", shelljoin(project+"_default"))
@@ -320,9 +326,15 @@ func buildEphemeralShellCmd(cfg *EphemeralConfig, sessionID string, profile Prof
 	overlayIndex := 0
 
 	// GPG signing uses the daemon's /api/gpg/sign endpoint via the wrapper script.
-	// Set the daemon port so the wrapper knows where to connect.
+	// Set the daemon port so the wrapper knows where to connect, and the file
+	// holding the session token it authenticates with.
 	if gpgCfg != nil {
 		runParts = append(runParts, "-e", shelljoin(fmt.Sprintf("VEE_DAEMON_PORT=%d", port)))
+		if tokenFile, err := writeSessionTokenFile(port, sessionID); err != nil {
+			slog.Error("failed to write session token", "error", err)
+		} else {
+			runParts = append(runParts, "-e", shelljoin("VEE_TOKEN_FILE="+tokenFile))
+		}
 	}
 
 	// User mounts
@@ -411,9 +423,10 @@ func writeMCPConfigDocker(port int, sessionID string) (string, error) {
 	}
 
 	path := filepath.Join(dir, "mcp.json")
-	content := fmt.Sprintf(`{"mcpServers":{"vee-daemon":{"type":"sse","url":"http://host.docker.internal:%d/sse?session=%s"}}}`, port, sessionID)
+	sseURL := fmt.Sprintf("http://host.docker.internal:%d/sse?session=%s", port, sessionID)
+	content := mcpConfigJSON(sseURL, sessionToken(port, sessionID))
 
-	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+	if err := os.WriteFile(path, content, 0600); err != nil {
 		return "", err
 	}
 
@@ -421,6 +434,20 @@ func writeMCPConfigDocker(port int, sessionID string) (string, error) {
 	return path, nil
 }
 
+// writeSessionTokenFile writes the session token to the session temp dir,
+// mounted in the container, for the scripts that call the daemon.
+func writeSessionTokenFile(port int, sessionID string) (string, error) {
+	dir := sessionTempDir(sessionID)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "token")
+	if err := os.WriteFile(path, []byte(sessionToken(port, sessionID)), 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
 // writeEphemeralSettings writes a settings file with curl-based hooks suitable
 // for use inside a Docker container (no vee binary required, just curl).
 // Uses a shell pipeline that reads stdin once, enriches it with flags, and
@@ -433,26 +460,33 @@ func writeEphemeralSettings(sessionID string, port int) (string, error) {
 
 	baseURL := fmt.Sprintf("http://host.docker.internal:%d/api/hook/window-state?session=%s",
 		port, sessionID)
+	auth := "Authorization: Bearer " + sessionToken(port, sessionID)
 
 	// UserPromptSubmit: merge working=true, notification=false into the hook JSON, then POST
 	promptSubmitCmd := fmt.Sprintf(
-		`jq -c '. + {"working":true,"notification":false}' | curl -sf -X POST '%s' -H 'Content-Type: application/json' -d @-`,
-		baseURL)
+		`jq -c '. + {"working":true,"notification":false}' | curl -sf -X POST '%s' -H '%s' -H 'Content-Type: application/json' -d @-`,
+		baseURL, auth)
 
 	// Stop: merge working=false into the hook JSON, then POST
 	stopCmd := fmt.Sprintf(
-		`jq -c '. + {"working":false}' | curl -sf -X POST '%s' -H 'Content-Type: application/json' -d @-`,
-		baseURL)
+		`jq -c '. + {"working":false}' | curl -sf -X POST '%s' -H '%s' -H 'Content-Type: application/json' -d @-`,
+		baseURL, auth)
+
+	// Stop: upload the transcript, which lives inside the container and is
+	// lost with it, so the daemon can archive it when the session ends
+	transcriptCmd := fmt.Sprintf(
+		`f=$(jq -r '.transcript_path // empty'); [ -f "$f" ] && curl -sf -X POST 'http://host.docker.internal:%d/api/hook/transcript?session=%s' -H '%s' -H 'Content-Type: application/x-ndjson' --data-binary @"$f"`,
+		port, sessionID, auth)
 
 	// PostToolUseFailure: clear working only when is_interrupt is true
 	interruptCmd := fmt.Sprintf(
-		`jq -ce 'select(.is_interrupt == true) | . + {"working":false}' | curl -sf -X POST '%s' -H 'Content-Type: application/json' -d @-`,
-		baseURL)
+		`jq -ce 'select(.is_interrupt == true) | . + {"working":false}' | curl -sf -X POST '%s' -H '%s' -H 'Content-Type: application/json' -d @-`,
+		baseURL, auth)
 
 	// Notification: merge notification=true into the hook JSON, then POST
 	notifCmd := fmt.Sprintf(
-		`jq -c '. + {"notification":true}' | curl -sf -X POST '%s' -H 'Content-Type: application/json' -d @-`,
-		baseURL)
+		`jq -c '. + {"notification":true}' | curl -sf -X POST '%s' -H '%s' -H 'Content-Type: application/json' -d @-`,
+		baseURL, auth)
 
 	settings := map[string]any{
 		"hooks": map[string]any{
@@ -473,6 +507,10 @@ func writeEphemeralSettings(sessionID string, port int) (string, error) {
 							"type":    "command",
 							"command": stopCmd,
 						},
+						{
+							"type":    "command",
+							"command": transcriptCmd,
+						},
 					},
 				},
 			},
@@ -90,6 +90,12 @@ func TestBuildEphemeralShellCmdWithoutCompose(t *testing.T) {
 		t.Errorf("unexpected --network flag in output, got:\n%s", cmd)
 	}
 
+	// Should label the container with its session and profile, for vee gc
+	for _, label := range []string{"--label vee.session=test-session-456", "--label vee.profile=vibe", "--label vee.project="} {
+		if !strings.Contains(cmd, label) {
+			t.Errorf("expected %q in output, got:\n%s", label, cmd)
+		}
+	}
 }
 
 func TestComposeSystemPromptInjection(t *testing.T) {
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lthms/vee/internal/api"
+	"github.com/lthms/vee/internal/kb"
+)
+
+// Event types published on /api/events. Session events carry the whole
+// session, so applying one twice is harmless.
+const (
+	evSessionCreated    = "session.created"       // Session
+	evSessionStatus     = "session.status"        // Session, moved between active, suspended and completed
+	evSessionWindow     = "session.window"        // Session, working/notification/permission mode changed
+	evSessionPreview    = "session.preview"       // Session, preview text changed
+	evSessionUpdated    = "session.updated"       // Session, any other field changed (usage, pipeline, parent…)
+	evSessionRemoved    = "session.removed"       // {"id"}
+	evIndexingProgress  = "indexing.progress"     // {"tasks"}, the statements being indexed
+	evStatementPromoted = "kb.statement_promoted" // {"id"}
+	evIssueOpened       = "kb.issue_opened"       // {"id", "open_issues"}
+	evIssueResolved     = "kb.issue_resolved"     // {"id", "open_issues"}
+	evJobs              = "jobs.changed"          // {"jobs"}
+	evSchedules         = "schedules.changed"     // {"schedules"}
+
+	// evResync tells a reconnecting client that the events it missed are no
+	// longer available, and that it must fetch /api/state again.
+	evResync = "resync"
+)
+
+// defaultEventBacklog is how many events are kept for clients catching up
+// after a reconnection.
+const defaultEventBacklog = 512
+
+// eventBufferSize is how many events a subscriber may lag behind before it
+// is disconnected. It then catches up from the backlog on reconnection.
+const eventBufferSize = 64
+
+// Event is a state change of the daemon.
+type Event = api.Event
+
+// eventBus fans events out to the /api/events subscribers, and keeps the
+// latest ones so that a client can resume after a disconnection.
+//
+// Event IDs are a sequence number prefixed by an epoch that changes on
+// every daemon start, so that a client resuming against a restarted daemon
+// is told to resync instead of missing events.
+type eventBus struct {
+	mu      sync.Mutex
+	epoch   string
+	seq     uint64
+	backlog []Event // oldest first, at most size events
+	size    int
+	subs    map[chan Event]struct{}
+}
+
+func newEventBus(size int) *eventBus {
+	return &eventBus{
+		epoch: strconv.FormatInt(time.Now().UnixNano(), 36),
+		size:  max(size, 1),
+		subs:  make(map[chan Event]struct{}),
+	}
+}
+
+// publish sends an event to every subscriber. data is encoded right away,
+// so callers may publish state they are holding a lock on. A subscriber too
+// slow to keep up is disconnected.
+func (b *eventBus) publish(typ string, data any) {
+	if b == nil {
+		return
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		slog.Warn("failed to encode event", "type", typ, "error", err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	ev := Event{
+		ID:   b.id(b.seq),
+		Type: typ,
+		Time: time.Now(),
+		Data: raw,
+	}
+	if len(b.backlog) == b.size {
+		b.backlog = append(b.backlog[:0], b.backlog[1:]...)
+	}
+	b.backlog = append(b.backlog, ev)
+
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default:
+			slog.Debug("event subscriber too slow, disconnecting")
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// lastID returns the ID of the latest event, or an ID before any event.
+func (b *eventBus) lastID() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.id(b.seq)
+}
+
+// id formats the ID of the event with sequence number seq.
+func (b *eventBus) id(seq uint64) string {
+	return b.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+// subscribe registers a subscriber. When lastID is set, it also returns the
+// events published after it, or a single resync event when they can't all
+// be replayed (too old, or from another daemon run). The channel is closed
+// by unsubscribe, or when the subscriber lags behind.
+func (b *eventBus) subscribe(lastID string) (missed []Event, ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if lastID != "" {
+		var ok bool
+		if missed, ok = b.since(lastID); !ok {
+			missed = []Event{{
+				ID:   b.id(b.seq),
+				Type: evResync,
+				Time: time.Now(),
+				Data: json.RawMessage("{}"),
+			}}
+		}
+	}
+	ch = make(chan Event, eventBufferSize)
+	b.subs[ch] = struct{}{}
+	return missed, ch
+}
+
+// since returns the backlog events after id. Callers hold b.mu.
+func (b *eventBus) since(id string) ([]Event, bool) {
+	epoch, seq, ok := splitEventID(id)
+	if !ok || epoch != b.epoch || seq > b.seq {
+		return nil, false
+	}
+	if seq == b.seq {
+		return nil, true
+	}
+	oldest := b.seq - uint64(len(b.backlog)) + 1
+	if seq+1 < oldest {
+		return nil, false
+	}
+	return append([]Event(nil), b.backlog[seq+1-oldest:]...), true
+}
+
+// splitEventID splits an event ID into its epoch and sequence number.
+func splitEventID(id string) (string, uint64, bool) {
+	epoch, seqStr, found := strings.Cut(id, "-")
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	return epoch, seq, found && err == nil
+}
+
+// unsubscribe removes a subscriber and closes its channel.
+func (b *eventBus) unsubscribe(ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[ch]; ok {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// publishKBEvent turns the progress of the knowledge base into events, and
+// tracks the statements being indexed.
+func publishKBEvent(app *App, e kb.Event) {
+	switch e.Type {
+	case kb.EventIndexing:
+		app.Indexing.add(e.ID, truncateRunes(firstLine(e.Content), 60))
+	case kb.EventIndexed:
+		app.Indexing.remove(e.ID)
+	case kb.EventStatementPromoted:
+		app.Events.publish(evStatementPromoted, map[string]string{"id": e.ID})
+	case kb.EventIssueOpened:
+		app.Events.publish(evIssueOpened, map[string]any{"id": e.ID, "open_issues": e.OpenIssues})
+	}
+}
+
+// handleEvents handles GET /api/events, a server-sent event stream of the
+// daemon's state changes. A client resuming with a Last-Event-ID header (or
+// a last_event_id query parameter) first receives the events it missed, or
+// a resync event when they are no longer available.
+func handleEvents(app *App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		flusher, canFlush := w.(http.Flusher)
+		if !canFlush {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		lastID := r.Header.Get("Last-Event-ID")
+		if lastID == "" {
+			lastID = r.URL.Query().Get("last_event_id")
+		}
+		missed, ch := app.Events.subscribe(lastID)
+		defer app.Events.unsubscribe(ch)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+
+		for _, ev := range missed {
+			writeEvent(w, ev)
+		}
+		flusher.Flush()
+
+		keepalive := time.NewTicker(defaultSSEKeepaliveInterval)
+		defer keepalive.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case ev, open := <-ch:
+				if !open {
+					return // lagging behind: the client resumes from its last ID
+				}
+				if err := writeEvent(w, ev); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-keepalive.C:
+				if _, err := w.Write([]byte(": keepalive\n\n")); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// writeEvent writes an event in the SSE wire format.
+func writeEvent(w http.ResponseWriter, ev Event) error {
+	payload, _ := json.Marshal(ev)
+	_, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, payload)
+	return err
+}
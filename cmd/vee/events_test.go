@@ -0,0 +1,174 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lthms/vee/internal/api"
+)
+
+func TestEventBusBackfill(t *testing.T) {
+	b := newEventBus(3)
+	start := b.lastID()
+	for _, typ := range []string{"a", "b", "c", "d"} {
+		b.publish(typ, map[string]string{})
+	}
+
+	types := func(evs []Event) string {
+		var names []string
+		for _, ev := range evs {
+			names = append(names, ev.Type)
+		}
+		return strings.Join(names, ",")
+	}
+
+	// The backlog holds b, c and d: resuming after a gets everything missed.
+	missed, ch := b.subscribe(b.id(1))
+	if got := types(missed); got != "b,c,d" {
+		t.Errorf("resume after 1: got %q, want b,c,d", got)
+	}
+	b.unsubscribe(ch)
+
+	missed, ch = b.subscribe(b.lastID())
+	if len(missed) != 0 {
+		t.Errorf("resume at the latest event: got %q, want nothing", types(missed))
+	}
+	b.unsubscribe(ch)
+
+	// a was dropped from the backlog, as are events of another daemon run.
+	for _, id := range []string{start, "other-1", b.id(9), "garbage"} {
+		missed, ch = b.subscribe(id)
+		if got := types(missed); got != evResync {
+			t.Errorf("resume after %q: got %q, want a resync", id, got)
+		}
+		if missed[0].ID != b.lastID() {
+			t.Errorf("resync ID = %q, want %q", missed[0].ID, b.lastID())
+		}
+		b.unsubscribe(ch)
+	}
+
+	// New subscribers get no backlog, then every new event.
+	missed, ch = b.subscribe("")
+	defer b.unsubscribe(ch)
+	if len(missed) != 0 {
+		t.Errorf("new subscriber: got %q, want nothing", types(missed))
+	}
+	b.publish("e", map[string]string{"k": "v"})
+	ev := <-ch
+	if ev.Type != "e" || string(ev.Data) != `{"k":"v"}` || ev.ID != b.id(5) {
+		t.Errorf("unexpected event %+v", ev)
+	}
+}
+
+func TestEventBusDropsSlowSubscribers(t *testing.T) {
+	b := newEventBus(defaultEventBacklog)
+	_, ch := b.subscribe("")
+	for range eventBufferSize + 1 {
+		b.publish("x", nil)
+	}
+	n := 0
+	for range ch {
+		n++
+	}
+	if n != eventBufferSize {
+		t.Errorf("received %d events before being dropped, want %d", n, eventBufferSize)
+	}
+	b.unsubscribe(ch) // no-op once dropped
+}
+
+func TestHandleEventsResumes(t *testing.T) {
+	app := newApp()
+	srv := httptest.NewServer(handleEvents(app))
+	defer srv.Close()
+
+	app.Sessions.create("s1", "normal", "🦊", "", "@1", false, "", "", "", nil, nil, ToolPermissions{})
+	resumeFrom := app.Events.lastID()
+	app.Sessions.setStatus("s1", "suspended")
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Last-Event-ID", resumeFrom)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /api/events: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	events := make(chan Event, 10)
+	go api.ReadEvents(resp.Body, func(ev Event) { events <- ev })
+
+	next := func() Event {
+		t.Helper()
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for an event")
+			return Event{}
+		}
+	}
+	if ev := next(); ev.Type != evSessionStatus {
+		t.Fatalf("backfilled event = %s, want %s", ev.Type, evSessionStatus)
+	}
+	app.Sessions.drop("s1")
+	if ev := next(); ev.Type != evSessionRemoved || string(ev.Data) != `{"id":"s1"}` {
+		t.Fatalf("live event = %+v", ev)
+	}
+}
+
+func TestDashboardStateApply(t *testing.T) {
+	b := newEventBus(defaultEventBacklog)
+	store := newSessionStore()
+	store.events = b
+	_, ch := b.subscribe("")
+	defer b.unsubscribe(ch)
+
+	state := &dashboardState{EventID: b.lastID(), IssueCount: 2}
+	store.create("s1", "normal", "🦊", "", "@1", false, "", "", "", nil, nil, ToolPermissions{})
+	store.create("s2", "vibe", "⚡", "", "@2", false, "", "", "", nil, nil, ToolPermissions{})
+	store.setPreview("s1", "fixing the build")
+	store.setStatus("s2", "completed")
+	b.publish(evIssueResolved, map[string]any{"id": "i1", "open_issues": 1})
+	b.publish(evIssueOpened, map[string]any{"id": "i2", "open_issues": 2})
+	b.publish(evJobs, map[string]any{"jobs": []api.Job{{ID: "j1", Status: "running"}}})
+
+	var applied []Event
+	for range 7 {
+		ev := <-ch
+		applied = append(applied, ev)
+		if !state.apply(ev) {
+			t.Fatalf("apply(%s) asked for a resync", ev.Type)
+		}
+	}
+
+	if len(state.Active) != 1 || state.Active[0].ID != "s1" || state.Active[0].Preview != "fixing the build" {
+		t.Errorf("active = %+v", state.Active)
+	}
+	if len(state.Completed) != 1 || state.Completed[0].ID != "s2" {
+		t.Errorf("completed = %+v", state.Completed)
+	}
+	if state.IssueCount != 2 {
+		t.Errorf("issue count = %d, want 2", state.IssueCount)
+	}
+	if len(state.Jobs) != 1 || state.Jobs[0].ID != "j1" {
+		t.Errorf("jobs = %+v", state.Jobs)
+	}
+
+	// Replayed events the state already includes are skipped.
+	if !state.apply(applied[0]) || len(state.Active) != 1 || state.Active[0].Preview != "fixing the build" {
+		t.Errorf("replaying an old event changed the state: %+v", state.Active)
+	}
+	for _, ev := range applied {
+		if ev.Type == evIssueOpened && (!state.apply(ev) || state.IssueCount != 2) {
+			t.Errorf("replaying an opened issue changed the count to %d", state.IssueCount)
+		}
+	}
+	if state.apply(Event{Type: evResync}) {
+		t.Error("apply(resync) should ask for a resync")
+	}
+}
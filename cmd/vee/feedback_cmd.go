@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/lthms/vee/internal/feedback"
+)
+
+// FeedbackCmd groups the feedback management subcommands.
+type FeedbackCmd struct {
+	Export FeedbackExportCmd `cmd:"" help:"Export local feedback examples to a file."`
+}
+
+// FeedbackExportCmd writes feedback entries from the local database into the
+// repo-committed project feedback file, so teammates get them too.
+type FeedbackExportCmd struct {
+	Project bool `required:"" help:"Export this project's project-scoped examples into .vee/feedback.yaml."`
+}
+
+// Run exports the current project's entries, merging with the existing file.
+func (cmd *FeedbackExportCmd) Run() error {
+	project, err := filepath.Abs(".")
+	if err != nil {
+		return fmt.Errorf("resolve project directory: %w", err)
+	}
+
+	stDir, err := stateDir()
+	if err != nil {
+		return fmt.Errorf("state dir: %w", err)
+	}
+	fstore, err := feedback.Open(filepath.Join(stDir, "feedback.db"))
+	if err != nil {
+		return fmt.Errorf("open feedback store: %w", err)
+	}
+	defer fstore.Close()
+
+	entries, err := fstore.List(feedback.ListFilter{Scope: "project", Project: project})
+	if err != nil {
+		return err
+	}
+
+	added, err := feedback.WriteProjectFile(project, entries)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %d new example(s) to %s (%d already present)\n", added, feedback.ProjectFile, len(entries)-added)
+	return nil
+}
@@ -0,0 +1,764 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lthms/vee/internal/api"
+	"github.com/lthms/vee/internal/feedback"
+	"golang.org/x/term"
+)
+
+// FeedbackExplorerCmd is the internal subcommand that shows an interactive
+// feedback browser, rendered inside a tmux display-popup.
+type FeedbackExplorerCmd struct {
+	Port    int    `short:"p" default:"2700" name:"port"`
+	Project string `name:"project" help:"Project directory used when moving an example to project scope."`
+}
+
+const (
+	fbStateList    = 0
+	fbStateDetail  = 1
+	fbStateEdit    = 2
+	fbStateConfirm = 3
+)
+
+type feedbackExplorerState struct {
+	port    int
+	project string
+
+	state    int // one of the fbState* constants
+	profiles []string
+	profile  int // index into profiles
+	all      []feedback.RankedEntry
+	entries  []feedback.RankedEntry // entries for the selected profile
+	selected int
+	byRank   bool   // sort by outcome score instead of recency
+	message  string // transient status message
+
+	// Detail view
+	detailLines  []string
+	detailScroll int
+
+	// Edit state
+	editBuf  []rune
+	prevView int // state to return to after editing or confirming
+
+	termWidth  int
+	termHeight int
+}
+
+func (cmd *FeedbackExplorerCmd) Run() error {
+	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return err
+	}
+	defer term.Restore(int(os.Stdin.Fd()), oldState)
+
+	fmt.Print("\033[?25l") // hide cursor
+	defer fmt.Print("\033[?25h")
+
+	w, h, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || w < 40 {
+		w = 100
+	}
+	if err != nil || h < 10 {
+		h = 40
+	}
+
+	project := cmd.Project
+	if project == "" {
+		project, _ = filepath.Abs(".")
+	}
+
+	fs := &feedbackExplorerState{
+		port:       cmd.Port,
+		project:    project,
+		termWidth:  w,
+		termHeight: h,
+	}
+
+	fs.fetchEntries()
+	fs.render()
+
+	inputCh := make(chan []byte, 1)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(inputCh)
+				return
+			}
+			data := make([]byte, n)
+			copy(data, buf[:n])
+			inputCh <- data
+		}
+	}()
+
+	for {
+		input, ok := <-inputCh
+		if !ok {
+			return nil
+		}
+
+		var quit bool
+		switch fs.state {
+		case fbStateList:
+			quit = fs.handleListInput(input)
+		case fbStateDetail:
+			quit = fs.handleDetailInput(input)
+		case fbStateEdit:
+			fs.handleEditInput(input)
+		case fbStateConfirm:
+			fs.handleConfirmInput(input)
+		}
+		if quit {
+			return nil
+		}
+
+		fs.render()
+	}
+}
+
+// handleListInput processes input in the list view.
+// Returns true if the explorer should exit.
+func (fs *feedbackExplorerState) handleListInput(input []byte) bool {
+	if len(input) == 1 {
+		switch input[0] {
+		case 'q', 27: // q or Esc
+			return true
+		case 10, 13: // Enter — open detail
+			if fs.current() != nil {
+				fs.openDetail()
+			}
+		case 'j':
+			fs.moveSelection(1)
+		case 'k':
+			fs.moveSelection(-1)
+		case 'h':
+			fs.switchProfile(-1)
+		case 'l', 9: // l or Tab
+			fs.switchProfile(1)
+		case 'r':
+			fs.byRank = !fs.byRank
+			fs.selected = 0
+			fs.fetchEntries()
+		default:
+			fs.handleAction(input[0])
+		}
+	} else if len(input) == 3 && input[0] == 27 && input[1] == 91 {
+		switch input[2] {
+		case 65: // Up
+			fs.moveSelection(-1)
+		case 66: // Down
+			fs.moveSelection(1)
+		case 67: // Right
+			fs.switchProfile(1)
+		case 68: // Left
+			fs.switchProfile(-1)
+		}
+	} else if len(input) == 2 && input[0] == 27 {
+		return true
+	}
+	return false
+}
+
+// handleDetailInput processes input in the detail view.
+// Returns true if the explorer should exit.
+func (fs *feedbackExplorerState) handleDetailInput(input []byte) bool {
+	if len(input) == 1 {
+		switch input[0] {
+		case 'q':
+			return true
+		case 27: // Esc — back to list
+			fs.state = fbStateList
+		case 'j':
+			fs.scrollDetail(1)
+		case 'k':
+			fs.scrollDetail(-1)
+		default:
+			fs.handleAction(input[0])
+		}
+	} else if len(input) == 3 && input[0] == 27 && input[1] == 91 {
+		switch input[2] {
+		case 65: // Up
+			fs.scrollDetail(-1)
+		case 66: // Down
+			fs.scrollDetail(1)
+		}
+	} else if len(input) == 2 && input[0] == 27 {
+		fs.state = fbStateList
+	}
+	return false
+}
+
+// handleAction runs the curation keys shared by the list and detail views.
+func (fs *feedbackExplorerState) handleAction(key byte) {
+	e := fs.current()
+	if e == nil {
+		return
+	}
+	switch key {
+	case 'e':
+		fs.prevView = fs.state
+		fs.editBuf = []rune(e.Statement)
+		fs.state = fbStateEdit
+	case 't':
+		kind := "bad"
+		if e.Kind == "bad" {
+			kind = "good"
+		}
+		fs.edit(e.ID, kind, e.Statement)
+	case 's':
+		fs.toggleScope(e)
+	case 'p':
+		fs.togglePin(e)
+	case 'd':
+		fs.prevView = fs.state
+		fs.state = fbStateConfirm
+	}
+}
+
+// handleEditInput processes input while editing a statement.
+func (fs *feedbackExplorerState) handleEditInput(input []byte) {
+	if len(input) == 1 {
+		switch input[0] {
+		case 27: // Esc — cancel
+			fs.editBuf = nil
+			fs.state = fs.prevView
+		case 10, 13: // Enter — save
+			if e := fs.current(); e != nil {
+				fs.state = fs.prevView
+				fs.edit(e.ID, e.Kind, strings.TrimSpace(string(fs.editBuf)))
+			}
+			fs.editBuf = nil
+		case 127, 8: // Backspace
+			if len(fs.editBuf) > 0 {
+				fs.editBuf = fs.editBuf[:len(fs.editBuf)-1]
+			}
+		case 21: // C-u — clear
+			fs.editBuf = nil
+		case 23: // C-w — delete last word
+			i := len(fs.editBuf)
+			for i > 0 && fs.editBuf[i-1] == ' ' {
+				i--
+			}
+			for i > 0 && fs.editBuf[i-1] != ' ' {
+				i--
+			}
+			fs.editBuf = fs.editBuf[:i]
+		default:
+			if input[0] >= 32 && input[0] < 127 {
+				fs.editBuf = append(fs.editBuf, rune(input[0]))
+			}
+		}
+		return
+	}
+
+	// Ignore escape sequences (arrows etc.); accept pasted or multi-byte text.
+	if input[0] == 27 || !utf8.Valid(input) {
+		return
+	}
+	for _, r := range string(input) {
+		if r == '\n' || r == '\r' || r == '\t' {
+			r = ' '
+		}
+		if r >= 32 && r != 127 {
+			fs.editBuf = append(fs.editBuf, r)
+		}
+	}
+}
+
+// handleConfirmInput processes the y/n answer to a delete confirmation.
+func (fs *feedbackExplorerState) handleConfirmInput(input []byte) {
+	fs.state = fs.prevView
+	if len(input) == 1 && (input[0] == 'y' || input[0] == 'Y') {
+		if e := fs.current(); e != nil {
+			fs.delete(e.ID)
+		}
+	}
+}
+
+func (fs *feedbackExplorerState) current() *feedback.RankedEntry {
+	if fs.selected < 0 || fs.selected >= len(fs.entries) {
+		return nil
+	}
+	return &fs.entries[fs.selected]
+}
+
+func (fs *feedbackExplorerState) moveSelection(delta int) {
+	if len(fs.entries) == 0 {
+		return
+	}
+	fs.selected += delta
+	if fs.selected < 0 {
+		fs.selected = 0
+	}
+	if fs.selected >= len(fs.entries) {
+		fs.selected = len(fs.entries) - 1
+	}
+}
+
+func (fs *feedbackExplorerState) switchProfile(delta int) {
+	if len(fs.profiles) == 0 {
+		return
+	}
+	fs.profile = (fs.profile + delta + len(fs.profiles)) % len(fs.profiles)
+	fs.selected = 0
+	fs.filterEntries()
+}
+
+func (fs *feedbackExplorerState) scrollDetail(delta int) {
+	fs.detailScroll += delta
+	if fs.detailScroll < 0 {
+		fs.detailScroll = 0
+	}
+	maxScroll := len(fs.detailLines) - (fs.termHeight - 8)
+	if maxScroll < 0 {
+		maxScroll = 0
+	}
+	if fs.detailScroll > maxScroll {
+		fs.detailScroll = maxScroll
+	}
+}
+
+// fetchEntries reloads every entry from the daemon and rebuilds the profile
+// tabs, keeping the current profile selected when it still exists.
+func (fs *feedbackExplorerState) fetchEntries() {
+	var current string
+	if fs.profile < len(fs.profiles) {
+		current = fs.profiles[fs.profile]
+	}
+
+	entries, err := daemonAPI(fs.port).FeedbackRanking(api.FeedbackFilter{})
+	if err != nil {
+		fs.all = nil
+		fs.message = "Error: " + err.Error()
+		fs.filterEntries()
+		return
+	}
+	if !fs.byRank {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].CreatedAt > entries[j].CreatedAt
+		})
+	}
+	fs.all = entries
+
+	seen := make(map[string]bool)
+	fs.profiles = nil
+	for _, e := range entries {
+		if !seen[e.Profile] {
+			seen[e.Profile] = true
+			fs.profiles = append(fs.profiles, e.Profile)
+		}
+	}
+	sort.Strings(fs.profiles)
+
+	fs.profile = 0
+	for i, p := range fs.profiles {
+		if p == current {
+			fs.profile = i
+		}
+	}
+	fs.filterEntries()
+}
+
+// filterEntries narrows the loaded entries to the selected profile.
+func (fs *feedbackExplorerState) filterEntries() {
+	fs.entries = nil
+	if fs.profile < len(fs.profiles) {
+		name := fs.profiles[fs.profile]
+		for _, e := range fs.all {
+			if e.Profile == name {
+				fs.entries = append(fs.entries, e)
+			}
+		}
+	}
+	if fs.selected >= len(fs.entries) {
+		fs.selected = len(fs.entries) - 1
+	}
+	if fs.selected < 0 {
+		fs.selected = 0
+	}
+	if fs.state == fbStateDetail {
+		if fs.current() == nil {
+			fs.state = fbStateList
+		} else {
+			fs.openDetail()
+		}
+	}
+}
+
+func (fs *feedbackExplorerState) openDetail() {
+	e := fs.current()
+	contentWidth := fs.termWidth - 8
+
+	var lines []string
+	lines = append(lines, kindBadge(e.Kind)+"  "+ansiMuted+scopeLabel(&e.Entry)+ansiReset)
+	lines = append(lines, "")
+	for _, line := range strings.Split(e.Statement, "\n") {
+		if len(line) <= contentWidth {
+			lines = append(lines, renderInlineMarkdown(line))
+		} else {
+			for _, wrapped := range wrapLine(line, contentWidth) {
+				lines = append(lines, renderInlineMarkdown(wrapped))
+			}
+		}
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, ansiDim+strings.Repeat("─", contentWidth)+ansiReset)
+	if e.Project != "" {
+		lines = append(lines, ansiMuted+"Project: "+e.Project+ansiReset)
+	}
+	lines = append(lines, ansiMuted+"Created: "+e.CreatedAt+ansiReset)
+	lines = append(lines, ansiMuted+fmt.Sprintf("Usage: %d sessions (%d good, %d bad), score %.2f",
+		e.Uses, e.GoodSessions, e.BadSessions, e.Score)+ansiReset)
+	lines = append(lines, ansiMuted+"ID: "+e.ID+ansiReset)
+
+	fs.detailLines = lines
+	fs.detailScroll = 0
+	fs.state = fbStateDetail
+}
+
+func (fs *feedbackExplorerState) edit(id, kind, statement string) {
+	err := daemonAPI(fs.port).EditFeedback(id, api.FeedbackEditRequest{Kind: kind, Statement: statement})
+	if fs.report(err) {
+		fs.message = "Saved"
+	}
+	fs.fetchEntries()
+}
+
+func (fs *feedbackExplorerState) toggleScope(e *feedback.RankedEntry) {
+	scope := "project"
+	if e.Scope == "project" {
+		scope = "user"
+	}
+	err := daemonAPI(fs.port).ScopeFeedback(e.ID, api.FeedbackScopeRequest{Scope: scope, Project: fs.project})
+	if fs.report(err) {
+		fs.message = "Scope: " + scope
+	}
+	fs.fetchEntries()
+}
+
+func (fs *feedbackExplorerState) togglePin(e *feedback.RankedEntry) {
+	if fs.report(daemonAPI(fs.port).PinFeedback(e.ID, !e.Pinned)) {
+		if e.Pinned {
+			fs.message = "Unpinned"
+		} else {
+			fs.message = "Pinned"
+		}
+	}
+	fs.fetchEntries()
+}
+
+func (fs *feedbackExplorerState) delete(id string) {
+	if fs.report(daemonAPI(fs.port).DeleteFeedback(id)) {
+		fs.message = "Deleted"
+	}
+	fs.state = fbStateList
+	fs.fetchEntries()
+}
+
+// report records the failure of a mutation, if any, in fs.message, and
+// returns whether it succeeded.
+func (fs *feedbackExplorerState) report(err error) bool {
+	if err == nil {
+		return true
+	}
+	var se *api.StatusError
+	if errors.As(err, &se) && se.Message != "" {
+		fs.message = "Error: " + se.Message
+	} else {
+		fs.message = "Error: " + err.Error()
+	}
+	return false
+}
+
+// kindBadge renders a colored GOOD/BAD label.
+func kindBadge(kind string) string {
+	if kind == "good" {
+		return ansiGreen + "GOOD" + ansiReset
+	}
+	return ansiOrange + "BAD " + ansiReset
+}
+
+// scopeLabel describes where an entry applies: "user" or "project:<dir>",
+// prefixed with "pinned" for pinned entries.
+func scopeLabel(e *feedback.Entry) string {
+	label := e.Scope
+	if e.Scope == "project" {
+		label = "project:" + filepath.Base(e.Project)
+	}
+	if e.Pinned {
+		label = "pinned " + label
+	}
+	return label
+}
+
+// usageLabel summarizes how often an entry was used and how its sessions
+// were rated, e.g. "4 uses +3 -1". Returns "unused" for fresh entries.
+func usageLabel(e *feedback.RankedEntry) string {
+	if e.Uses == 0 {
+		return "unused"
+	}
+	label := fmt.Sprintf("%d uses", e.Uses)
+	if e.GoodSessions > 0 || e.BadSessions > 0 {
+		label += fmt.Sprintf(" +%d -%d", e.GoodSessions, e.BadSessions)
+	}
+	return label
+}
+
+// render draws the current state to the terminal.
+func (fs *feedbackExplorerState) render() {
+	var sb strings.Builder
+	sb.WriteString("\033[2J\033[H")
+
+	switch fs.state {
+	case fbStateList:
+		fs.renderList(&sb)
+	case fbStateDetail:
+		fs.renderDetail(&sb)
+	case fbStateEdit:
+		fs.renderEdit(&sb)
+	case fbStateConfirm:
+		fs.renderConfirm(&sb)
+	}
+
+	fmt.Print(sb.String())
+}
+
+func (fs *feedbackExplorerState) renderHeader(sb *strings.Builder) {
+	sb.WriteString("\r\n  ")
+	sb.WriteString(ansiAccent)
+	sb.WriteString(ansiBold)
+	sb.WriteString("Feedback")
+	sb.WriteString(ansiReset)
+
+	// Profile tabs
+	for i, p := range fs.profiles {
+		if p == feedback.AllProfiles {
+			p = "all profiles"
+		}
+		sb.WriteString("  ")
+		if i == fs.profile {
+			sb.WriteString(ansiBold)
+			sb.WriteString("[" + p + "]")
+		} else {
+			sb.WriteString(ansiMuted)
+			sb.WriteString(p)
+		}
+		sb.WriteString(ansiReset)
+	}
+	sb.WriteString("\r\n\r\n")
+
+	if fs.message != "" {
+		sb.WriteString("  ")
+		if strings.HasPrefix(fs.message, "Error") {
+			sb.WriteString(ansiOrange)
+		} else {
+			sb.WriteString(ansiGreen)
+		}
+		sb.WriteString(fs.message)
+		sb.WriteString(ansiReset)
+		sb.WriteString("\r\n\r\n")
+		fs.message = ""
+	}
+}
+
+func (fs *feedbackExplorerState) renderList(sb *strings.Builder) {
+	w := fs.termWidth
+	fs.renderHeader(sb)
+
+	if len(fs.entries) == 0 {
+		sb.WriteString("  ")
+		sb.WriteString(ansiMuted)
+		sb.WriteString(ansiItalic)
+		sb.WriteString("No feedback recorded")
+		sb.WriteString(ansiReset)
+		sb.WriteString("\r\n")
+	} else {
+		// header(3) + message(2) + footer(3) = 8 lines overhead, each entry = 2 lines
+		maxVisible := (fs.termHeight - 8) / 2
+		if maxVisible < 1 {
+			maxVisible = 1
+		}
+		if maxVisible > len(fs.entries) {
+			maxVisible = len(fs.entries)
+		}
+
+		start := 0
+		if fs.selected >= maxVisible {
+			start = fs.selected - maxVisible + 1
+		}
+		end := start + maxVisible
+		if end > len(fs.entries) {
+			end = len(fs.entries)
+			start = end - maxVisible
+			if start < 0 {
+				start = 0
+			}
+		}
+
+		for i := start; i < end; i++ {
+			e := &fs.entries[i]
+
+			if i == fs.selected {
+				sb.WriteString("  ")
+				sb.WriteString(ansiAccent)
+				sb.WriteString("▸")
+				sb.WriteString(ansiReset)
+				sb.WriteString(" ")
+			} else {
+				sb.WriteString("    ")
+			}
+
+			sb.WriteString(kindBadge(e.Kind))
+
+			scope := scopeLabel(&e.Entry) + "  " + usageLabel(e)
+			date := formatVerifiedDate(e.CreatedAt)
+			preview := firstLine(e.Statement)
+			maxPreview := w - 4 - 4 - 2 - len(scope) - 2 - len(date) - 2
+			if maxPreview > 3 && len(preview) > maxPreview {
+				preview = preview[:maxPreview-3] + "..."
+			}
+
+			sb.WriteString("  ")
+			if i == fs.selected {
+				sb.WriteString(ansiBold)
+			}
+			sb.WriteString(preview)
+			if i == fs.selected {
+				sb.WriteString(ansiReset)
+			}
+
+			padding := w - 4 - 4 - 2 - len(preview) - len(scope) - 2 - len(date) - 2
+			if padding < 2 {
+				padding = 2
+			}
+			sb.WriteString(strings.Repeat(" ", padding))
+			sb.WriteString(ansiMuted)
+			sb.WriteString(scope)
+			sb.WriteString("  ")
+			sb.WriteString(date)
+			sb.WriteString(ansiReset)
+			sb.WriteString("\r\n\r\n")
+		}
+	}
+
+	sb.WriteString("\r\n  ")
+	sb.WriteString(ansiMuted)
+	sortHint := "r rank"
+	if fs.byRank {
+		sortHint = "r recency"
+	}
+	sb.WriteString("↑↓ navigate  ←→ profile  Enter view  e edit  t good/bad  s scope  p pin  d delete  " + sortHint + "  q quit")
+	sb.WriteString(ansiReset)
+	sb.WriteString("\r\n")
+}
+
+func (fs *feedbackExplorerState) renderDetail(sb *strings.Builder) {
+	sb.WriteString("\r\n  ")
+	sb.WriteString(ansiMuted)
+	sb.WriteString("← Esc back")
+	sb.WriteString(ansiReset)
+	sb.WriteString("\r\n\r\n")
+
+	if fs.message != "" {
+		sb.WriteString("  ")
+		sb.WriteString(ansiGreen)
+		sb.WriteString(fs.message)
+		sb.WriteString(ansiReset)
+		sb.WriteString("\r\n")
+		fs.message = ""
+	}
+
+	visibleLines := fs.termHeight - 8
+	if visibleLines < 1 {
+		visibleLines = 1
+	}
+
+	start := fs.detailScroll
+	end := start + visibleLines
+	if end > len(fs.detailLines) {
+		end = len(fs.detailLines)
+	}
+
+	for i := start; i < end; i++ {
+		sb.WriteString("    ")
+		sb.WriteString(fs.detailLines[i])
+		sb.WriteString("\r\n")
+	}
+
+	for i := end - start; i < visibleLines; i++ {
+		sb.WriteString("\r\n")
+	}
+
+	sb.WriteString("\r\n  ")
+	sb.WriteString(ansiMuted)
+	sb.WriteString("↑↓/jk scroll  e edit  t good/bad  s scope  p pin  d delete  Esc back  q quit")
+	sb.WriteString(ansiReset)
+	sb.WriteString("\r\n")
+}
+
+func (fs *feedbackExplorerState) renderEdit(sb *strings.Builder) {
+	contentWidth := fs.termWidth - 8
+
+	sb.WriteString("\r\n  ")
+	sb.WriteString(ansiAccent)
+	sb.WriteString(ansiBold)
+	sb.WriteString("Edit example")
+	sb.WriteString(ansiReset)
+	sb.WriteString("\r\n\r\n")
+
+	text := string(fs.editBuf)
+	lines := wrapLine(text, contentWidth)
+	if len(lines) == 0 {
+		lines = []string{""}
+	}
+	for i, line := range lines {
+		sb.WriteString("    ")
+		sb.WriteString(line)
+		if i == len(lines)-1 {
+			sb.WriteString(ansiMuted + "▏" + ansiReset)
+		}
+		sb.WriteString("\r\n")
+	}
+
+	sb.WriteString("\r\n  ")
+	sb.WriteString(ansiMuted)
+	sb.WriteString("Enter save  C-u clear  C-w delete word  Esc cancel")
+	sb.WriteString(ansiReset)
+	sb.WriteString("\r\n")
+}
+
+func (fs *feedbackExplorerState) renderConfirm(sb *strings.Builder) {
+	e := fs.current()
+
+	sb.WriteString("\r\n  ")
+	sb.WriteString(ansiOrange)
+	sb.WriteString(ansiBold)
+	sb.WriteString("Delete this example?")
+	sb.WriteString(ansiReset)
+	sb.WriteString("\r\n\r\n")
+
+	if e != nil {
+		for _, line := range wrapLine(e.Statement, fs.termWidth-8) {
+			sb.WriteString("    ")
+			sb.WriteString(line)
+			sb.WriteString("\r\n")
+		}
+	}
+
+	sb.WriteString("\r\n  ")
+	sb.WriteString(ansiMuted)
+	sb.WriteString("y delete  any other key cancel")
+	sb.WriteString(ansiReset)
+	sb.WriteString("\r\n")
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// ForkSessionCmd is the internal subcommand that starts a new session from
+// the conversation of an existing one, under another profile. Without a
+// profile it shows a tmux menu of profiles, whose items run it again with
+// the chosen one.
+type ForkSessionCmd struct {
+	Port       int    `short:"p" default:"2700" name:"port"`
+	WindowID   string `name:"window-id" help:"Window of the session to fork."`
+	SessionID  string `name:"session-id" help:"Session to fork."`
+	Profile    string `name:"profile" help:"Profile of the new session."`
+	TmuxSocket string `name:"tmux-socket" default:"vee" help:"Tmux socket name."`
+}
+
+// Run forks the session into a new window, or asks for the target profile.
+func (cmd *ForkSessionCmd) Run() error {
+	tmuxSocketName = cmd.TmuxSocket
+
+	var parent *Session
+	var err error
+	if cmd.SessionID != "" {
+		parent, err = fetchSession(cmd.Port, cmd.SessionID)
+	} else {
+		parent, err = fetchSessionByWindow(cmd.Port, cmd.WindowID)
+	}
+	if err != nil {
+		tmuxRun("display-message", "No session to fork in this window")
+		return nil
+	}
+
+	// The conversation of an ephemeral session lives in its container
+	if parent.Ephemeral {
+		tmuxRun("display-message", "Ephemeral sessions cannot be forked")
+		return nil
+	}
+
+	veeBinary, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to resolve executable path: %w", err)
+	}
+
+	cfg, err := fetchAppConfig(cmd.Port)
+	if err != nil {
+		return fmt.Errorf("failed to fetch config from daemon: %w", err)
+	}
+
+	if err := initProfileRegistry(cfg.VeePath); err != nil {
+		return fmt.Errorf("failed to init profile registry: %w", err)
+	}
+
+	if cmd.Profile == "" {
+		return showForkMenu(veeBinary, cmd.Port, parent)
+	}
+
+	profile, ok := profileRegistry[cmd.Profile]
+	if !ok {
+		return fmt.Errorf("unknown profile: %s", cmd.Profile)
+	}
+
+	sessionID := newUUID()
+
+	var feedbackSeed string
+	if cfg.Seeded {
+		feedbackSeed = sessionID
+	}
+	feedbackBlock, feedbackIDs := fetchFeedbackBlock(cmd.Port, profile, "", feedbackSeed, cfg)
+	systemPrompt := composeSystemPrompt(profile.Prompt, cfg.IdentityRule, cfg.PlatformsRule, feedbackBlock, cfg.ProjectConfig, false, "")
+
+	sessionArgs := buildForkArgs(parent.ID, sessionID, profile, cfg.ProjectConfig, cfg.IdentityRule, cfg.PlatformsRule, feedbackBlock, cfg.Port, cfg.VeePath, cfg.Passthrough, veeBinary)
+	shellCmd := buildWindowShellCmd(veeBinary, cfg.Port, sessionID, sessionArgs, "")
+	windowName := fmt.Sprintf("%s %s", profile.Indicator, profile.Name)
+
+	windowID, err := tmuxNewWindow(windowName, shellCmd)
+	if err != nil {
+		return fmt.Errorf("failed to create tmux window: %w", err)
+	}
+
+	if err := registerSession(cfg.Port, sessionID, profile, windowID, false, "", "", systemPrompt, feedbackIDs, "", "", "", parent.ID); err != nil {
+		slog.Warn("failed to register session with daemon", "error", err)
+	}
+
+	return nil
+}
+
+// showForkMenu shows a tmux display-menu of the profiles a session can be
+// forked into.
+func showForkMenu(veeBinary string, port int, parent *Session) error {
+	args := []string{"display-menu", "-T", fmt.Sprintf("Fork %s %s", parent.Indicator, parent.Profile)}
+
+	for _, name := range profileOrder {
+		profile := profileRegistry[name]
+		label := fmt.Sprintf("%s %s", profile.Indicator, profile.Name)
+
+		forkCmd := fmt.Sprintf("%s _fork-session --port %d --session-id %s --profile %s --tmux-socket %s",
+			shelljoin(veeBinary), port, parent.ID, shelljoin(profile.Name), tmuxSocketName)
+
+		args = append(args, label, "", "run-shell "+shelljoin(forkCmd))
+	}
+
+	_, err := tmuxRun(args...)
+	return err
+}
+
+// buildForkArgs builds the claude arguments of a session forked from
+// parentID: the conversation is resumed under the new session ID, with the
+// system prompt of the target profile.
+func buildForkArgs(parentID, sessionID string, profile Profile, projectConfig, identityRule, platformsRule, feedbackBlock string, port int, veePath string, passthrough []string, veeBinary string) []string {
+	args := buildSessionArgs(sessionID, false, profile, projectConfig, identityRule, platformsRule, feedbackBlock, port, veePath, passthrough, veeBinary)
+	return append(args, "--resume", parentID, "--fork-session")
+}
+
+// fetchSessionByWindow retrieves the session running in a tmux window from
+// the daemon.
+func fetchSessionByWindow(port int, windowID string) (*Session, error) {
+	return daemonAPI(port).SessionByWindow(windowID)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBuildForkArgs(t *testing.T) {
+	sessionID := "test-fork-456"
+	defer os.RemoveAll(sessionTempDir(sessionID))
+
+	profile := Profile{Name: "vibe", Indicator: "⚡", Prompt: "vibe prompt"}
+	args := buildForkArgs("parent-123", sessionID, profile, "", "", "", "", 2700, "/opt/vee", []string{"--append-system-prompt", "user rule"}, "/usr/bin/vee")
+
+	resume := slices.Index(args, "--resume")
+	if resume < 0 || args[resume+1] != "parent-123" {
+		t.Fatalf("expected --resume parent-123, got %v", args)
+	}
+	if !slices.Contains(args, "--fork-session") {
+		t.Errorf("expected --fork-session, got %v", args)
+	}
+	if i := slices.Index(args, "--session-id"); i < 0 || args[i+1] != sessionID {
+		t.Errorf("expected --session-id %s, got %v", sessionID, args)
+	}
+
+	// The fork keeps the target profile's system prompt, unlike a resume
+	i := slices.Index(args, "--append-system-prompt")
+	if i < 0 {
+		t.Fatalf("expected --append-system-prompt, got %v", args)
+	}
+	if prompt := args[i+1]; !strings.Contains(prompt, "vibe prompt") || !strings.Contains(prompt, "user rule") {
+		t.Errorf("expected the profile prompt and the user's, got %q", prompt)
+	}
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lthms/vee/internal/history"
+	"golang.org/x/term"
+)
+
+// Labels set on the container of an ephemeral session, so that it can be
+// found again once the instance that started it is gone.
+const (
+	sessionLabel = "vee.session"
+	projectLabel = "vee.project"
+	profileLabel = "vee.profile"
+)
+
+// Labels Docker Compose sets on the containers of a stack.
+const (
+	composeProjectLabel    = "com.docker.compose.project"
+	composeWorkingDirLabel = "com.docker.compose.project.working_dir"
+	composeFilesLabel      = "com.docker.compose.project.config_files"
+)
+
+// leftoverMinAge is how old a container must be before it is taken for a
+// leftover: _new-pane starts the container of an ephemeral session before
+// registering the session with the daemon.
+const leftoverMinAge = 2 * time.Minute
+
+// dockerTimeLayout is the format of the creation time printed by docker ps.
+const dockerTimeLayout = "2006-01-02 15:04:05 -0700 MST"
+
+// leftover is what an ephemeral session has in Docker: its container, its
+// Compose stack, or both.
+type leftover struct {
+	SessionID      string
+	Project        string // project directory
+	Profile        string
+	Container      string // container name, "" when only the stack is left
+	Running        bool   // the container is running
+	ComposeProject string
+	ComposeFile    string
+	Created        time.Time        // creation of its newest container
+	Archived       *history.Session // the session in the history archive, if any
+}
+
+// reattachable reports whether the session still runs in its container,
+// and was not archived as completed.
+func (l *leftover) reattachable() bool {
+	return l.Container != "" && l.Running && (l.Archived == nil || l.Archived.Status != "completed")
+}
+
+// describe tells which session the leftover belongs to and what it is made of.
+func (l *leftover) describe() string {
+	var parts []string
+	if l.Container != "" {
+		state := "stopped"
+		if l.Running {
+			state = "running"
+		}
+		parts = append(parts, fmt.Sprintf("container %s (%s)", l.Container, state))
+	}
+	if l.ComposeProject != "" {
+		parts = append(parts, "compose stack "+l.ComposeProject)
+	}
+
+	session := "session " + shortID(l.SessionID)
+	if l.Profile != "" {
+		session = l.Profile + " " + session
+	}
+	if l.Archived != nil {
+		session += fmt.Sprintf(" (%s: %s)", l.Archived.Status, firstLine(l.Archived.Preview))
+	}
+	return fmt.Sprintf("%s in %s: %s", session, shortenHome(l.Project), strings.Join(parts, " and "))
+}
+
+// tearDown removes the container, the Compose stack and the temp dir of the
+// session. Every step runs, even when an earlier one fails.
+func (l *leftover) tearDown() error {
+	var errs []error
+	if l.Container != "" {
+		if out, err := exec.Command("docker", "rm", "-f", l.Container).CombinedOutput(); err != nil {
+			errs = append(errs, fmt.Errorf("remove container %s: %w: %s", l.Container, err, strings.TrimSpace(string(out))))
+		}
+	}
+	if l.ComposeProject != "" {
+		if out, err := exec.Command("docker", "compose", "-p", l.ComposeProject, "down").CombinedOutput(); err != nil {
+			errs = append(errs, fmt.Errorf("compose down %s: %w: %s", l.ComposeProject, err, strings.TrimSpace(string(out))))
+		}
+	}
+	if err := os.RemoveAll(sessionTempDir(l.SessionID)); err != nil {
+		errs = append(errs, fmt.Errorf("remove temp dir: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// dockerPS lists every container matching filter, one line each, printed
+// with format. Tests replace it.
+var dockerPS = func(filter, format string) (string, error) {
+	out, err := exec.Command("docker", "ps", "-a", "--filter", filter, "--format", format).Output()
+	return string(out), err
+}
+
+// findLeftovers lists the containers and Compose stacks of ephemeral sessions.
+func findLeftovers() ([]*leftover, error) {
+	containers, err := dockerPS("label="+sessionLabel,
+		fmt.Sprintf(`{{.Label %q}}\t{{.Label %q}}\t{{.Label %q}}\t{{.Names}}\t{{.State}}\t{{.CreatedAt}}`, sessionLabel, projectLabel, profileLabel))
+	if err != nil {
+		return nil, fmt.Errorf("list containers: %w", err)
+	}
+	stacks, err := dockerPS("label="+composeProjectLabel,
+		fmt.Sprintf(`{{.Label %q}}\t{{.Label %q}}\t{{.Label %q}}\t{{.CreatedAt}}`, composeProjectLabel, composeWorkingDirLabel, composeFilesLabel))
+	if err != nil {
+		return nil, fmt.Errorf("list compose stacks: %w", err)
+	}
+	return parseLeftovers(containers, stacks), nil
+}
+
+// parseLeftovers groups the output of findLeftovers' docker ps calls by
+// session. Only the stacks named after a session, whose Compose file lives
+// in a .vee directory, belong to vee.
+func parseLeftovers(containers, stacks string) []*leftover {
+	var list []*leftover
+	byID := make(map[string]*leftover)
+	get := func(id string) *leftover {
+		l, ok := byID[id]
+		if !ok {
+			l = &leftover{SessionID: id}
+			byID[id] = l
+			list = append(list, l)
+		}
+		return l
+	}
+	created := func(l *leftover, value string) {
+		if t, err := time.Parse(dockerTimeLayout, value); err == nil && t.After(l.Created) {
+			l.Created = t
+		}
+	}
+
+	for _, line := range strings.Split(containers, "\n") {
+		f := strings.Split(line, "\t")
+		if len(f) != 6 || f[0] == "" {
+			continue
+		}
+		l := get(f[0])
+		l.Project, l.Profile, l.Container, l.Running = f[1], f[2], f[3], f[4] == "running"
+		created(l, f[5])
+	}
+
+	for _, line := range strings.Split(stacks, "\n") {
+		f := strings.Split(line, "\t")
+		if len(f) != 4 {
+			continue
+		}
+		id, ok := strings.CutPrefix(f[0], "vee-")
+		if !ok || id == "" {
+			continue
+		}
+		dir, ok := composeProjectDir(f[1])
+		if !ok {
+			continue
+		}
+		l := get(id)
+		l.ComposeProject = f[0]
+		l.ComposeFile, _, _ = strings.Cut(f[2], ",")
+		created(l, f[3])
+		if l.Project == "" {
+			l.Project = dir
+		}
+	}
+	return list
+}
+
+// composeProjectDir returns the project a Compose stack was started from,
+// the parent of the .vee directory holding its Compose file.
+func composeProjectDir(workingDir string) (string, bool) {
+	for dir := filepath.Clean(workingDir); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if filepath.Base(dir) == ".vee" {
+			return filepath.Dir(dir), true
+		}
+	}
+	return "", false
+}
+
+// orphans returns the leftovers of the sessions not in live, leaving out
+// those created less than leftoverMinAge before now.
+func orphans(all []*leftover, live map[string]bool, now time.Time) []*leftover {
+	var list []*leftover
+	for _, l := range all {
+		if !live[l.SessionID] && now.Sub(l.Created) >= leftoverMinAge {
+			list = append(list, l)
+		}
+	}
+	return list
+}
+
+// liveSessions returns the IDs of the active sessions of the instances still
+// running, found from the tmux sockets of the runtime directory. It fails
+// when the daemon of a running instance cannot list its sessions, since
+// the sessions it serves would then pass for leftovers.
+func liveSessions() (map[string]bool, error) {
+	live := make(map[string]bool)
+	entries, _ := os.ReadDir(veeRuntimeDir())
+	for _, e := range entries {
+		if e.Type()&fs.ModeSocket == 0 || !strings.HasPrefix(e.Name(), "vee-") {
+			continue
+		}
+		port, err := discoverDaemonPortOn(e.Name())
+		if err != nil || !daemonAlive(port) {
+			continue
+		}
+		sessions, err := listSessions(port)
+		if err != nil {
+			return live, fmt.Errorf("list sessions of %s: %w", e.Name(), err)
+		}
+		for _, sess := range sessions {
+			if sess.Status == "active" {
+				live[sess.ID] = true
+			}
+		}
+	}
+	return live, nil
+}
+
+// matchArchive looks up the sessions of the leftovers in the history archive.
+func matchArchive(list []*leftover) {
+	hstore, err := openHistoryStore()
+	if err != nil {
+		return
+	}
+	defer hstore.Close()
+	for _, l := range list {
+		if sess, err := hstore.Get(l.SessionID); err == nil {
+			l.Archived = sess
+		}
+	}
+}
+
+// recoverLeftovers asks, for each orphaned container or Compose stack of the
+// instance named socket, whether to reattach to it, tear it down or leave it
+// alone, and returns those to reattach to. Without a terminal to ask on, it
+// only points at vee gc.
+func recoverLeftovers(socket string, live map[string]bool) []*leftover {
+	all, err := findLeftovers()
+	if err != nil {
+		return nil // no Docker, nothing to recover
+	}
+	var mine []*leftover
+	for _, l := range orphans(all, live, time.Now()) {
+		if instanceSocketFor(l.Project) == socket {
+			mine = append(mine, l)
+		}
+	}
+	if len(mine) == 0 {
+		return nil
+	}
+	matchArchive(mine)
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprintf(os.Stderr, "vee: %d ephemeral session(s) of a previous run are still in Docker, run vee gc to remove them\n", len(mine))
+		return nil
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	var reattach []*leftover
+	for _, l := range mine {
+		fmt.Printf("Left by a previous run: %s\n", l.describe())
+		if l.reattachable() {
+			fmt.Print("[r]eattach, [t]ear down or [l]eave it? ")
+		} else {
+			fmt.Print("[t]ear down or [l]eave it? ")
+		}
+		answer, _ := in.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "r":
+			if l.reattachable() {
+				reattach = append(reattach, l)
+			}
+		case "t":
+			if err := l.tearDown(); err != nil {
+				fmt.Fprintf(os.Stderr, "vee: %v\n", err)
+			}
+		}
+	}
+	return reattach
+}
+
+// reattachLeftover opens a window attached to the container of an orphaned
+// ephemeral session, and registers the session with the daemon on port so
+// that it is cleaned up when it ends.
+func reattachLeftover(port int, veeBinary string, l *leftover) error {
+	profile, ok := profileRegistry[l.Profile]
+	if !ok {
+		profile = Profile{Name: l.Profile}
+	}
+
+	shellCmd := fmt.Sprintf("printf '\\033[?25h'; docker attach %s; %s _session-ended --port %d --tmux-socket %s --session-id %s --wait-for-user",
+		shelljoin(l.Container), shelljoin(veeBinary), port, tmuxSocketName, l.SessionID)
+	windowID, err := tmuxNewWindow(fmt.Sprintf("%s %s", profile.Indicator, profile.Name), shellCmd)
+	if err != nil {
+		return fmt.Errorf("create tmux window: %w", err)
+	}
+	tmuxSetWindowOption(windowID, "vee-ephemeral", "1")
+
+	if err := registerSession(port, l.SessionID, profile, windowID, true, l.ComposeFile, l.ComposeProject, "", nil, "", "", "", ""); err != nil {
+		return fmt.Errorf("register session: %w", err)
+	}
+	return nil
+}
+
+// GcCmd removes the containers, Compose stacks and temp dirs of the
+// ephemeral sessions no running instance knows about, as left behind when
+// an instance crashes.
+type GcCmd struct {
+	DryRun bool `short:"n" name:"dry-run" help:"Only list what would be removed."`
+}
+
+// Run tears down the orphaned containers and stacks of every project.
+func (cmd *GcCmd) Run() error {
+	all, err := findLeftovers()
+	if err != nil {
+		return err
+	}
+	live, err := liveSessions()
+	if err != nil {
+		return err
+	}
+	list := orphans(all, live, time.Now())
+	matchArchive(list)
+
+	verb := "removed"
+	if cmd.DryRun {
+		verb = "would remove"
+	}
+	var failed bool
+	for _, l := range list {
+		if !cmd.DryRun {
+			if err := l.tearDown(); err != nil {
+				fmt.Fprintf(os.Stderr, "vee: %v\n", err)
+				failed = true
+				continue
+			}
+		}
+		fmt.Printf("%s %s\n", verb, l.describe())
+	}
+
+	for _, path := range staleTempDirs(live) {
+		if !cmd.DryRun {
+			if err := os.RemoveAll(path); err != nil {
+				fmt.Fprintf(os.Stderr, "vee: %v\n", err)
+				failed = true
+				continue
+			}
+		}
+		fmt.Printf("%s %s\n", verb, path)
+	}
+
+	if failed {
+		return fmt.Errorf("some leftovers could not be removed")
+	}
+	return nil
+}
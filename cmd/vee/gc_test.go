@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseLeftovers(t *testing.T) {
+	containers := "s1\t/home/me/proj\tnormal\tvee-s1\trunning\t2025-03-01 10:00:00 +0000 UTC\n" +
+		"s2\t/home/me/other\tvibe\tvee-s2\texited\t2025-03-01 09:00:00 +0000 UTC\n"
+	stacks := "vee-s1\t/home/me/proj/.vee\t/home/me/proj/.vee/compose.yml\t2025-03-01 10:00:05 +0000 UTC\n" +
+		"vee-s1\t/home/me/proj/.vee\t/home/me/proj/.vee/compose.yml\t2025-03-01 09:59:55 +0000 UTC\n" +
+		"vee-s3\t/home/me/third/.vee/services\t/home/me/third/.vee/services/compose.yml,/home/me/third/.vee/services/extra.yml\tnot a date\n" +
+		"vee-web\t/home/me/site\t/home/me/site/compose.yml\t2025-03-01 10:00:00 +0000 UTC\n" +
+		"shop\t/home/me/shop/.vee\t/home/me/shop/.vee/compose.yml\t2025-03-01 10:00:00 +0000 UTC\n"
+
+	got := parseLeftovers(containers, stacks)
+	want := []leftover{
+		{SessionID: "s1", Project: "/home/me/proj", Profile: "normal", Container: "vee-s1", Running: true, ComposeProject: "vee-s1", ComposeFile: "/home/me/proj/.vee/compose.yml",
+			Created: time.Date(2025, 3, 1, 10, 0, 5, 0, time.UTC)},
+		{SessionID: "s2", Project: "/home/me/other", Profile: "vibe", Container: "vee-s2", Created: time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)},
+		{SessionID: "s3", Project: "/home/me/third", ComposeProject: "vee-s3", ComposeFile: "/home/me/third/.vee/services/compose.yml"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d leftovers, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if *got[i] != want[i] {
+			t.Errorf("leftover %d = %+v, want %+v", i, *got[i], want[i])
+		}
+	}
+}
+
+func TestOrphans(t *testing.T) {
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	all := []*leftover{
+		{SessionID: "s1", Created: now.Add(-time.Hour)},
+		{SessionID: "s2", Created: now.Add(-time.Hour)},
+		{SessionID: "s3"},
+		{SessionID: "s4", Created: now.Add(-10 * time.Second)}, // not registered yet
+	}
+	var ids []string
+	for _, l := range orphans(all, map[string]bool{"s2": true}, now) {
+		ids = append(ids, l.SessionID)
+	}
+	if !slices.Equal(ids, []string{"s1", "s3"}) {
+		t.Errorf("orphans = %v, want [s1 s3]", ids)
+	}
+}
+
+func TestStaleTempDirs(t *testing.T) {
+	rt := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", rt)
+	for _, name := range []string{"vee/session-s1", "vee/session-s2", "vee/other"} {
+		if err := os.MkdirAll(filepath.Join(rt, name), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := staleTempDirs(map[string]bool{"s2": true})
+	want := []string{filepath.Join(veeRuntimeDir(), "session-s1")}
+	if !slices.Equal(got, want) {
+		t.Errorf("staleTempDirs = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/lthms/vee/internal/history"
+)
+
+// HistoryCmd lists and searches the archived sessions, or prints one of
+// them as a readable conversation.
+type HistoryCmd struct {
+	Search  string `short:"s" help:"Only list sessions whose transcript matches these words."`
+	Profile string `help:"Only list sessions of this profile."`
+	All     bool   `short:"a" help:"List sessions of every project, not only the current one."`
+	Limit   int    `short:"n" default:"50" help:"Maximum number of sessions to list (0 for no limit)."`
+	Show    string `arg:"" optional:"" help:"Session ID (or unique prefix) to print."`
+}
+
+// Run lists the matching sessions, or prints the requested one.
+func (cmd *HistoryCmd) Run() error {
+	hstore, err := openHistoryStore()
+	if err != nil {
+		return err
+	}
+	defer hstore.Close()
+
+	if cmd.Show != "" {
+		return cmd.show(hstore)
+	}
+
+	filter := history.ListFilter{Profile: cmd.Profile, Query: cmd.Search, Limit: cmd.Limit}
+	if !cmd.All {
+		if filter.Project, err = filepath.Abs("."); err != nil {
+			return fmt.Errorf("resolve project directory: %w", err)
+		}
+	}
+
+	sessions, err := hstore.List(filter)
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		fmt.Println("No archived sessions.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, s := range sessions {
+		fmt.Fprintf(tw, "%s\t%s\t%s %s\t%s\n", shortID(s.ID), historyDate(s.EndedAt), s.Indicator, s.Profile, firstLine(s.Preview))
+		if s.Snippet != "" {
+			fmt.Fprintf(tw, "\t\t\t  %s\n", strings.Join(strings.Fields(s.Snippet), " "))
+		}
+	}
+	return tw.Flush()
+}
+
+func (cmd *HistoryCmd) show(hstore *history.Store) error {
+	id, err := hstore.Resolve(cmd.Show)
+	if err != nil {
+		return err
+	}
+	sess, err := hstore.Get(id)
+	if err != nil {
+		return err
+	}
+	messages, err := hstore.Messages(id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %s — %s (%s)\n\n", sess.Indicator, sess.Profile, historyDate(sess.EndedAt), sess.ID)
+	if len(messages) == 0 {
+		fmt.Println("No transcript was archived for this session.")
+		return nil
+	}
+	fmt.Println(renderConversation(messages, 100))
+	return nil
+}
+
+// Styles
+var (
+	hvUserStyle      = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#89b4fa"))
+	hvAssistantStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#a6e3a1"))
+	hvToolStyle      = lipgloss.NewStyle().Faint(true)
+)
+
+// renderConversation lays out the messages of a session, wrapped to width.
+// Tool calls and results are folded into single faint lines.
+func renderConversation(messages []history.Message, width int) string {
+	wrap := lipgloss.NewStyle().Width(max(width-2, 20))
+	var b strings.Builder
+	for i, m := range messages {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		switch m.Role {
+		case "tool":
+			line := strings.Join(strings.Fields(m.Text), " ")
+			if r := []rune(line); len(r) > width-4 {
+				line = string(r[:max(width-5, 1)]) + "…"
+			}
+			b.WriteString(hvToolStyle.Render("  ⚙ " + line))
+			continue
+		case "user":
+			b.WriteString(hvUserStyle.Render("▶ You"))
+		default:
+			b.WriteString(hvAssistantStyle.Render("◀ Claude"))
+		}
+		b.WriteString("\n")
+		b.WriteString(wrap.Render(m.Text))
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// historyDate formats an archive timestamp for listings.
+func historyDate(ts string) string {
+	if len(ts) >= 16 {
+		return strings.Replace(ts[:16], "T", " ", 1)
+	}
+	return ts
+}
+
+// shortID abbreviates a session ID for listings.
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lthms/vee/internal/history"
+)
+
+// HistoryViewerCmd is the internal subcommand that browses archived
+// sessions inside a tmux display-popup.
+type HistoryViewerCmd struct {
+	Project string `required:"" name:"project" help:"Project directory whose sessions are listed by default."`
+}
+
+// historyLoadedMsg carries the result of listing or searching sessions.
+type historyLoadedMsg struct {
+	sessions []history.Session
+	err      error
+}
+
+// conversationMsg carries the conversation of the session being opened.
+type conversationMsg struct {
+	session  history.Session
+	messages []history.Message
+	err      error
+}
+
+// historyViewerModel is the Bubble Tea model for the history popup. It
+// lists sessions, and opens one as a scrollable conversation.
+type historyViewerModel struct {
+	store   *history.Store
+	project string
+
+	sessions []history.Session
+	cursor   int
+	offset   int // first visible row of the list
+	all      bool
+	query    string
+	status   string
+
+	searching bool
+	input     string
+
+	// Conversation view
+	viewing  bool
+	open     history.Session
+	viewport viewport.Model
+
+	width  int
+	height int
+}
+
+func (m historyViewerModel) Init() tea.Cmd {
+	return m.load()
+}
+
+// load lists the sessions matching the current filters.
+func (m historyViewerModel) load() tea.Cmd {
+	store, query := m.store, m.query
+	filter := history.ListFilter{Query: query, Limit: 500}
+	if !m.all {
+		filter.Project = m.project
+	}
+	return func() tea.Msg {
+		sessions, err := store.List(filter)
+		return historyLoadedMsg{sessions: sessions, err: err}
+	}
+}
+
+// openSession loads the conversation of a session.
+func (m historyViewerModel) openSession(sess history.Session) tea.Cmd {
+	store := m.store
+	return func() tea.Msg {
+		messages, err := store.Messages(sess.ID)
+		return conversationMsg{session: sess, messages: messages, err: err}
+	}
+}
+
+func (m historyViewerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.viewport.Width = m.width
+		m.viewport.Height = max(m.height-3, 1)
+		m.scroll()
+		return m, nil
+
+	case historyLoadedMsg:
+		if msg.err != nil {
+			m.status = "failed to load history: " + msg.err.Error()
+			return m, nil
+		}
+		m.sessions = msg.sessions
+		m.cursor, m.offset = 0, 0
+		m.status = ""
+		return m, nil
+
+	case conversationMsg:
+		if msg.err != nil {
+			m.status = "failed to open session: " + msg.err.Error()
+			return m, nil
+		}
+		content := "No transcript was archived for this session."
+		if len(msg.messages) > 0 {
+			content = renderConversation(msg.messages, m.width)
+		}
+		m.open = msg.session
+		m.viewing = true
+		m.viewport = viewport.New(m.width, max(m.height-3, 1))
+		m.viewport.SetContent(content)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.searching {
+			return m.handleSearchInput(msg)
+		}
+		if m.viewing {
+			return m.handleViewInput(msg)
+		}
+		return m.handleListInput(msg)
+	}
+	return m, nil
+}
+
+func (m historyViewerModel) handleSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.searching = false
+	case tea.KeyEnter:
+		m.searching = false
+		m.query = strings.TrimSpace(m.input)
+		return m, m.load()
+	case tea.KeyBackspace:
+		if r := []rune(m.input); len(r) > 0 {
+			m.input = string(r[:len(r)-1])
+		}
+	case tea.KeyCtrlU:
+		m.input = ""
+	case tea.KeyRunes, tea.KeySpace:
+		m.input += string(msg.Runes)
+	}
+	return m, nil
+}
+
+func (m historyViewerModel) handleListInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		if m.query != "" {
+			m.query, m.input = "", ""
+			return m, m.load()
+		}
+		return m, tea.Quit
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+			m.scroll()
+		}
+	case "down", "j":
+		if m.cursor < len(m.sessions)-1 {
+			m.cursor++
+			m.scroll()
+		}
+	case "/":
+		m.searching = true
+		m.input = m.query
+	case "a":
+		m.all = !m.all
+		return m, m.load()
+	case "enter":
+		if len(m.sessions) > 0 {
+			return m, m.openSession(m.sessions[m.cursor])
+		}
+	}
+	return m, nil
+}
+
+// visibleRows returns how many sessions fit in the list. Each takes two
+// lines: summary and preview (or match excerpt).
+func (m historyViewerModel) visibleRows() int {
+	return max((m.height-3)/2, 1)
+}
+
+// scroll keeps the cursor within the visible part of the list.
+func (m *historyViewerModel) scroll() {
+	rows := m.visibleRows()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+rows {
+		m.offset = m.cursor - rows + 1
+	}
+}
+
+func (m historyViewerModel) handleViewInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "q", "esc":
+		m.viewing = false
+		return m, nil
+	case "g":
+		m.viewport.GotoTop()
+		return m, nil
+	case "G":
+		m.viewport.GotoBottom()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m historyViewerModel) View() string {
+	if m.width == 0 {
+		return ""
+	}
+	if m.viewing {
+		return m.viewConversation()
+	}
+	return m.viewList()
+}
+
+func (m historyViewerModel) viewList() string {
+	var b strings.Builder
+
+	title := " Session history"
+	if m.all {
+		title += pvHelpStyle.Render(" · all projects")
+	}
+	if m.query != "" {
+		title += pvSearchStyle.Render(fmt.Sprintf(" [%s] ", m.query)) + pvHelpStyle.Render(fmt.Sprintf("%d matches", len(m.sessions)))
+	}
+	b.WriteString(pvTitleStyle.Render(title))
+	b.WriteString("\n")
+	b.WriteString(pvHelpStyle.Render(strings.Repeat("─", m.width)))
+	b.WriteString("\n")
+
+	rows := m.visibleRows()
+	lines := 0
+	if len(m.sessions) == 0 {
+		b.WriteString(pvHelpStyle.Render("  No archived sessions."))
+		b.WriteString("\n")
+		lines++
+	}
+	for i := m.offset; i < len(m.sessions) && i < m.offset+rows; i++ {
+		s := m.sessions[i]
+		marker := "  "
+		if i == m.cursor {
+			marker = pvSearchStyle.Render("▸ ")
+		}
+		summary := fmt.Sprintf("%s  %s %s  %s", historyDate(s.EndedAt), s.Indicator, s.Profile, pvHelpStyle.Render(shortID(s.ID)))
+		detail := firstLine(s.Preview)
+		if s.Snippet != "" {
+			detail = strings.Join(strings.Fields(s.Snippet), " ")
+		}
+		b.WriteString(marker + summary + "\n")
+		b.WriteString("    " + pvHelpStyle.Render(truncateRunes(detail, m.width-6)) + "\n")
+		lines += 2
+	}
+	b.WriteString(strings.Repeat("\n", max(m.height-3-lines, 0)))
+
+	if m.searching {
+		b.WriteString(" " + pvSearchStyle.Render("/") + m.input + pvHelpStyle.Render("▏"))
+	} else if m.status != "" {
+		b.WriteString(" " + pvErrorStyle.Render(m.status))
+	} else {
+		help := "↑/↓ move  Enter open  / search  a all projects  q quit"
+		if m.query != "" {
+			help = "Esc clear  " + help
+		}
+		b.WriteString(" " + pvHelpStyle.Render(help))
+	}
+	return b.String()
+}
+
+func (m historyViewerModel) viewConversation() string {
+	var b strings.Builder
+	title := fmt.Sprintf(" %s %s — %s", m.open.Indicator, m.open.Profile, historyDate(m.open.EndedAt))
+	b.WriteString(pvTitleStyle.Render(title))
+	b.WriteString("\n")
+	b.WriteString(pvHelpStyle.Render(strings.Repeat("─", m.width)))
+	b.WriteString("\n")
+	b.WriteString(m.viewport.View())
+	b.WriteString("\n")
+
+	position := ""
+	if m.viewport.TotalLineCount() > m.viewport.Height {
+		position = fmt.Sprintf(" %.0f%%", m.viewport.ScrollPercent()*100)
+	}
+	b.WriteString(" " + pvHelpStyle.Render("↑/↓ scroll  g/G top/bottom  q back") + pvHelpStyle.Render(position))
+	return b.String()
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut.
+func truncateRunes(s string, n int) string {
+	if n < 1 {
+		return ""
+	}
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+func (cmd *HistoryViewerCmd) Run() error {
+	hstore, err := openHistoryStore()
+	if err != nil {
+		return err
+	}
+	defer hstore.Close()
+
+	m := historyViewerModel{store: hstore, project: cmd.Project}
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	_, err = p.Run()
+	return err
+}
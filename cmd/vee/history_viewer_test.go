@@ -0,0 +1,64 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lthms/vee/internal/history"
+)
+
+func TestRenderConversation(t *testing.T) {
+	out := renderConversation([]history.Message{
+		{Role: "user", Text: "Fix the flaky test"},
+		{Role: "tool", Text: "Read {\"file_path\":\n\"worker.go\"}"},
+		{Role: "assistant", Text: "Done."},
+	}, 80)
+
+	for _, want := range []string{"You", "Fix the flaky test", `⚙ Read {"file_path": "worker.go"}`, "Claude", "Done."} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestHistoryViewerModelOpensSession(t *testing.T) {
+	dir := t.TempDir()
+	store, err := history.Open(filepath.Join(dir, "history.db"), filepath.Join(dir, "transcripts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	transcript := `{"type":"user","message":{"role":"user","content":"Explain the worker"}}` + "\n"
+	path, err := store.SaveTranscript("s1", strings.NewReader(transcript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Archive(history.Session{ID: "s1", Profile: "vibe", Project: "/p", Status: "completed", EndedAt: "2025-03-01T10:00:00Z"}, path); err != nil {
+		t.Fatal(err)
+	}
+
+	var m tea.Model = historyViewerModel{store: store, project: "/p"}
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	m, _ = m.Update(m.Init()())
+
+	if got := m.View(); !strings.Contains(got, "vibe") {
+		t.Fatalf("expected the session in the list:\n%s", got)
+	}
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("expected Enter to open the session")
+	}
+	m, _ = m.Update(cmd())
+	if got := m.View(); !strings.Contains(got, "Explain the worker") {
+		t.Fatalf("expected the conversation:\n%s", got)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
+	if m.(historyViewerModel).viewing {
+		t.Fatal("expected q to return to the list")
+	}
+}
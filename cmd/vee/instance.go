@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lthms/vee/internal/feedback"
+	"github.com/lthms/vee/internal/history"
+	"github.com/lthms/vee/internal/kb"
+)
+
+// startInstance serves the project described by cfg on a port of its own:
+// its sessions, jobs, schedules and time limits, with the given knowledge
+// base and stores. Its profiles are loaded from cfg.VeePath, so that every
+// instance sees the current profile files. It sets cfg.Port; the returned
+// function stops serving.
+func startInstance(ctx context.Context, app *App, cfg *AppConfig, userCfg *UserConfig, kbase *kb.KnowledgeBase, fstore *feedback.Store, hstore *history.Store) (func(), error) {
+	profiles, _, err := loadProfiles(cfg.VeePath)
+	if err != nil {
+		return nil, fmt.Errorf("load profiles: %w", err)
+	}
+	app.SetProfiles(profiles)
+	app.Jobs.setLimit(userCfg.Jobs.Concurrency)
+	app.Notifier.configure(userCfg.Notify)
+
+	stopServer, port, err := startHTTPServerInBackground(app, kbase, fstore, hstore)
+	if err != nil {
+		return nil, fmt.Errorf("start HTTP server: %w", err)
+	}
+	cfg.Port = port
+	app.SetConfig(cfg)
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	// Run background jobs until the instance stops
+	app.Jobs.start(ctx)
+
+	// Start the sessions of [schedule "name"] sections when they are due
+	startScheduler(ctx, app)
+
+	// Suspend idle sessions and end ephemeral ones past their time limit
+	startSessionLimiter(ctx, app, hstore, userCfg.Sessions)
+
+	return func() {
+		cancel()
+		stopServer()
+	}, nil
+}
+
+// startLocalInstance serves the project described by cfg from the current
+// process, with a knowledge base worker of its own. It sets cfg.Port; the
+// returned function stops serving and closes the stores.
+func startLocalInstance(cfg *AppConfig, userCfg *UserConfig) (func(), error) {
+	app := newApp()
+
+	kbase, err := openKB(userCfg, func(e kb.Event) { publishKBEvent(app, e) })
+	if err != nil {
+		return nil, fmt.Errorf("open knowledge base: %w", err)
+	}
+	fstore, err := openFeedbackStore(userCfg)
+	if err != nil {
+		kbase.Close()
+		return nil, err
+	}
+	hstore, err := openHistoryStore()
+	if err != nil {
+		fstore.Close()
+		kbase.Close()
+		return nil, err
+	}
+
+	// Start the KB background worker for async embedding + duplicate detection
+	ctx, cancel := context.WithCancel(context.Background())
+	go kbase.RunWorker(ctx)
+
+	stop, err := startInstance(ctx, app, cfg, userCfg, kbase, fstore, hstore)
+	if err != nil {
+		cancel()
+		hstore.Close()
+		fstore.Close()
+		kbase.Close()
+		return nil, err
+	}
+	return func() {
+		stop()
+		cancel()
+		hstore.Close()
+		fstore.Close()
+		kbase.Close()
+	}, nil
+}
@@ -1,13 +1,11 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"os"
 	"strings"
 
+	"github.com/lthms/vee/internal/kb"
 	"golang.org/x/term"
 )
 
@@ -17,21 +15,6 @@ type IssueResolverCmd struct {
 	Port int `short:"p" default:"2700" name:"port"`
 }
 
-// resolverIssue mirrors kb.Issue for JSON decoding.
-type resolverIssue struct {
-	ID         string  `json:"id"`
-	Type       string  `json:"type"`
-	Status     string  `json:"status"`
-	StatementA string  `json:"statement_a"`
-	StatementB string  `json:"statement_b"`
-	Score      float64 `json:"score"`
-	CreatedAt  string  `json:"created_at"`
-	ContentA   string  `json:"content_a"`
-	ContentB   string  `json:"content_b"`
-	SourceA    string  `json:"source_a"`
-	SourceB    string  `json:"source_b"`
-}
-
 const (
 	resolverStateList   = 0
 	resolverStateDetail = 1
@@ -41,7 +24,7 @@ type resolverState struct {
 	port int
 
 	state    int // resolverStateList or resolverStateDetail
-	issues   []resolverIssue
+	issues   []kb.Issue
 	selected int
 	message  string // transient status message
 
@@ -215,18 +198,11 @@ func (rs *resolverState) scrollDetail(delta int) {
 }
 
 func (rs *resolverState) fetchIssues() {
-	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/api/kb/issues", rs.port))
+	issues, err := daemonAPI(rs.port).KBIssues()
 	if err != nil {
 		rs.issues = nil
 		return
 	}
-	defer resp.Body.Close()
-
-	var issues []resolverIssue
-	if err := json.NewDecoder(resp.Body).Decode(&issues); err != nil {
-		rs.issues = nil
-		return
-	}
 
 	rs.issues = issues
 	if rs.selected >= len(rs.issues) {
@@ -298,22 +274,10 @@ func (rs *resolverState) resolveSelected(action string) {
 
 	iss := rs.issues[rs.selected]
 
-	body, _ := json.Marshal(map[string]string{"action": action})
-	resp, err := http.Post(
-		fmt.Sprintf("http://127.0.0.1:%d/api/kb/issues/resolve?id=%s", rs.port, iss.ID),
-		"application/json",
-		bytes.NewReader(body),
-	)
-	if err != nil {
+	if err := daemonAPI(rs.port).ResolveIssue(iss.ID, action); err != nil {
 		rs.message = "Error: " + err.Error()
 		return
 	}
-	resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		rs.message = fmt.Sprintf("Error: HTTP %d", resp.StatusCode)
-		return
-	}
 
 	rs.message = fmt.Sprintf("Resolved: %s", action)
 	rs.state = resolverStateList
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lthms/vee/internal/api"
+	"github.com/lthms/vee/internal/feedback"
+)
+
+// Job is a Claude session run non-interactively in the background, with its
+// output captured to a log file.
+type Job struct {
+	api.Job
+	Args []string // claude arguments, besides the prompt
+	Dir  string   // project directory claude runs in
+}
+
+// jobQueue runs queued jobs in submission order, at most limit at a time,
+// for the lifetime of ctx. Changes are published on events, when set.
+type jobQueue struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	jobs    map[string]*Job
+	order   []string
+	limit   int
+	running int
+	run     func(context.Context, *Job) error
+	events  *eventBus
+}
+
+func newJobQueue(limit int) *jobQueue {
+	return &jobQueue{
+		ctx:   context.Background(),
+		jobs:  make(map[string]*Job),
+		limit: max(limit, 1),
+		run:   runJob,
+	}
+}
+
+// start runs the jobs for the lifetime of ctx. When it is done, running
+// jobs are killed and queued jobs are cancelled.
+func (q *jobQueue) start(ctx context.Context) {
+	q.mu.Lock()
+	q.ctx = ctx
+	q.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		q.dispatch()
+	}()
+	q.dispatch()
+}
+
+// setLimit changes how many jobs may run at once, starting queued jobs if
+// the limit was raised.
+func (q *jobQueue) setLimit(limit int) {
+	q.mu.Lock()
+	q.limit = max(limit, 1)
+	q.mu.Unlock()
+	q.dispatch()
+}
+
+// submit queues a job and starts it if a slot is free. It fails if a job
+// with the same ID is already known to the queue.
+func (q *jobQueue) submit(job *Job) error {
+	q.mu.Lock()
+	if _, ok := q.jobs[job.ID]; ok {
+		q.mu.Unlock()
+		return fmt.Errorf("job %s already exists", job.ID)
+	}
+	job.Status = "queued"
+	job.QueuedAt = time.Now()
+	q.jobs[job.ID] = job
+	q.order = append(q.order, job.ID)
+	q.publish()
+	q.mu.Unlock()
+	q.dispatch()
+	return nil
+}
+
+// dispatch starts queued jobs, oldest first, while slots are free. Once the
+// queue's context is done, queued jobs are cancelled instead.
+func (q *jobQueue) dispatch() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	changed := false
+	for _, id := range q.order {
+		job := q.jobs[id]
+		if job.Status != "queued" {
+			continue
+		}
+		if err := q.ctx.Err(); err != nil {
+			job.Status = "cancelled"
+			job.Error = err.Error()
+			job.EndedAt = time.Now()
+			changed = true
+			continue
+		}
+		if q.running >= q.limit {
+			break
+		}
+		job.Status = "running"
+		job.StartedAt = time.Now()
+		q.running++
+		changed = true
+		go q.execute(q.ctx, job)
+	}
+	if changed {
+		q.publish()
+	}
+}
+
+func (q *jobQueue) execute(ctx context.Context, job *Job) {
+	slog.Debug("job started", "id", job.ID, "profile", job.Profile)
+	err := q.run(ctx, job)
+
+	q.mu.Lock()
+	job.EndedAt = time.Now()
+	switch {
+	case ctx.Err() != nil:
+		job.Status = "cancelled"
+		job.Error = ctx.Err().Error()
+	case err != nil:
+		job.Status = "failed"
+		job.Error = err.Error()
+	default:
+		job.Status = "succeeded"
+	}
+	q.running--
+	q.publish()
+	q.mu.Unlock()
+
+	slog.Debug("job finished", "id", job.ID, "status", job.Status, "error", err)
+	q.dispatch()
+}
+
+// get returns a copy of a job, or nil if it is unknown.
+func (q *jobQueue) get(id string) *Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return nil
+	}
+	c := *job
+	return &c
+}
+
+// list returns copies of all jobs, in submission order.
+func (q *jobQueue) list() []api.Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.snapshot()
+}
+
+// publish sends the jobs as a jobs event. Callers hold q.mu.
+func (q *jobQueue) publish() {
+	q.events.publish(evJobs, map[string]any{"jobs": q.snapshot()})
+}
+
+// snapshot returns copies of all jobs. Callers hold q.mu.
+func (q *jobQueue) snapshot() []api.Job {
+	result := make([]api.Job, 0, len(q.order))
+	for _, id := range q.order {
+		result = append(result, q.jobs[id].Job)
+	}
+	return result
+}
+
+// scheduleActive reports whether a job queued by a schedule is still queued
+// or running.
+func (q *jobQueue) scheduleActive(schedule string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, job := range q.jobs {
+		if job.Schedule == schedule && (job.Status == "queued" || job.Status == "running") {
+			return true
+		}
+	}
+	return false
+}
+
+// jobLogPath returns the log file of a job for the Vee instance named
+// socket.
+func jobLogPath(socket, id string) string {
+	return filepath.Join(veeRuntimeDir(), socket+"-jobs", id+".log")
+}
+
+// runJob runs claude in print mode with the job's prompt, writing its output
+// to the job's log file. Claude is interrupted when ctx is done.
+func runJob(ctx context.Context, job *Job) error {
+	if err := os.MkdirAll(filepath.Dir(job.LogPath), 0700); err != nil {
+		return fmt.Errorf("create log dir: %w", err)
+	}
+	logFile, err := os.OpenFile(job.LogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("open job log: %w", err)
+	}
+	defer logFile.Close()
+
+	fmt.Fprintf(logFile, "%s %s — %s\n\n", job.Indicator, job.Profile, job.Prompt)
+
+	cmd := exec.CommandContext(ctx, "claude", append([]string{"-p", job.Prompt}, job.Args...)...)
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = 5 * time.Second
+	cmd.Dir = job.Dir
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(logFile, "\n[%s]\n", err)
+		return err
+	}
+	return nil
+}
+
+// handleJobs handles GET /api/jobs to list jobs and POST /api/jobs to queue
+// one. The feedback entries injected into the job are recorded in fstore.
+func handleJobs(app *App, fstore *feedback.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(app.Jobs.list())
+
+		case http.MethodPost:
+			var req api.SubmitJobRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if req.ID == "" || strings.TrimSpace(req.Prompt) == "" {
+				http.Error(w, "bad request: id and prompt are required", http.StatusBadRequest)
+				return
+			}
+
+			err := app.Jobs.submit(&Job{
+				Job: api.Job{
+					ID:          req.ID,
+					Profile:     req.Profile,
+					Indicator:   req.Indicator,
+					Prompt:      req.Prompt,
+					LogPath:     jobLogPath(app.tmuxSocket(), req.ID),
+					Schedule:    req.Schedule,
+					Permissions: req.Permissions,
+				},
+				Dir:  app.projectDir(),
+				Args: req.Args,
+			})
+			if err != nil {
+				http.Error(w, "submit failed: "+err.Error(), http.StatusConflict)
+				return
+			}
+			slog.Debug("job queued via API", "id", req.ID, "profile", req.Profile)
+
+			if fstore != nil {
+				if err := fstore.RecordUsage(req.ID, req.FeedbackIDs); err != nil {
+					slog.Warn("failed to record feedback usage", "job", req.ID, "error", err)
+				}
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(api.Status{Status: "queued"})
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// submitJob queues a background job with the running daemon.
+func submitJob(port int, jobID string, profile Profile, prompt string, args, feedbackIDs []string, schedule string) error {
+	return daemonAPI(port).SubmitJob(api.SubmitJobRequest{
+		ID:          jobID,
+		Profile:     profile.Name,
+		Indicator:   profile.Indicator,
+		Prompt:      prompt,
+		Args:        args,
+		FeedbackIDs: feedbackIDs,
+		Schedule:    schedule,
+		Permissions: profile.Permissions,
+	})
+}
+
+// JobMenuCmd is the internal subcommand that lists background jobs in a tmux
+// menu, and opens the log of the selected one in the log viewer.
+type JobMenuCmd struct {
+	Port       int    `short:"p" default:"2700" name:"port"`
+	TmuxSocket string `name:"tmux-socket" default:"vee" help:"Tmux socket name."`
+}
+
+// Run fetches the jobs from the daemon and shows a tmux picker.
+func (cmd *JobMenuCmd) Run() error {
+	tmuxSocketName = cmd.TmuxSocket
+
+	jobs, err := daemonAPI(cmd.Port).Jobs()
+	if err != nil {
+		return err
+	}
+
+	if len(jobs) == 0 {
+		tmuxRun("display-message", "No background jobs")
+		return nil
+	}
+
+	veeBinary, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"display-menu", "-T", "Background Jobs"}
+
+	// Most recent first
+	for i := len(jobs) - 1; i >= 0; i-- {
+		job := jobs[i]
+		label := fmt.Sprintf("%s %s %s", jobStatusIcon(job.Status), job.Indicator, job.Profile)
+		if job.Prompt != "" {
+			label += "  " + truncateRunes(firstLine(job.Prompt), 40)
+		}
+
+		viewerCmd := fmt.Sprintf("%s _log-viewer --tmux-socket %s --file %s",
+			shelljoin(veeBinary), tmuxSocketName, shelljoin(job.LogPath))
+
+		args = append(args, label, "", "display-popup -E -w 90% -h 80% "+shelljoin(viewerCmd))
+	}
+
+	_, err = tmuxRun(args...)
+	return err
+}
+
+// jobStatusIcon returns the symbol shown for a job status.
+func jobStatusIcon(status string) string {
+	switch status {
+	case "queued":
+		return "◷"
+	case "running":
+		return "⟳"
+	case "succeeded":
+		return "✓"
+	case "cancelled":
+		return "⊘"
+	default:
+		return "✗"
+	}
+}
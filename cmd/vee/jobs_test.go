@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lthms/vee/internal/api"
+)
+
+// waitForStatus polls the queue until a job reaches the expected status.
+func waitForStatus(t *testing.T, q *jobQueue, id, status string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if job := q.get(id); job != nil && job.Status == status {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s: expected status %s, got %+v", id, status, q.get(id))
+}
+
+func TestJobQueueRespectsConcurrency(t *testing.T) {
+	release := map[string]chan error{
+		"a": make(chan error),
+		"b": make(chan error),
+		"c": make(chan error),
+	}
+	q := newJobQueue(2)
+	q.run = func(_ context.Context, job *Job) error { return <-release[job.ID] }
+
+	for _, id := range []string{"a", "b", "c"} {
+		q.submit(&Job{Job: api.Job{ID: id, Prompt: "p"}})
+	}
+
+	waitForStatus(t, q, "a", "running")
+	waitForStatus(t, q, "b", "running")
+	if got := q.get("c").Status; got != "queued" {
+		t.Fatalf("expected c to wait for a free slot, got %s", got)
+	}
+
+	release["a"] <- nil
+	waitForStatus(t, q, "a", "succeeded")
+	waitForStatus(t, q, "c", "running")
+
+	release["b"] <- errors.New("exit status 1")
+	release["c"] <- nil
+	waitForStatus(t, q, "b", "failed")
+	waitForStatus(t, q, "c", "succeeded")
+
+	jobs := q.list()
+	if len(jobs) != 3 || jobs[0].ID != "a" || jobs[2].ID != "c" {
+		t.Fatalf("expected jobs in submission order, got %+v", jobs)
+	}
+	if jobs[1].Error != "exit status 1" || jobs[1].EndedAt.IsZero() {
+		t.Fatalf("expected b to record its failure, got %+v", jobs[1])
+	}
+}
+
+func TestJobQueueCancelsJobsWhenStopped(t *testing.T) {
+	q := newJobQueue(1)
+	q.run = func(ctx context.Context, job *Job) error {
+		<-ctx.Done()
+		return errors.New("signal: interrupt")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	q.start(ctx)
+
+	q.submit(&Job{Job: api.Job{ID: "a", Prompt: "p"}})
+	q.submit(&Job{Job: api.Job{ID: "b", Prompt: "p"}})
+	waitForStatus(t, q, "a", "running")
+
+	cancel()
+	waitForStatus(t, q, "a", "cancelled")
+	waitForStatus(t, q, "b", "cancelled")
+	if job := q.get("b"); !job.StartedAt.IsZero() || job.EndedAt.IsZero() {
+		t.Fatalf("expected b to be cancelled without running, got %+v", job)
+	}
+
+	q.submit(&Job{Job: api.Job{ID: "c", Prompt: "p"}})
+	waitForStatus(t, q, "c", "cancelled")
+}
+
+func TestSubmitJobRejectsDuplicateIDs(t *testing.T) {
+	app := newApp()
+	release := make(chan error)
+	app.Jobs.run = func(context.Context, *Job) error { return <-release }
+	defer close(release)
+
+	body := `{"id":"a","prompt":"p"}`
+	for _, want := range []int{http.StatusCreated, http.StatusConflict} {
+		rec := httptest.NewRecorder()
+		handleJobs(app, nil)(rec, httptest.NewRequest(http.MethodPost, "/api/jobs", strings.NewReader(body)))
+		if rec.Code != want {
+			t.Fatalf("POST /api/jobs = %d %s, expected %d", rec.Code, rec.Body, want)
+		}
+	}
+	if jobs := app.Jobs.list(); len(jobs) != 1 {
+		t.Fatalf("expected a single job, got %+v", jobs)
+	}
+}
@@ -1,15 +1,13 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"net/http"
-	"net/url"
 	"os"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/lthms/vee/internal/kb"
 	"golang.org/x/term"
 )
 
@@ -19,26 +17,6 @@ type KBExplorerCmd struct {
 	Port int `short:"p" default:"2700" name:"port"`
 }
 
-// explorerResult is a single search hit from the KB query API.
-type explorerResult struct {
-	ID           string  `json:"id"`
-	Content      string  `json:"content"`
-	Source       string  `json:"source"`
-	Score        float64 `json:"score"`
-	LastVerified string  `json:"last_verified"`
-}
-
-// explorerStatement is a full statement returned by the KB fetch API.
-type explorerStatement struct {
-	ID           string `json:"id"`
-	Content      string `json:"content"`
-	Source       string `json:"source"`
-	SourceType   string `json:"source_type"`
-	Status       string `json:"status"`
-	CreatedAt    string `json:"created_at"`
-	LastVerified string `json:"last_verified"`
-}
-
 const (
 	explorerStateSearch  = 0
 	explorerStateViewing = 1
@@ -55,15 +33,15 @@ type explorerState struct {
 
 	state    int // explorerStateSearch or explorerStateViewing
 	query    string
-	results  []explorerResult
+	results  []kb.QueryResult
 	selected int
 	searched bool // true after at least one search has been performed
 
 	// Note viewing state
-	noteStack  []noteView         // navigation stack
-	noteStmt   *explorerStatement // current statement being viewed
-	noteLines  []string           // rendered lines of current note
-	noteScroll int                // top line offset
+	noteStack  []noteView    // navigation stack
+	noteStmt   *kb.Statement // current statement being viewed
+	noteLines  []string      // rendered lines of current note
+	noteScroll int           // top line offset
 
 	termWidth  int
 	termHeight int
@@ -268,20 +246,12 @@ func (es *explorerState) scrollNote(delta int) {
 }
 
 func (es *explorerState) search() {
-	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/api/kb/query?q=%s", es.port, url.QueryEscape(es.query)))
+	results, err := daemonAPI(es.port).KBQuery(es.query)
 	if err != nil {
 		es.results = nil
 		es.searched = true
 		return
 	}
-	defer resp.Body.Close()
-
-	var results []explorerResult
-	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
-		es.results = nil
-		es.searched = true
-		return
-	}
 
 	es.results = results
 	es.selected = 0
@@ -289,20 +259,14 @@ func (es *explorerState) search() {
 }
 
 func (es *explorerState) openNote(id string) {
-	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/api/kb/fetch?id=%s", es.port, url.QueryEscape(id)))
+	stmt, err := daemonAPI(es.port).KBFetch(id)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
-
-	var stmt explorerStatement
-	if err := json.NewDecoder(resp.Body).Decode(&stmt); err != nil {
-		return
-	}
 
 	es.noteStack = append(es.noteStack, noteView{id: id})
-	es.noteStmt = &stmt
-	es.prepareNoteView(&stmt)
+	es.noteStmt = stmt
+	es.prepareNoteView(stmt)
 	es.state = explorerStateViewing
 }
 
@@ -314,7 +278,7 @@ func renderInlineMarkdown(line string) string {
 	return line
 }
 
-func (es *explorerState) prepareNoteView(stmt *explorerStatement) {
+func (es *explorerState) prepareNoteView(stmt *kb.Statement) {
 	contentWidth := es.termWidth - 6
 
 	var lines []string
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lthms/vee/internal/history"
+)
+
+// limitsTickInterval is how often session time limits are checked.
+const limitsTickInterval = 30 * time.Second
+
+// sessionLimiter enforces the time limits of SessionsConfig: it suspends
+// idle sessions, and ends ephemeral sessions that ran for their maximum
+// duration after warning about it.
+type sessionLimiter struct {
+	limits   SessionsConfig
+	sessions *sessionStore
+	warned   map[string]bool // ephemeral sessions already warned about
+
+	suspend func(*Session)
+	warn    func(sess *Session, left time.Duration)
+}
+
+func newSessionLimiter(limits SessionsConfig, sessions *sessionStore) *sessionLimiter {
+	return &sessionLimiter{
+		limits:   limits,
+		sessions: sessions,
+		warned:   make(map[string]bool),
+	}
+}
+
+// tick checks the active sessions against the limits. A session running a
+// prompt is never considered idle.
+func (l *sessionLimiter) tick(now time.Time) {
+	for _, sess := range l.sessions.active() {
+		l.sessions.mu.RLock()
+		ephemeral, working := sess.Ephemeral, sess.Working
+		startedAt, lastActivity := sess.StartedAt, sess.LastActivity
+		l.sessions.mu.RUnlock()
+
+		if ephemeral {
+			if l.limits.EphemeralMaxDuration <= 0 {
+				continue
+			}
+			deadline := startedAt.Add(l.limits.EphemeralMaxDuration)
+			if !now.Before(deadline) {
+				slog.Info("ephemeral session reached its maximum duration", "session", sess.ID, "max", l.limits.EphemeralMaxDuration)
+				delete(l.warned, sess.ID)
+				l.suspend(sess)
+				continue
+			}
+			if left := deadline.Sub(now); left <= l.limits.EphemeralWarning && !l.warned[sess.ID] {
+				l.warned[sess.ID] = true
+				l.warn(sess, left)
+			}
+			continue
+		}
+
+		if l.limits.IdleTimeout > 0 && !working && now.Sub(lastActivity) >= l.limits.IdleTimeout {
+			slog.Info("suspending idle session", "session", sess.ID, "idle", now.Sub(lastActivity).Round(time.Second))
+			l.suspend(sess)
+		}
+	}
+}
+
+// run ticks until ctx is cancelled.
+func (l *sessionLimiter) run(ctx context.Context) {
+	ticker := time.NewTicker(limitsTickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			l.tick(now)
+		}
+	}
+}
+
+// startSessionLimiter enforces the session time limits for the lifetime of
+// ctx, through the same path as Ctrl-b q. It does nothing when no limit is
+// configured.
+func startSessionLimiter(ctx context.Context, app *App, hstore *history.Store, limits SessionsConfig) {
+	if limits.IdleTimeout <= 0 && limits.EphemeralMaxDuration <= 0 {
+		return
+	}
+	l := newSessionLimiter(limits, app.Sessions)
+	l.suspend = func(sess *Session) {
+		suspendSession(app, hstore, sess)
+		app.Notifier.notify(sess, eventEnded)
+	}
+	l.warn = func(sess *Session, left time.Duration) {
+		msg := fmt.Sprintf("%s %s reaches its time limit %s, then its container is removed", sess.Indicator, sess.Profile, formatUntil(left))
+		tmuxRunOn(app.tmuxSocket(), "display-message", "-d", "10000", "-t", sess.WindowTarget, msg)
+	}
+	go l.run(ctx)
+}
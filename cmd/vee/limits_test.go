@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionLimiterTick(t *testing.T) {
+	start := time.Date(2025, 3, 5, 9, 0, 0, 0, time.UTC)
+	store := newSessionStore()
+	idle := store.create("idle", "normal", "🦊", "", "@1", false, "", "", "", nil, nil, ToolPermissions{})
+	busy := store.create("busy", "vibe", "⚡", "", "@2", false, "", "", "", nil, nil, ToolPermissions{})
+	eph := store.create("eph", "vibe", "⚡", "", "@3", true, "", "", "", nil, nil, ToolPermissions{})
+	for _, sess := range []*Session{idle, busy, eph} {
+		sess.StartedAt, sess.LastActivity = start, start
+	}
+	busy.Working = true
+
+	var suspended, warned []string
+	l := newSessionLimiter(SessionsConfig{
+		IdleTimeout:          time.Hour,
+		EphemeralMaxDuration: 2 * time.Hour,
+		EphemeralWarning:     10 * time.Minute,
+	}, store)
+	l.suspend = func(sess *Session) {
+		suspended = append(suspended, sess.ID)
+		store.setStatus(sess.ID, "suspended")
+	}
+	l.warn = func(sess *Session, left time.Duration) {
+		warned = append(warned, sess.ID)
+	}
+
+	l.tick(start.Add(59 * time.Minute))
+	if len(suspended) != 0 {
+		t.Fatalf("expected nothing suspended before the idle timeout, got %v", suspended)
+	}
+
+	// Only the idle session is suspended; a working one is never idle
+	l.tick(start.Add(time.Hour))
+	if len(suspended) != 1 || suspended[0] != "idle" {
+		t.Fatalf("expected the idle session suspended, got %v", suspended)
+	}
+
+	// The ephemeral session is warned once, then ended
+	l.tick(start.Add(115 * time.Minute))
+	l.tick(start.Add(118 * time.Minute))
+	if len(warned) != 1 || warned[0] != "eph" {
+		t.Fatalf("expected one warning for the ephemeral session, got %v", warned)
+	}
+	l.tick(start.Add(2 * time.Hour))
+	if len(suspended) != 2 || suspended[1] != "eph" {
+		t.Fatalf("expected the ephemeral session ended, got %v", suspended)
+	}
+}
@@ -16,6 +16,7 @@ import (
 // log viewer with syntax highlighting, rendered inside a tmux display-popup.
 type LogViewerCmd struct {
 	TmuxSocket string `name:"tmux-socket" default:"vee" help:"Tmux socket name."`
+	File       string `name:"file" help:"Log file to show instead of the instance log (e.g. a background job's)."`
 }
 
 // Styles for slog syntax highlighting (Tokyo Night palette)
@@ -56,9 +57,9 @@ type logModel struct {
 	width     int
 	height    int
 
-	file       *os.File
-	lastSize   int64
-	socketName string
+	file     *os.File
+	lastSize int64
+	path     string
 }
 
 type tickMsg time.Time
@@ -75,18 +76,18 @@ type newLinesMsg struct {
 	lastSize int64
 }
 
-func initialLogModel(socketName string) logModel {
+func initialLogModel(path string) logModel {
 	return logModel{
-		lines:      make([]string, 0, maxLogLines),
-		following:  true,
-		socketName: socketName,
-		width:      80,
-		height:     24,
+		lines:     make([]string, 0, maxLogLines),
+		following: true,
+		path:      path,
+		width:     80,
+		height:    24,
 	}
 }
 
 func (m logModel) Init() tea.Cmd {
-	return openLogFile(m.socketName)
+	return openLogFile(m.path)
 }
 
 func tickCmd() tea.Cmd {
@@ -95,11 +96,8 @@ func tickCmd() tea.Cmd {
 	})
 }
 
-func openLogFile(socketName string) tea.Cmd {
+func openLogFile(path string) tea.Cmd {
 	return func() tea.Msg {
-		tmuxSocketName = socketName
-		path := logFilePath()
-
 		f, err := os.Open(path)
 		if err != nil {
 			return fileOpenedMsg{err: err}
@@ -759,7 +757,11 @@ func truncateAnsi(line string, maxWidth int) string {
 func (cmd *LogViewerCmd) Run() error {
 	tmuxSocketName = cmd.TmuxSocket
 
-	m := initialLogModel(cmd.TmuxSocket)
+	path := cmd.File
+	if path == "" {
+		path = logFilePath()
+	}
+	m := initialLogModel(path)
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
 	_, err := p.Run()
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"context"
 	"crypto/sha256"
 	"embed"
 	"encoding/json"
@@ -10,10 +9,12 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/alecthomas/kong"
+	"github.com/lthms/vee/internal/api"
 	"github.com/lthms/vee/internal/feedback"
 )
 
@@ -26,9 +27,13 @@ type Profile struct {
 	Indicator         string
 	Description       string
 	Priority          int
-	Prompt            string // composed system prompt content
-	DefaultPrompt     string // template for the initial prompt (optional)
-	PromptPlaceholder string // hint text for the picker's prompt field (optional)
+	Prompt            string          // composed system prompt content
+	DefaultPrompt     string          // template for the initial prompt (optional)
+	PromptPlaceholder string          // hint text for the picker's prompt field (optional)
+	Groups            []string        // profile groups sharing feedback examples (optional)
+	Next              string          // profile offered once a session completes (optional)
+	Headless          bool            // run as a background job by default (optional)
+	Permissions       ToolPermissions // Vee MCP tools the profile may use (optional)
 }
 
 // logFilePath returns the log path for this Vee instance.
@@ -42,13 +47,25 @@ func instanceSocket() string {
 	if err != nil {
 		abs = "."
 	}
-	h := sha256.Sum256([]byte(abs))
+	return instanceSocketFor(abs)
+}
+
+// instanceSocketFor returns the tmux socket name of the instance of the
+// project in dir, an absolute path.
+func instanceSocketFor(dir string) string {
+	h := sha256.Sum256([]byte(dir))
 	return fmt.Sprintf("vee-%x", h[:8])
 }
 
 // discoverDaemonPort reads VEE_PORT from the tmux environment.
 func discoverDaemonPort() (int, error) {
-	out, err := tmuxRun("show-environment", "VEE_PORT")
+	return discoverDaemonPortOn(tmuxSocketName)
+}
+
+// discoverDaemonPortOn reads VEE_PORT from the environment of the tmux
+// server of the instance named socket.
+func discoverDaemonPortOn(socket string) (int, error) {
+	out, err := tmuxRunOn(socket, "show-environment", "VEE_PORT")
 	if err != nil {
 		return 0, fmt.Errorf("show-environment: %w", err)
 	}
@@ -66,13 +83,9 @@ func discoverDaemonPort() (int, error) {
 
 // daemonAlive checks whether the daemon is responding on the given port.
 func daemonAlive(port int) bool {
-	client := &http.Client{Timeout: 2 * time.Second}
-	resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d/api/state", port))
-	if err != nil {
-		return false
-	}
-	resp.Body.Close()
-	return resp.StatusCode == http.StatusOK
+	client := &http.Client{Timeout: 2 * time.Second, Transport: daemonClient.Transport}
+	_, err := api.New(fmt.Sprintf("http://127.0.0.1:%d", port), client).State()
+	return err == nil
 }
 
 // waitForDaemon polls until the daemon is reachable or the timeout expires.
@@ -99,25 +112,42 @@ type claudeArgs []string
 
 // CLI is the top-level command structure for vee.
 type CLI struct {
-	Debug          bool              `env:"VEE_DEBUG" help:"Enable debug logging."`
-	Start          StartCmd          `cmd:"" help:"Start an interactive Vee session."`
-	Daemon         DaemonCmd         `cmd:"" help:"Run the Vee daemon (MCP server + dashboard)."`
-	NewPane        NewPaneCmd        `cmd:"" name:"_new-pane" hidden:"" help:"Internal: create a new tmux window."`
-	Dashboard      DashboardCmd      `cmd:"" name:"_dashboard" hidden:"" help:"Internal: session dashboard TUI."`
-	SessionPicker  SessionPickerCmd  `cmd:"" name:"_session-picker" hidden:"" help:"Internal: interactive profile picker."`
-	SuspendWindow  SuspendWindowCmd  `cmd:"" name:"_suspend-window" hidden:"" help:"Internal: suspend session by window."`
-	CompleteWindow CompleteWindowCmd `cmd:"" name:"_complete-window" hidden:"" help:"Internal: complete session by window."`
-	ResumeMenu     ResumeMenuCmd     `cmd:"" name:"_resume-menu" hidden:"" help:"Internal: show resume picker."`
-	ResumeSession  ResumeSessionCmd  `cmd:"" name:"_resume-session" hidden:"" help:"Internal: resume a suspended session."`
-	SessionEnded   SessionEndedCmd   `cmd:"" name:"_session-ended" hidden:"" help:"Internal: clean up after Claude exits."`
-	UpdatePreview  UpdatePreviewCmd  `cmd:"" name:"_update-preview" hidden:"" help:"Internal: update session preview from hook."`
-	UpdateWindow   UpdateWindowCmd   `cmd:"" name:"_update-window" hidden:"" help:"Internal: update window state from hook."`
-	LogViewer      LogViewerCmd      `cmd:"" name:"_log-viewer" hidden:"" help:"Internal: tail logs in a popup."`
-	PromptViewer   PromptViewerCmd   `cmd:"" name:"_prompt-viewer" hidden:"" help:"Internal: display session system prompt."`
-	KBExplorer     KBExplorerCmd     `cmd:"" name:"_kb-explorer" hidden:"" help:"Internal: KB explorer TUI."`
-	IssueResolver  IssueResolverCmd  `cmd:"" name:"_issue-resolver" hidden:"" help:"Internal: KB issue resolver TUI."`
-	Shutdown       ShutdownCmd       `cmd:"" name:"_shutdown" hidden:"" help:"Internal: graceful shutdown."`
-	Serve          ServeCmd          `cmd:"" name:"_serve" hidden:"" help:"Internal: daemon + dashboard inside tmux."`
+	Debug            bool                `env:"VEE_DEBUG" help:"Enable debug logging."`
+	Start            StartCmd            `cmd:"" help:"Start an interactive Vee session."`
+	Ls               LsCmd               `cmd:"" help:"List the sessions of this directory's instance."`
+	New              NewCmd              `cmd:"" help:"Start a session in this directory's instance."`
+	Suspend          SuspendCmd          `cmd:"" help:"Suspend a session."`
+	Resume           ResumeCmd           `cmd:"" help:"Resume a suspended session."`
+	Kill             KillCmd             `cmd:"" help:"Complete a session and close its window."`
+	Attach           AttachCmd           `cmd:"" help:"Attach to the window of a session."`
+	Daemon           DaemonCmd           `cmd:"" help:"Run the Vee daemon (MCP server + dashboard)."`
+	NewPane          NewPaneCmd          `cmd:"" name:"_new-pane" hidden:"" help:"Internal: create a new tmux window."`
+	Dashboard        DashboardCmd        `cmd:"" name:"_dashboard" hidden:"" help:"Internal: session dashboard TUI."`
+	SessionPicker    SessionPickerCmd    `cmd:"" name:"_session-picker" hidden:"" help:"Internal: interactive profile picker."`
+	SuspendWindow    SuspendWindowCmd    `cmd:"" name:"_suspend-window" hidden:"" help:"Internal: suspend session by window."`
+	CompleteWindow   CompleteWindowCmd   `cmd:"" name:"_complete-window" hidden:"" help:"Internal: complete session by window."`
+	ResumeMenu       ResumeMenuCmd       `cmd:"" name:"_resume-menu" hidden:"" help:"Internal: show resume picker."`
+	ResumeSession    ResumeSessionCmd    `cmd:"" name:"_resume-session" hidden:"" help:"Internal: resume a suspended session."`
+	ForkSession      ForkSessionCmd      `cmd:"" name:"_fork-session" hidden:"" help:"Internal: fork a session into a new profile."`
+	StartNext        StartNextCmd        `cmd:"" name:"_start-next" hidden:"" help:"Internal: start the next pipeline stage."`
+	JobMenu          JobMenuCmd          `cmd:"" name:"_job-menu" hidden:"" help:"Internal: show background jobs."`
+	SessionEnded     SessionEndedCmd     `cmd:"" name:"_session-ended" hidden:"" help:"Internal: clean up after Claude exits."`
+	UpdatePreview    UpdatePreviewCmd    `cmd:"" name:"_update-preview" hidden:"" help:"Internal: update session preview from hook."`
+	UpdateWindow     UpdateWindowCmd     `cmd:"" name:"_update-window" hidden:"" help:"Internal: update window state from hook."`
+	LogViewer        LogViewerCmd        `cmd:"" name:"_log-viewer" hidden:"" help:"Internal: tail logs in a popup."`
+	PromptViewer     PromptViewerCmd     `cmd:"" name:"_prompt-viewer" hidden:"" help:"Internal: display session system prompt."`
+	ToolCallsViewer  ToolCallsViewerCmd  `cmd:"" name:"_tool-calls" hidden:"" help:"Internal: display session tool calls."`
+	KBExplorer       KBExplorerCmd       `cmd:"" name:"_kb-explorer" hidden:"" help:"Internal: KB explorer TUI."`
+	IssueResolver    IssueResolverCmd    `cmd:"" name:"_issue-resolver" hidden:"" help:"Internal: KB issue resolver TUI."`
+	Feedback         FeedbackCmd         `cmd:"" help:"Manage feedback examples."`
+	FeedbackExplorer FeedbackExplorerCmd `cmd:"" name:"_feedback-explorer" hidden:"" help:"Internal: feedback explorer TUI."`
+	History          HistoryCmd          `cmd:"" help:"List, search and read archived sessions."`
+	Usage            UsageCmd            `cmd:"" help:"Report token usage and estimated cost."`
+	Gc               GcCmd               `cmd:"" help:"Remove the containers and temp dirs crashed instances left behind."`
+	HistoryViewer    HistoryViewerCmd    `cmd:"" name:"_history" hidden:"" help:"Internal: session history TUI."`
+	MCPProxy         MCPProxyCmd         `cmd:"" name:"_mcp-proxy" hidden:"" help:"Internal: relay a session's MCP messages to the daemon."`
+	Shutdown         ShutdownCmd         `cmd:"" name:"_shutdown" hidden:"" help:"Internal: graceful shutdown."`
+	Serve            ServeCmd            `cmd:"" name:"_serve" hidden:"" help:"Internal: daemon + dashboard inside tmux."`
 }
 
 // StartCmd runs the in-process server and manages the tmux session.
@@ -160,12 +190,27 @@ func (cmd *StartCmd) Run(args claudeArgs) error {
 			fmt.Print("\033[H\033[2J")
 			return err
 		}
-		// Stale session — clean up
+		// Stale session — clean up, along with the files of its daemon
 		tmuxRun("kill-session", "-t", tmuxSessionName)
+		if err == nil {
+			os.Remove(daemonSocketPath(port))
+			os.Remove(instanceSecretPath(port))
+		}
 	}
 
-	// Clean up stale temp directories from previous runs
-	cleanStaleTempFiles()
+	// Offer to reattach to, or tear down, the containers and Compose stacks
+	// a crashed instance of this project left behind
+	live, liveErr := liveSessions()
+	reattach := recoverLeftovers(socketName, live)
+	for _, l := range reattach {
+		live[l.SessionID] = true
+	}
+
+	// Clean up stale temp directories from previous runs, unless the
+	// sessions of another instance are unknown
+	if liveErr == nil {
+		cleanStaleTempFiles(live)
+	}
 
 	// Build the _serve command for window 0
 	absVeePath, _ := filepath.Abs(cmd.VeePath)
@@ -184,10 +229,23 @@ func (cmd *StartCmd) Run(args claudeArgs) error {
 	}
 
 	// Wait for the daemon to come up
-	if _, err := waitForDaemon(10 * time.Second); err != nil {
+	port, err := waitForDaemon(10 * time.Second)
+	if err != nil {
 		return fmt.Errorf("daemon failed to start: %w", err)
 	}
 
+	// Open a window on each container the user chose to reattach to
+	if len(reattach) > 0 {
+		if err := initProfileRegistry(cmd.VeePath); err != nil {
+			slog.Warn("failed to init profile registry", "error", err)
+		}
+		for _, l := range reattach {
+			if err := reattachLeftover(port, veeBinary, l); err != nil {
+				fmt.Fprintf(os.Stderr, "vee: failed to reattach to %s: %v\n", l.Container, err)
+			}
+		}
+	}
+
 	// Attach to tmux — blocks until detach or session end
 	err = tmuxAttach()
 	fmt.Print("\033[H\033[2J")
@@ -244,56 +302,50 @@ func (cmd *ServeCmd) Run(args claudeArgs) error {
 	}
 	idRule := identityRule(resolvedIdentity)
 
-	kbase, err := openKB(userCfg)
-	if err != nil {
-		return fmt.Errorf("failed to open knowledge base: %w", err)
-	}
-	defer kbase.Close()
-
-	// Start the KB background worker for async embedding + duplicate detection
-	workerCtx, workerCancel := context.WithCancel(context.Background())
-	defer workerCancel()
-	go kbase.RunWorker(workerCtx)
-
-	stDir, err := stateDir()
-	if err != nil {
-		return fmt.Errorf("state dir: %w", err)
-	}
-	fstore, err := feedback.Open(filepath.Join(stDir, "feedback.db"))
+	veeBinary, err := os.Executable()
 	if err != nil {
-		return fmt.Errorf("open feedback store: %w", err)
+		return fmt.Errorf("failed to resolve executable path: %w", err)
 	}
-	defer fstore.Close()
 
-	app := newApp()
+	// Resolve project directory for status bar
+	projectDir, _ := filepath.Abs(".")
 
-	srv, port, err := startHTTPServerInBackground(app, kbase, fstore)
-	if err != nil {
-		return fmt.Errorf("failed to start HTTP server: %w", err)
+	cfg := &AppConfig{
+		VeePath:        cmd.VeePath,
+		Passthrough:    []string(args),
+		ProjectConfig:  projectConfig,
+		IdentityRule:   idRule,
+		PlatformsRule:  platRule,
+		MaxExamples:    userCfg.Feedback.MaxExamples,
+		MaxPinned:      userCfg.Feedback.MaxPinned,
+		GroupExamples:  userCfg.Feedback.GroupExamples,
+		GlobalExamples: userCfg.Feedback.GlobalExamples,
+		Seeded:         userCfg.Feedback.Seeded,
+		AutoNext:       userCfg.Pipeline.Autostart,
+		ProjectDir:     projectDir,
+		TmuxSocket:     cmd.TmuxSocket,
+	}
+
+	// Serve the instance from the user-level daemon when it is shared, or
+	// from this process
+	var port int
+	if userCfg.Daemon.Shared {
+		if port, err = openSharedInstance(veeBinary, cfg); err != nil {
+			return fmt.Errorf("failed to register with the user-level daemon: %w", err)
+		}
+		defer closeSharedInstance(projectDir)
+	} else {
+		stop, err := startLocalInstance(cfg, userCfg)
+		if err != nil {
+			return fmt.Errorf("failed to start daemon: %w", err)
+		}
+		defer stop()
+		port = cfg.Port
 	}
-	defer srv.Close()
 
 	// Publish port so StartCmd can discover it on reattach
 	tmuxRun("set-environment", "VEE_PORT", fmt.Sprintf("%d", port))
 
-	veeBinary, err := os.Executable()
-	if err != nil {
-		return fmt.Errorf("failed to resolve executable path: %w", err)
-	}
-
-	app.SetConfig(&AppConfig{
-		VeePath:       cmd.VeePath,
-		Port:          port,
-		Passthrough:   []string(args),
-		ProjectConfig: projectConfig,
-		IdentityRule:  idRule,
-		PlatformsRule: platRule,
-		MaxExamples:   userCfg.Feedback.MaxExamples,
-	})
-
-	// Resolve project directory for status bar
-	projectDir, _ := filepath.Abs(".")
-
 	// Apply tmux configuration
 	if err := tmuxConfigure(veeBinary, port, cmd.VeePath, []string(args), projectDir); err != nil {
 		return fmt.Errorf("failed to configure tmux: %w", err)
@@ -309,7 +361,12 @@ type NewPaneCmd struct {
 	Port       int    `short:"p" default:"2700" name:"port"`
 	Profile    string `required:"" name:"profile"`
 	Prompt     string `name:"prompt" help:"Initial prompt for the session."`
+	Arg        string `name:"arg" help:"Argument the prompt was expanded from, reused by the next pipeline stage."`
+	Chain      string `name:"chain" help:"Pipeline the session continues."`
 	Ephemeral  bool   `name:"ephemeral" help:"Run session in an ephemeral Docker container."`
+	Job        bool   `name:"job" help:"Queue the session as a headless background job instead of opening a window."`
+	Schedule   string `name:"schedule" help:"Schedule that started the session."`
+	SessionID  string `name:"session-id" help:"ID of the new session (generated when empty)."`
 	TmuxSocket string `name:"tmux-socket" default:"vee" help:"Tmux socket name."`
 }
 
@@ -337,11 +394,19 @@ func (cmd *NewPaneCmd) Run(args claudeArgs) error {
 		appCfg = &AppConfig{}
 	}
 
-	// Generate session ID
-	sessionID := newUUID()
+	// Generate session ID, unless the caller chose it
+	sessionID := cmd.SessionID
+	if sessionID == "" {
+		sessionID = newUUID()
+	}
 
-	// Sample feedback for this profile
-	feedbackBlock := fetchFeedbackBlock(cmd.Port, profile.Name, appCfg.MaxExamples)
+	// Sample feedback for this profile. In seeded mode the session ID fixes
+	// the draw, so the same session always gets the same examples.
+	var feedbackSeed string
+	if appCfg.Seeded {
+		feedbackSeed = sessionID
+	}
+	feedbackBlock, feedbackIDs := fetchFeedbackBlock(cmd.Port, profile, cmd.Prompt, feedbackSeed, appCfg)
 
 	// Read compose file contents for prompt injection (if ephemeral + compose configured)
 	isEphemeral := cmd.Ephemeral
@@ -358,6 +423,23 @@ func (cmd *NewPaneCmd) Run(args claudeArgs) error {
 	// Compose system prompt (for storage — lets prompt viewer display it later)
 	systemPrompt := composeSystemPrompt(profile.Prompt, appCfg.IdentityRule, appCfg.PlatformsRule, feedbackBlock, appCfg.ProjectConfig, isEphemeral, composeContents)
 
+	// Background jobs run claude in print mode from the daemon, with the same
+	// arguments as a host session but no window.
+	if cmd.Job {
+		if isEphemeral {
+			return fmt.Errorf("background jobs cannot run in ephemeral containers")
+		}
+		if strings.TrimSpace(cmd.Prompt) == "" {
+			return fmt.Errorf("background jobs need a prompt")
+		}
+		sessionArgs := buildSessionArgs(sessionID, false, profile, appCfg.ProjectConfig, appCfg.IdentityRule, appCfg.PlatformsRule, feedbackBlock, cmd.Port, cmd.VeePath, []string(args), veeBinary)
+		if err := submitJob(cmd.Port, sessionID, profile, cmd.Prompt, sessionArgs, feedbackIDs, cmd.Schedule); err != nil {
+			return fmt.Errorf("failed to queue job: %w", err)
+		}
+		tmuxRun("display-message", fmt.Sprintf("Queued job %s %s", profile.Indicator, profile.Name))
+		return nil
+	}
+
 	var shellCmd string
 	if isEphemeral {
 		cfg, err := readProjectTOML()
@@ -404,8 +486,16 @@ func (cmd *NewPaneCmd) Run(args claudeArgs) error {
 		}
 	}
 
-	// Register session with daemon, including the window target and system prompt
-	if err := registerSession(cmd.Port, sessionID, profile, windowID, isEphemeral, regComposePath, regComposeProject, systemPrompt); err != nil {
+	// A session whose profile has a next stage starts a pipeline, named after
+	// its prompt argument, unless it already continues one.
+	chain := cmd.Chain
+	if chain == "" && profile.Next != "" {
+		chain = cmd.Arg
+	}
+
+	// Register session with daemon, including the window target, system prompt,
+	// the feedback examples it was given and its place in a pipeline
+	if err := registerSession(cmd.Port, sessionID, profile, windowID, isEphemeral, regComposePath, regComposeProject, systemPrompt, feedbackIDs, cmd.Arg, chain, cmd.Schedule, ""); err != nil {
 		slog.Warn("failed to register session with daemon", "error", err)
 	}
 
@@ -413,34 +503,25 @@ func (cmd *NewPaneCmd) Run(args claudeArgs) error {
 }
 
 // registerSession registers a new session with the running daemon.
-func registerSession(port int, sessionID string, profile Profile, windowTarget string, ephemeral bool, composePath, composeProject, systemPrompt string) error {
-	payload, _ := json.Marshal(map[string]any{
-		"id":              sessionID,
-		"profile":         profile.Name,
-		"indicator":       profile.Indicator,
-		"preview":         "",
-		"window_target":   windowTarget,
-		"ephemeral":       ephemeral,
-		"compose_path":    composePath,
-		"compose_project": composeProject,
-		"system_prompt":   systemPrompt,
+func registerSession(port int, sessionID string, profile Profile, windowTarget string, ephemeral bool, composePath, composeProject, systemPrompt string, feedbackIDs []string, promptArg, chain, schedule, parentID string) error {
+	return daemonAPI(port).CreateSession(api.CreateSessionRequest{
+		ID:             sessionID,
+		Profile:        profile.Name,
+		Indicator:      profile.Indicator,
+		WindowTarget:   windowTarget,
+		Ephemeral:      ephemeral,
+		ComposePath:    composePath,
+		ComposeProject: composeProject,
+		SystemPrompt:   systemPrompt,
+		FeedbackIDs:    feedbackIDs,
+		Groups:         profile.Groups,
+		PromptArg:      promptArg,
+		Next:           profile.Next,
+		Chain:          chain,
+		Schedule:       schedule,
+		ParentID:       parentID,
+		Permissions:    profile.Permissions,
 	})
-
-	resp, err := http.Post(
-		fmt.Sprintf("http://127.0.0.1:%d/api/sessions", port),
-		"application/json",
-		strings.NewReader(string(payload)),
-	)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("daemon returned %d", resp.StatusCode)
-	}
-
-	return nil
 }
 
 // SuspendWindowCmd suspends the session running in a given tmux window.
@@ -453,25 +534,13 @@ type SuspendWindowCmd struct {
 // Run suspends the session by its tmux window ID.
 func (cmd *SuspendWindowCmd) Run() error {
 	tmuxSocketName = cmd.TmuxSocket
-	body := fmt.Sprintf(`{"window_target":%q}`, cmd.WindowID)
-
-	resp, err := http.Post(
-		fmt.Sprintf("http://127.0.0.1:%d/api/suspend", cmd.Port),
-		"application/json",
-		strings.NewReader(body),
-	)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusNotFound {
+	_, err := daemonAPI(cmd.Port).Suspend(cmd.WindowID)
+	if api.IsStatus(err, http.StatusNotFound) {
 		// No session in this window (e.g. dashboard) — show a tmux message
 		tmuxRun("display-message", "No session to suspend in this window")
 		return nil
 	}
-
-	return nil
+	return err
 }
 
 // CompleteWindowCmd marks the session running in a given tmux window as completed.
@@ -484,24 +553,12 @@ type CompleteWindowCmd struct {
 // Run marks the session as completed by its tmux window ID.
 func (cmd *CompleteWindowCmd) Run() error {
 	tmuxSocketName = cmd.TmuxSocket
-	body := fmt.Sprintf(`{"window_target":%q}`, cmd.WindowID)
-
-	resp, err := http.Post(
-		fmt.Sprintf("http://127.0.0.1:%d/api/complete", cmd.Port),
-		"application/json",
-		strings.NewReader(body),
-	)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusNotFound {
+	_, err := daemonAPI(cmd.Port).Complete(cmd.WindowID)
+	if api.IsStatus(err, http.StatusNotFound) {
 		tmuxRun("display-message", "No session to complete in this window")
 		return nil
 	}
-
-	return nil
+	return err
 }
 
 // ResumeMenuCmd shows a tmux display-menu of suspended sessions.
@@ -514,20 +571,20 @@ type ResumeMenuCmd struct {
 func (cmd *ResumeMenuCmd) Run() error {
 	tmuxSocketName = cmd.TmuxSocket
 	// Fetch state from daemon
-	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/api/state", cmd.Port))
+	state, err := daemonAPI(cmd.Port).State()
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	var state struct {
-		Suspended []*Session `json:"suspended_sessions"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
-		return err
+	// Pipeline stages offered when a session completed, and not started yet
+	var pending []*Session
+	for _, sess := range state.Completed {
+		if sess.Next != "" && !sess.NextStarted {
+			pending = append(pending, sess)
+		}
 	}
 
-	if len(state.Suspended) == 0 {
+	if len(state.Suspended) == 0 && len(pending) == 0 {
 		tmuxRun("display-message", "No suspended sessions")
 		return nil
 	}
@@ -556,6 +613,13 @@ func (cmd *ResumeMenuCmd) Run() error {
 		args = append(args, label, "", "run-shell "+shelljoin(resumeCmd))
 	}
 
+	for _, sess := range pending {
+		startCmd := fmt.Sprintf("%s _start-next --port %d --session-id %s --tmux-socket %s",
+			shelljoin(veeBinary), cmd.Port, sess.ID, tmuxSocketName)
+
+		args = append(args, nextStageLabel(sess, profileRegistry), "", "run-shell "+shelljoin(startCmd))
+	}
+
 	_, err = tmuxRun(args...)
 	return err
 }
@@ -614,16 +678,8 @@ func (cmd *ResumeSessionCmd) Run() error {
 	}
 
 	// Activate the session with the new window target
-	activateBody := fmt.Sprintf(`{"session_id":%q,"window_target":%q}`, cmd.SessionID, windowID)
-	resp, err := http.Post(
-		fmt.Sprintf("http://127.0.0.1:%d/api/activate", cfg.Port),
-		"application/json",
-		strings.NewReader(activateBody),
-	)
-	if err != nil {
+	if err := daemonAPI(cfg.Port).Activate(cmd.SessionID, windowID); err != nil {
 		slog.Warn("failed to activate session", "error", err)
-	} else {
-		resp.Body.Close()
 	}
 
 	return nil
@@ -678,18 +734,8 @@ func (cmd *SessionEndedCmd) Run() error {
 		slog.Debug("session-ended: cleaned up temp dir", "dir", dir)
 	}
 
-	body := fmt.Sprintf(`{"session_id":%q}`, cmd.SessionID)
-
-	resp, err := http.Post(
-		fmt.Sprintf("http://127.0.0.1:%d/api/session-ended", cmd.Port),
-		"application/json",
-		strings.NewReader(body),
-	)
-	if err != nil {
-		// Daemon might already be gone (e.g. Ctrl-b q killed everything)
-		return nil
-	}
-	defer resp.Body.Close()
+	// The daemon might already be gone (e.g. Ctrl-b q killed everything)
+	daemonAPI(cmd.Port).SessionEnded(cmd.SessionID)
 	return nil
 }
 
@@ -706,63 +752,43 @@ func (cmd *ShutdownCmd) Run() error {
 	slog.Debug("shutdown: starting graceful shutdown")
 
 	// Fetch state from the daemon
-	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/api/state", cmd.Port))
-	if err == nil {
-		defer resp.Body.Close()
-
-		var state struct {
-			Active   []*Session     `json:"active_sessions"`
-			Indexing []IndexingTask `json:"indexing_tasks"`
-		}
-		if err := json.NewDecoder(resp.Body).Decode(&state); err == nil {
-			// Warn if indexing is in progress
-			if len(state.Indexing) > 0 {
-				slog.Debug("shutdown: indexing in progress", "count", len(state.Indexing))
-				msg := fmt.Sprintf("%d note(s) are being indexed. Quit anyway?", len(state.Indexing))
-				// Use tmux confirm-before to ask the user
-				out, confirmErr := tmuxRun("confirm-before", "-p", msg+" (y/n)", "run-shell 'exit 0'")
-				if confirmErr != nil {
-					slog.Debug("shutdown: user cancelled due to indexing warning", "output", out)
-					return nil
-				}
+	client := daemonAPI(cmd.Port)
+	if state, err := client.State(); err == nil {
+		// Warn if indexing is in progress
+		if len(state.Indexing) > 0 {
+			slog.Debug("shutdown: indexing in progress", "count", len(state.Indexing))
+			msg := fmt.Sprintf("%d note(s) are being indexed. Quit anyway?", len(state.Indexing))
+			// Use tmux confirm-before to ask the user
+			out, confirmErr := tmuxRun("confirm-before", "-p", msg+" (y/n)", "run-shell 'exit 0'")
+			if confirmErr != nil {
+				slog.Debug("shutdown: user cancelled due to indexing warning", "output", out)
+				return nil
 			}
+		}
 
-			slog.Debug("shutdown: handling active sessions", "count", len(state.Active))
-			for _, sess := range state.Active {
-				if sess.Ephemeral {
-					// Ephemeral sessions cannot be suspended — the daemon's
-					// /api/complete handler takes care of container + compose cleanup.
-					slog.Debug("shutdown: completing ephemeral session", "id", sess.ID, "profile", sess.Profile)
-					body := fmt.Sprintf(`{"window_target":%q}`, sess.WindowTarget)
-					r, err := http.Post(
-						fmt.Sprintf("http://127.0.0.1:%d/api/complete", cmd.Port),
-						"application/json",
-						strings.NewReader(body),
-					)
-					if err == nil {
-						r.Body.Close()
-					}
-				} else {
-					slog.Debug("shutdown: suspending session", "id", sess.ID, "profile", sess.Profile, "window", sess.WindowTarget)
-					body := fmt.Sprintf(`{"window_target":%q}`, sess.WindowTarget)
-					r, err := http.Post(
-						fmt.Sprintf("http://127.0.0.1:%d/api/suspend", cmd.Port),
-						"application/json",
-						strings.NewReader(body),
-					)
-					if err == nil {
-						r.Body.Close()
-					}
-				}
+		slog.Debug("shutdown: handling active sessions", "count", len(state.Active))
+		for _, sess := range state.Active {
+			if sess.Ephemeral {
+				// Ephemeral sessions cannot be suspended — the daemon's
+				// /api/complete handler takes care of container + compose cleanup.
+				slog.Debug("shutdown: completing ephemeral session", "id", sess.ID, "profile", sess.Profile)
+				client.Complete(sess.WindowTarget)
+			} else {
+				slog.Debug("shutdown: suspending session", "id", sess.ID, "profile", sess.Profile, "window", sess.WindowTarget)
+				client.Suspend(sess.WindowTarget)
 			}
 		}
 	} else {
 		slog.Warn("shutdown: failed to fetch state from daemon", "error", err)
 	}
 
-	// Clean up all temp dirs
+	// Clean up the temp dirs no running instance uses anymore
 	slog.Debug("shutdown: cleaning stale temp files")
-	cleanStaleTempFiles()
+	if live, err := liveSessions(); err == nil {
+		cleanStaleTempFiles(live)
+	} else {
+		slog.Warn("shutdown: keeping temp files", "error", err)
+	}
 
 	// Kill the entire tmux server for this socket. Each vee instance has
 	// its own socket, so this is safe and also cleans up the background
@@ -804,21 +830,9 @@ func (cmd *UpdatePreviewCmd) Run() error {
 
 	slog.Debug("update-preview: posting preview", "session", cmd.SessionID, "preview", preview)
 
-	body, _ := json.Marshal(map[string]string{
-		"session_id": cmd.SessionID,
-		"preview":    preview,
-	})
-
-	resp, err := http.Post(
-		fmt.Sprintf("http://127.0.0.1:%d/api/preview", cmd.Port),
-		"application/json",
-		strings.NewReader(string(body)),
-	)
-	if err != nil {
+	if err := daemonAPI(cmd.Port).SetPreview(cmd.SessionID, preview); err != nil {
 		slog.Debug("update-preview: failed to post preview", "error", err)
-		return nil
 	}
-	resp.Body.Close()
 	return nil
 }
 
@@ -845,6 +859,7 @@ func (cmd *UpdateWindowCmd) Run() error {
 		PermissionMode string `json:"permission_mode"`
 		Prompt         string `json:"prompt"`
 		IsInterrupt    bool   `json:"is_interrupt"`
+		TranscriptPath string `json:"transcript_path"`
 	}
 	if err := json.NewDecoder(os.Stdin).Decode(&hookData); err != nil {
 		slog.Debug("update-window: failed to decode hook stdin", "error", err)
@@ -857,24 +872,18 @@ func (cmd *UpdateWindowCmd) Run() error {
 	}
 
 	// Build the request body
-	body := map[string]any{
-		"session_id": cmd.SessionID,
-	}
-
-	if cmd.Working {
-		body["working"] = true
-	} else if cmd.NoWorking {
-		body["working"] = false
+	req := api.WindowStateRequest{
+		SessionID:      cmd.SessionID,
+		PermissionMode: hookData.PermissionMode,
+		TranscriptPath: hookData.TranscriptPath,
 	}
 
-	if cmd.Notification {
-		body["notification"] = true
-	} else if cmd.NoNotification {
-		body["notification"] = false
+	if cmd.Working || cmd.NoWorking {
+		req.Working = &cmd.Working
 	}
 
-	if hookData.PermissionMode != "" {
-		body["permission_mode"] = hookData.PermissionMode
+	if cmd.Notification || cmd.NoNotification {
+		req.Notification = &cmd.Notification
 	}
 
 	if hookData.Prompt != "" {
@@ -882,26 +891,15 @@ func (cmd *UpdateWindowCmd) Run() error {
 		if len(preview) > 200 {
 			preview = preview[:200]
 		}
-		body["preview"] = preview
-	}
-
-	payload, err := json.Marshal(body)
-	if err != nil {
-		return err
+		req.Preview = preview
 	}
 
+	payload, _ := json.Marshal(req)
 	slog.Debug("update-window: posting state", "session", cmd.SessionID, "body", string(payload))
 
-	resp, err := http.Post(
-		fmt.Sprintf("http://127.0.0.1:%d/api/window-state", cmd.Port),
-		"application/json",
-		strings.NewReader(string(payload)),
-	)
-	if err != nil {
+	if err := daemonAPI(cmd.Port).SetWindowState(req); err != nil {
 		slog.Debug("update-window: failed to post state", "error", err)
-		return nil
 	}
-	resp.Body.Close()
 	return nil
 }
 
@@ -910,20 +908,30 @@ func sessionTempDir(sessionID string) string {
 	return filepath.Join(veeRuntimeDir(), "session-"+sessionID)
 }
 
-// cleanStaleTempFiles removes leftover session temp dirs from the runtime directory.
-func cleanStaleTempFiles() {
+// cleanStaleTempFiles removes leftover session temp dirs from the runtime
+// directory, except those of the sessions in keep.
+func cleanStaleTempFiles(keep map[string]bool) {
+	for _, path := range staleTempDirs(keep) {
+		slog.Debug("cleanup: removing stale session dir", "path", path)
+		os.RemoveAll(path)
+	}
+}
+
+// staleTempDirs returns the session temp dirs of the runtime directory that
+// belong to none of the sessions in keep.
+func staleTempDirs(keep map[string]bool) []string {
 	rtDir := veeRuntimeDir()
 	entries, _ := os.ReadDir(rtDir)
+	var paths []string
 	for _, e := range entries {
 		if !e.IsDir() {
 			continue
 		}
-		if strings.HasPrefix(e.Name(), "session-") {
-			path := filepath.Join(rtDir, e.Name())
-			slog.Debug("cleanup: removing stale session dir", "path", path)
-			os.RemoveAll(path)
+		if id, ok := strings.CutPrefix(e.Name(), "session-"); ok && !keep[id] {
+			paths = append(paths, filepath.Join(rtDir, e.Name()))
 		}
 	}
+	return paths
 }
 
 // splitAtDashDash splits args at the first "--".
@@ -1041,16 +1049,24 @@ func composeSystemPrompt(base, identityRule, platformsRule, feedbackBlock, proje
 	return sb.String()
 }
 
-func writeMCPConfig(port int, sessionID string) (string, error) {
+// writeMCPConfig writes the MCP config of a host session, which reaches the
+// daemon through vee _mcp-proxy and the daemon's Unix socket.
+func writeMCPConfig(veeBinary string, port int, sessionID string) (string, error) {
 	dir := sessionTempDir(sessionID)
 	if err := os.MkdirAll(dir, 0700); err != nil {
 		return "", err
 	}
 
 	path := filepath.Join(dir, "mcp.json")
-	content := fmt.Sprintf(`{"mcpServers":{"vee-daemon":{"type":"sse","url":"http://127.0.0.1:%d/sse?session=%s"}}}`, port, sessionID)
+	content, _ := json.Marshal(map[string]any{
+		"mcpServers": map[string]any{"vee-daemon": map[string]any{
+			"type":    "stdio",
+			"command": veeBinary,
+			"args":    []string{"_mcp-proxy", "--port", strconv.Itoa(port), "--session-id", sessionID},
+		}},
+	})
 
-	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+	if err := os.WriteFile(path, content, 0600); err != nil {
 		return "", err
 	}
 
@@ -1058,6 +1074,19 @@ func writeMCPConfig(port int, sessionID string) (string, error) {
 	return path, nil
 }
 
+// mcpConfigJSON returns an MCP config connecting to the daemon's SSE
+// endpoint at url, authenticated with a session token, for containers.
+func mcpConfigJSON(url, token string) []byte {
+	server := map[string]any{"type": "sse", "url": url}
+	if token != "" {
+		server["headers"] = map[string]string{"Authorization": "Bearer " + token}
+	}
+	content, _ := json.Marshal(map[string]any{
+		"mcpServers": map[string]any{"vee-daemon": server},
+	})
+	return content
+}
+
 func writeSettings(sessionID string, port int, veeBinary string) (string, error) {
 	dir := sessionTempDir(sessionID)
 	if err := os.MkdirAll(dir, 0700); err != nil {
@@ -1167,42 +1196,12 @@ func buildArgs(originalArgs []string, systemPromptContent string) []string {
 
 // fetchAppConfig fetches the full AppConfig from the running daemon.
 func fetchAppConfig(port int) (*AppConfig, error) {
-	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/api/config", port))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("daemon returned %d", resp.StatusCode)
-	}
-
-	var cfg AppConfig
-	if err := json.NewDecoder(resp.Body).Decode(&cfg); err != nil {
-		return nil, err
-	}
-
-	return &cfg, nil
+	return daemonAPI(port).Config()
 }
 
 // fetchSession fetches a single session's state from the running daemon.
 func fetchSession(port int, sessionID string) (*Session, error) {
-	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/api/session?id=%s", port, sessionID))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("daemon returned %d", resp.StatusCode)
-	}
-
-	var sess Session
-	if err := json.NewDecoder(resp.Body).Decode(&sess); err != nil {
-		return nil, err
-	}
-
-	return &sess, nil
+	return daemonAPI(port).Session(sessionID)
 }
 
 // stripSystemPrompt removes --append-system-prompt and its value from args.
@@ -1227,46 +1226,45 @@ func stripSystemPrompt(args []string) []string {
 }
 
 // fetchFeedbackBlock calls the daemon's /api/feedback/sample endpoint and
-// formats the result as a prompt block. Returns "" if no entries are sampled.
-func fetchFeedbackBlock(port int, profile string, maxExamples int) string {
-	if maxExamples <= 0 {
-		return ""
+// formats the result as a prompt block. The session's initial prompt, if any,
+// lets the daemon favor relevant examples; a non-empty seed makes the draw
+// deterministic. Examples are drawn from the profile, its groups and all
+// profiles within the quotas of cfg, plus pinned examples within their own
+// budget. Also returns the IDs of the injected entries.
+// Returns "" if no entries are sampled.
+func fetchFeedbackBlock(port int, profile Profile, prompt, seed string, cfg *AppConfig) (string, []string) {
+	if cfg.MaxExamples <= 0 && cfg.GroupExamples <= 0 && cfg.GlobalExamples <= 0 && cfg.MaxPinned <= 0 {
+		return "", nil
 	}
 
 	project, _ := filepath.Abs(".")
 
-	url := fmt.Sprintf("http://127.0.0.1:%d/api/feedback/sample?profile=%s&project=%s&n=%d",
-		port, profile, project, maxExamples)
-
-	resp, err := http.Get(url)
+	entries, err := daemonAPI(port).FeedbackSample(api.FeedbackSample{
+		Profile: profile.Name,
+		Project: project,
+		N:       cfg.MaxExamples,
+		Pinned:  cfg.MaxPinned,
+		Prompt:  prompt,
+		Seed:    seed,
+		Groups:  profile.Groups,
+		GroupN:  cfg.GroupExamples,
+		GlobalN: cfg.GlobalExamples,
+	})
 	if err != nil {
 		slog.Debug("failed to fetch feedback samples", "error", err)
-		return ""
+		return "", nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return ""
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
 	}
-
-	var entries []struct {
-		Kind      string `json:"kind"`
-		Statement string `json:"statement"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
-		slog.Debug("failed to decode feedback samples", "error", err)
-		return ""
-	}
-
-	return formatFeedbackBlock(entries)
+	return formatFeedbackBlock(entries), ids
 }
 
 // formatFeedbackBlock renders a list of feedback entries as a <rule> block
 // for injection into the system prompt. Returns "" if entries is empty.
-func formatFeedbackBlock(entries []struct {
-	Kind      string `json:"kind"`
-	Statement string `json:"statement"`
-}) string {
+func formatFeedbackBlock(entries []feedback.Entry) string {
 	if len(entries) == 0 {
 		return ""
 	}
@@ -1298,7 +1296,7 @@ func buildSessionArgs(sessionID string, resume bool, profile Profile, projectCon
 	}
 
 	// MCP config — always provided (needed for request_suspend and KB tools)
-	mcpConfigFile, err := writeMCPConfig(port, sessionID)
+	mcpConfigFile, err := writeMCPConfig(veeBinary, port, sessionID)
 	if err != nil {
 		slog.Error("failed to write MCP config", "error", err)
 	} else {
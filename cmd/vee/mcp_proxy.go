@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// MCPProxyCmd is the MCP server of host sessions. Claude only reaches SSE
+// servers over TCP, so host sessions run this command instead: it relays
+// the messages Claude exchanges on stdio with the daemon's SSE endpoint,
+// over the daemon's Unix socket.
+type MCPProxyCmd struct {
+	Port      int    `short:"p" default:"2700" name:"port"`
+	SessionID string `required:"" name:"session-id"`
+}
+
+// Run relays MCP messages until Claude or the daemon closes its side.
+func (cmd *MCPProxyCmd) Run() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	daemon, err := (&mcp.SSEClientTransport{
+		Endpoint:   fmt.Sprintf("http://127.0.0.1:%d/sse?session=%s", cmd.Port, cmd.SessionID),
+		HTTPClient: daemonClient,
+	}).Connect(ctx)
+	if err != nil {
+		return fmt.Errorf("connect to daemon: %w", err)
+	}
+	defer daemon.Close()
+
+	claude, err := (&mcp.StdioTransport{}).Connect(ctx)
+	if err != nil {
+		return fmt.Errorf("connect to stdio: %w", err)
+	}
+	defer claude.Close()
+
+	done := make(chan error, 2)
+	go func() { done <- relayMCP(ctx, claude, daemon) }()
+	go func() { done <- relayMCP(ctx, daemon, claude) }()
+	slog.Debug("mcp proxy stopped", "session", cmd.SessionID, "reason", <-done)
+	return nil
+}
+
+// relayMCP copies the messages read from one connection to the other.
+func relayMCP(ctx context.Context, from, to mcp.Connection) error {
+	for {
+		msg, err := from.Read(ctx)
+		if err != nil {
+			return err
+		}
+		if err := to.Write(ctx, msg); err != nil {
+			return err
+		}
+	}
+}
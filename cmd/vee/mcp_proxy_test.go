@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestRelayMCP(t *testing.T) {
+	ctx := context.Background()
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "vee", Version: "1.0.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "ping"}, func(ctx context.Context, req *mcp.CallToolRequest, args struct{}) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "pong"}}}, nil, nil
+	})
+
+	// client ↔ (claude side) relay (daemon side) ↔ server
+	clientT, claudeT := mcp.NewInMemoryTransports()
+	daemonT, serverT := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverT, nil); err != nil {
+		t.Fatal(err)
+	}
+	claude, err := claudeT.Connect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	daemon, err := daemonT.Connect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go relayMCP(ctx, claude, daemon)
+	go relayMCP(ctx, daemon, claude)
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "claude", Version: "1.0.0"}, nil)
+	cs, err := client.Connect(ctx, clientT, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+
+	res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "ping"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, ok := res.Content[0].(*mcp.TextContent); !ok || text.Text != "pong" {
+		t.Errorf("result = %+v", res.Content)
+	}
+}
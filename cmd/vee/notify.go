@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Session events that can be notified.
+const (
+	eventNotification = "notification" // the session raised a notification (e.g. a permission prompt)
+	eventTurn         = "turn"         // the session finished a turn
+	eventEnded        = "ended"        // the session ended or was suspended without the user
+)
+
+// Notification is a session event sent to the notification sinks.
+type Notification struct {
+	Event     string    `json:"event"`
+	SessionID string    `json:"session_id"`
+	Profile   string    `json:"profile"`
+	Indicator string    `json:"indicator"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	Time      time.Time `json:"time"`
+}
+
+// notifier sends session events to the configured sinks, following the
+// rules of the session's profile and dropping repeats within the debounce
+// delay.
+type notifier struct {
+	mu    sync.Mutex
+	cfg   NotifyConfig
+	sinks map[string]func(Notification) error
+	last  map[string]time.Time // "session/event" → last notification
+	now   func() time.Time
+
+	// tmuxSocket names the tmux server whose clients the bell rings.
+	tmuxSocket func() string
+}
+
+func newNotifier() *notifier {
+	n := &notifier{
+		last:       make(map[string]time.Time),
+		now:        time.Now,
+		tmuxSocket: func() string { return tmuxSocketName },
+	}
+	n.sinks = map[string]func(Notification) error{
+		"desktop": sendDesktop,
+		"bell": func(Notification) error {
+			return sendBell(n.tmuxSocket())
+		},
+	}
+	return n
+}
+
+// configure replaces the notification settings.
+func (n *notifier) configure(cfg NotifyConfig) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.cfg = cfg
+	n.sinks["webhook"] = func(notif Notification) error {
+		return sendWebhook(cfg.Webhook, notif)
+	}
+	n.sinks["command"] = func(notif Notification) error {
+		return runNotifyCommand(cfg.Command, notif)
+	}
+	for _, name := range cfg.Sinks {
+		if _, ok := n.sinks[name]; !ok {
+			slog.Warn("unknown notification sink, ignoring", "sink", name)
+		}
+	}
+}
+
+// notify sends an event of sess to the sinks enabled for its profile, in
+// the background.
+func (n *notifier) notify(sess *Session, event string) {
+	n.mu.Lock()
+	sinks, events := n.cfg.Sinks, n.cfg.Events
+	if rule, ok := n.cfg.Profiles[sess.Profile]; ok {
+		if rule.Sinks != nil {
+			sinks = rule.Sinks
+		}
+		if rule.Events != nil {
+			events = rule.Events
+		}
+	}
+	if len(sinks) == 0 || !slices.Contains(events, event) {
+		n.mu.Unlock()
+		return
+	}
+
+	now := n.now()
+	key := sess.ID + "/" + event
+	if last, ok := n.last[key]; ok && now.Sub(last) < n.cfg.Debounce {
+		n.mu.Unlock()
+		slog.Debug("notification debounced", "session", sess.ID, "event", event)
+		return
+	}
+	n.last[key] = now
+
+	var send []func(Notification) error
+	var names []string
+	for _, name := range sinks {
+		if fn, ok := n.sinks[name]; ok {
+			send = append(send, fn)
+			names = append(names, name)
+		}
+	}
+	n.mu.Unlock()
+
+	notif := newNotification(sess, event, now)
+	for i, fn := range send {
+		go func() {
+			if err := fn(notif); err != nil {
+				slog.Warn("failed to send notification", "sink", names[i], "event", event, "session", sess.ID, "error", err)
+			}
+		}()
+	}
+}
+
+// newNotification describes an event of sess.
+func newNotification(sess *Session, event string, at time.Time) Notification {
+	name := strings.TrimSpace(sess.Indicator + " " + sess.Profile)
+	notif := Notification{
+		Event:     event,
+		SessionID: sess.ID,
+		Profile:   sess.Profile,
+		Indicator: sess.Indicator,
+		Message:   sess.Preview,
+		Time:      at,
+	}
+	switch event {
+	case eventNotification:
+		notif.Title = name + " needs your attention"
+	case eventTurn:
+		notif.Title = name + " finished its turn"
+	case eventEnded:
+		notif.Title = name + " ended"
+	default:
+		notif.Title = name + ": " + event
+	}
+	return notif
+}
+
+// sendDesktop shows a desktop notification with notify-send.
+func sendDesktop(notif Notification) error {
+	out, err := exec.Command("notify-send", "--app-name=vee", notif.Title, notif.Message).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("notify-send: %w: %s", err, out)
+	}
+	return nil
+}
+
+// sendBell rings the terminal bell of every client attached to the Vee tmux
+// session of the instance named socket, which most terminals turn into an
+// urgency hint.
+func sendBell(socket string) error {
+	out, err := tmuxRunOn(socket, "list-clients", "-t", tmuxSessionName, "-F", "#{client_tty}")
+	if err != nil {
+		return fmt.Errorf("list clients: %w", err)
+	}
+	for tty := range strings.Lines(out) {
+		tty = strings.TrimSpace(tty)
+		if tty == "" {
+			continue
+		}
+		f, err := os.OpenFile(tty, os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("open %s: %w", tty, err)
+		}
+		f.WriteString("\a")
+		f.Close()
+	}
+	return nil
+}
+
+// sendWebhook POSTs the notification as JSON to url.
+func sendWebhook(url string, notif Notification) error {
+	if url == "" {
+		return fmt.Errorf("notify.webhook is not set")
+	}
+	payload, _ := json.Marshal(notif)
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// runNotifyCommand runs the command hook through the shell, with the
+// notification as JSON on stdin and its fields in VEE_* variables.
+func runNotifyCommand(command string, notif Notification) error {
+	if command == "" {
+		return fmt.Errorf("notify.command is not set")
+	}
+	payload, _ := json.Marshal(notif)
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"VEE_EVENT="+notif.Event,
+		"VEE_SESSION_ID="+notif.SessionID,
+		"VEE_PROFILE="+notif.Profile,
+		"VEE_TITLE="+notif.Title,
+		"VEE_MESSAGE="+notif.Message,
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, out)
+	}
+	return nil
+}
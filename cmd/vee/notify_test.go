@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNotifierRulesAndDebounce(t *testing.T) {
+	now := time.Date(2025, 3, 5, 9, 0, 0, 0, time.UTC)
+	sent := make(chan Notification, 10)
+
+	n := newNotifier()
+	n.now = func() time.Time { return now }
+	n.configure(NotifyConfig{
+		Sinks:    []string{"test"},
+		Events:   []string{eventNotification, eventEnded},
+		Debounce: 30 * time.Second,
+		Profiles: map[string]NotifyRule{
+			"vibe": {Events: []string{eventTurn}},
+		},
+	})
+	n.sinks["test"] = func(notif Notification) error {
+		sent <- notif
+		return nil
+	}
+
+	normal := &Session{ID: "s1", Profile: "normal", Indicator: "🦊", Preview: "fix the build"}
+	vibe := &Session{ID: "s2", Profile: "vibe", Indicator: "⚡"}
+
+	expect := func(event, session string) {
+		t.Helper()
+		select {
+		case notif := <-sent:
+			if notif.Event != event || notif.SessionID != session {
+				t.Fatalf("expected %s for %s, got %+v", event, session, notif)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected %s for %s, got nothing", event, session)
+		}
+	}
+
+	n.notify(normal, eventNotification)
+	expect(eventNotification, "s1")
+
+	// Turns are not notified for normal, but are for vibe, which does not
+	// get notifications
+	n.notify(normal, eventTurn)
+	n.notify(vibe, eventNotification)
+	n.notify(vibe, eventTurn)
+	expect(eventTurn, "s2")
+
+	// Repeats are dropped within the debounce delay
+	now = now.Add(10 * time.Second)
+	n.notify(normal, eventNotification)
+	now = now.Add(30 * time.Second)
+	n.notify(normal, eventNotification)
+	expect(eventNotification, "s1")
+
+	select {
+	case notif := <-sent:
+		t.Fatalf("unexpected notification %+v", notif)
+	default:
+	}
+}
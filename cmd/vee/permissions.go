@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lthms/vee/internal/api"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Knowledge base access levels a profile can grant.
+const (
+	kbNone  = "none"
+	kbRead  = "read"
+	kbWrite = "write"
+)
+
+// veeMCPTools lists the tools of the Vee MCP server.
+var veeMCPTools = []string{"request_suspend", "kb_remember", "kb_query", "kb_touch", "feedback_record"}
+
+// kbToolAccess is the knowledge base access each KB tool needs.
+var kbToolAccess = map[string]string{
+	"kb_query":    kbRead,
+	"kb_remember": kbWrite,
+	"kb_touch":    kbWrite,
+}
+
+// mcpRegistrationWait is how long an MCP connection waits for its session
+// to be registered: Claude may connect before the window that runs it is
+// known to the daemon.
+var mcpRegistrationWait = 5 * time.Second
+
+// ToolPermissions are the Vee MCP tools a profile may use, declared with
+// tools: and kb: in its frontmatter.
+type ToolPermissions = api.ToolPermissions
+
+// validatePermissions reports unknown tools and access levels.
+func validatePermissions(p ToolPermissions) error {
+	for _, tool := range p.Tools {
+		if !slices.Contains(veeMCPTools, tool) {
+			return fmt.Errorf("unknown tool %q (known: %s)", tool, strings.Join(veeMCPTools, ", "))
+		}
+	}
+	switch p.KB {
+	case "", kbNone, kbRead, kbWrite:
+		return nil
+	}
+	return fmt.Errorf("kb must be none, read or write, got %q", p.KB)
+}
+
+// toolAllowed reports whether a tool may be called: it must be listed, when
+// tools are listed, and KB tools need the access level they require.
+func toolAllowed(p ToolPermissions, tool string) bool {
+	if p.Tools != nil && !slices.Contains(p.Tools, tool) {
+		return false
+	}
+	need, ok := kbToolAccess[tool]
+	if !ok {
+		return true
+	}
+	switch p.KB {
+	case "", kbWrite:
+		return true
+	case kbRead:
+		return need == kbRead
+	}
+	return false
+}
+
+// deniedTools returns the Vee MCP tools that may not be called.
+func deniedTools(p ToolPermissions) []string {
+	var names []string
+	for _, tool := range veeMCPTools {
+		if !toolAllowed(p, tool) {
+			names = append(names, tool)
+		}
+	}
+	return names
+}
+
+// noTools grants nothing, for MCP connections of unknown sessions.
+var noTools = ToolPermissions{Tools: []string{}, KB: kbNone}
+
+// sessionPermissions returns the profile and tool permissions of a session
+// or background job, waiting up to wait for it to be registered.
+func sessionPermissions(app *App, id string, wait time.Duration) (string, ToolPermissions, bool) {
+	deadline := time.Now().Add(wait)
+	for {
+		if sess := app.Sessions.get(id); sess != nil {
+			return sess.Profile, sess.Permissions, true
+		}
+		if job := app.Jobs.get(id); job != nil {
+			return job.Profile, job.Permissions, true
+		}
+		if id == "" || time.Now().After(deadline) {
+			return "", noTools, false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// restrictTools removes the tools a session may not call from its MCP
+// server, and logs the calls it makes to them anyway.
+func restrictTools(server *mcp.Server, sessionID, profile string, perms ToolPermissions) {
+	if denied := deniedTools(perms); len(denied) > 0 {
+		server.RemoveTools(denied...)
+	}
+	server.AddReceivingMiddleware(func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method == "tools/call" {
+				if params, ok := req.GetParams().(*mcp.CallToolParamsRaw); ok && !toolAllowed(perms, params.Name) {
+					slog.Warn("denied mcp tool call", "session", sessionID, "profile", profile, "tool", params.Name)
+					return &mcp.CallToolResult{
+						Content: []mcp.Content{
+							&mcp.TextContent{Text: fmt.Sprintf("The %s tool is not available to the %s profile.", params.Name, profile)},
+						},
+						IsError: true,
+					}, nil
+				}
+			}
+			return next(ctx, method, req)
+		}
+	})
+}
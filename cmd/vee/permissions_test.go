@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lthms/vee/internal/api"
+)
+
+func TestToolPermissionsAllows(t *testing.T) {
+	tests := []struct {
+		name   string
+		perms  ToolPermissions
+		denied []string
+	}{
+		{"default", ToolPermissions{}, nil},
+		{"kb write", ToolPermissions{KB: "write"}, nil},
+		{"kb read", ToolPermissions{KB: "read"}, []string{"kb_remember", "kb_touch"}},
+		{"kb none", ToolPermissions{KB: "none"}, []string{"kb_remember", "kb_query", "kb_touch"}},
+		{"listed tools", ToolPermissions{Tools: []string{"kb_query", "kb_touch"}}, []string{"request_suspend", "kb_remember", "feedback_record"}},
+		{"listed tools, kb read", ToolPermissions{Tools: []string{"kb_query", "kb_touch"}, KB: "read"}, []string{"request_suspend", "kb_remember", "kb_touch", "feedback_record"}},
+		{"no tools", ToolPermissions{Tools: []string{}}, veeMCPTools},
+	}
+	for _, tt := range tests {
+		if got := deniedTools(tt.perms); !slices.Equal(got, tt.denied) {
+			t.Errorf("%s: denied = %v, want %v", tt.name, got, tt.denied)
+		}
+	}
+}
+
+func TestSessionPermissions(t *testing.T) {
+	app := newApp()
+	readOnly := ToolPermissions{KB: "read"}
+
+	// The session registers after its MCP connection
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		app.Sessions.create("s1", "normal", "🦊", "", "@1", false, "", "", "", nil, nil, readOnly)
+	}()
+	profile, perms, ok := sessionPermissions(app, "s1", time.Second)
+	if !ok || profile != "normal" || perms.KB != "read" {
+		t.Errorf("session: got %q, %+v, %v", profile, perms, ok)
+	}
+
+	app.Jobs.run = func(context.Context, *Job) error { return nil }
+	app.Jobs.submit(&Job{Job: api.Job{ID: "j1", Profile: "implement", Permissions: ToolPermissions{Tools: []string{"kb_query"}}}})
+	if profile, perms, ok := sessionPermissions(app, "j1", 0); !ok || profile != "implement" || !slices.Equal(perms.Tools, []string{"kb_query"}) {
+		t.Errorf("job: got %q, %+v, %v", profile, perms, ok)
+	}
+
+	// Unknown sessions get no tools
+	if _, perms, ok := sessionPermissions(app, "ghost", 100*time.Millisecond); ok || len(deniedTools(perms)) != len(veeMCPTools) {
+		t.Errorf("unknown session: got %+v, %v", perms, ok)
+	}
+}
@@ -25,6 +25,7 @@ type pickerProfile struct {
 	desc              string
 	defaultPrompt     string
 	promptPlaceholder string
+	headless          bool
 }
 
 // pickerModel is the Bubble Tea model for the profile picker.
@@ -34,6 +35,7 @@ type pickerModel struct {
 	prompt       textarea.Model
 	ephemeral    bool
 	canEphemeral bool
+	job          bool
 	width        int
 	height       int
 
@@ -135,6 +137,7 @@ func (m pickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cursor--
 				m.updateScroll()
 				m.updatePlaceholder()
+				m.updateMode()
 			}
 			return m, nil
 
@@ -143,12 +146,24 @@ func (m pickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cursor++
 				m.updateScroll()
 				m.updatePlaceholder()
+				m.updateMode()
 			}
 			return m, nil
 
 		case tea.KeyCtrlE:
 			if m.canEphemeral {
 				m.ephemeral = !m.ephemeral
+				// Jobs run on the host only
+				if m.ephemeral {
+					m.job = false
+				}
+			}
+			return m, nil
+
+		case tea.KeyCtrlO:
+			m.job = !m.job
+			if m.job {
+				m.ephemeral = false
 			}
 			return m, nil
 		}
@@ -169,6 +184,14 @@ func (m *pickerModel) updatePlaceholder() {
 	m.prompt.Placeholder = cur.promptPlaceholder
 }
 
+// updateMode defaults to a background job for headless profiles.
+func (m *pickerModel) updateMode() {
+	m.job = m.profiles[m.cursor].headless
+	if m.job {
+		m.ephemeral = false
+	}
+}
+
 func (m pickerModel) promptHeight() int {
 	// Calculate height based on content, min 2, max 5
 	lines := strings.Count(m.prompt.Value(), "\n") + 1
@@ -196,11 +219,8 @@ func (m *pickerModel) updateScroll() {
 }
 
 func (m pickerModel) visibleProfileRows() int {
-	// Reserve space for: title (2 lines) + ephemeral (2) + prompt area (4) + help (2) + margins (2)
+	// Reserve space for: title (2 lines) + mode (2) + prompt area (4) + help (2) + margins (2)
 	overhead := 12
-	if !m.canEphemeral {
-		overhead -= 2
-	}
 	rows := m.height - overhead
 	if rows < 1 {
 		rows = 1
@@ -258,16 +278,22 @@ func (m pickerModel) View() string {
 
 	b.WriteString("\n")
 
-	// Ephemeral toggle
+	// Ephemeral and job toggles
+	b.WriteString("  ")
 	if m.canEphemeral {
-		b.WriteString("  ")
 		if m.ephemeral {
 			b.WriteString(ephemeralActiveStyle.Render("\u23e3 Ephemeral"))
 		} else {
 			b.WriteString(dimStyle.Render("\u23e3 Local"))
 		}
-		b.WriteString("\n")
+		b.WriteString("  ")
+	}
+	if m.job {
+		b.WriteString(ephemeralActiveStyle.Render("\u25c6 Background job"))
+	} else {
+		b.WriteString(dimStyle.Render("\u25c7 Interactive"))
 	}
+	b.WriteString("\n")
 
 	// Prompt input
 	cur := m.profiles[m.cursor]
@@ -297,9 +323,9 @@ func (m pickerModel) View() string {
 	// Help text
 	b.WriteString("\n  ")
 	if m.canEphemeral {
-		b.WriteString(helpStyle.Render("C-n/C-p select  C-e ephemeral  Enter confirm  Esc cancel"))
+		b.WriteString(helpStyle.Render("C-n/C-p select  C-e ephemeral  C-o job  Enter confirm  Esc cancel"))
 	} else {
-		b.WriteString(helpStyle.Render("C-n/C-p select  Enter confirm  Esc cancel"))
+		b.WriteString(helpStyle.Render("C-n/C-p select  C-o job  Enter confirm  Esc cancel"))
 	}
 	b.WriteString("\n")
 
@@ -324,6 +350,7 @@ func (cmd *SessionPickerCmd) Run(args claudeArgs) error {
 			desc:              profile.Description,
 			defaultPrompt:     profile.DefaultPrompt,
 			promptPlaceholder: profile.PromptPlaceholder,
+			headless:          profile.Headless,
 		})
 	}
 
@@ -331,6 +358,7 @@ func (cmd *SessionPickerCmd) Run(args claudeArgs) error {
 	// Set initial placeholder
 	if len(profiles) > 0 {
 		m.prompt.Placeholder = profiles[0].promptPlaceholder
+		m.updateMode()
 	}
 
 	p := tea.NewProgram(m, tea.WithAltScreen())
@@ -347,21 +375,17 @@ func (cmd *SessionPickerCmd) Run(args claudeArgs) error {
 	if result.confirmed {
 		// Normalize newlines to spaces for the prompt
 		promptText := strings.ReplaceAll(result.prompt.Value(), "\n", " ")
-		return cmd.createSession(result.profiles[result.cursor], promptText, result.ephemeral, result.args)
+		return cmd.createSession(result.profiles[result.cursor], promptText, result.ephemeral, result.job, result.args)
 	}
 
 	return nil
 }
 
-func (cmd *SessionPickerCmd) createSession(profile pickerProfile, prompt string, ephemeral bool, args claudeArgs) error {
+func (cmd *SessionPickerCmd) createSession(profile pickerProfile, prompt string, ephemeral, job bool, args claudeArgs) error {
 	// Template expansion: resolve the final prompt from default_prompt + user input.
-	if profile.defaultPrompt != "" {
-		if strings.Contains(profile.defaultPrompt, "{}") {
-			prompt = strings.Replace(profile.defaultPrompt, "{}", prompt, 1)
-		} else {
-			prompt = profile.defaultPrompt
-		}
-	}
+	// The raw input is passed along so later pipeline stages reuse it.
+	arg := prompt
+	prompt = expandPrompt(profile.defaultPrompt, arg)
 
 	veeBinary, err := os.Executable()
 	if err != nil {
@@ -378,9 +402,15 @@ func (cmd *SessionPickerCmd) createSession(profile pickerProfile, prompt string,
 	if prompt != "" {
 		cmdParts = append(cmdParts, "--prompt", prompt)
 	}
+	if arg != "" {
+		cmdParts = append(cmdParts, "--arg", arg)
+	}
 	if ephemeral {
 		cmdParts = append(cmdParts, "--ephemeral")
 	}
+	if job {
+		cmdParts = append(cmdParts, "--job")
+	}
 
 	if len(args) > 0 {
 		cmdParts = append(cmdParts, "--")
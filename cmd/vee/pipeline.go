@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+
+	"github.com/lthms/vee/internal/api"
+)
+
+// StartNextCmd is the internal subcommand that starts the next stage of a
+// pipeline once a session completes. It is run from the offer menu, or
+// directly by the daemon when pipelines start automatically.
+type StartNextCmd struct {
+	Port       int    `short:"p" default:"2700" name:"port"`
+	SessionID  string `required:"" name:"session-id" help:"Completed session whose next stage to start."`
+	TmuxSocket string `name:"tmux-socket" default:"vee" help:"Tmux socket name."`
+}
+
+// Run claims the next stage from the daemon and creates its window, with the
+// prompt argument of the completed session expanded by the next profile.
+func (cmd *StartNextCmd) Run() error {
+	tmuxSocketName = cmd.TmuxSocket
+
+	stage, err := daemonAPI(cmd.Port).NextStage(cmd.SessionID)
+	if api.IsStatus(err, http.StatusConflict) {
+		tmuxRun("display-message", "Next stage already started")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	appCfg, err := fetchAppConfig(cmd.Port)
+	if err != nil {
+		return fmt.Errorf("failed to fetch config from daemon: %w", err)
+	}
+	if err := initProfileRegistry(appCfg.VeePath); err != nil {
+		return fmt.Errorf("failed to init profile registry: %w", err)
+	}
+	profile, ok := profileRegistry[stage.Profile]
+	if !ok {
+		return fmt.Errorf("unknown profile: %s", stage.Profile)
+	}
+
+	veeBinary, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	cmdParts := []string{veeBinary, "_new-pane",
+		"--vee-path", appCfg.VeePath,
+		"--port", fmt.Sprintf("%d", cmd.Port),
+		"--tmux-socket", tmuxSocketName,
+		"--profile", profile.Name,
+		"--chain", stage.Chain,
+	}
+	if prompt := expandPrompt(profile.DefaultPrompt, stage.PromptArg); prompt != "" {
+		cmdParts = append(cmdParts, "--prompt", prompt)
+	}
+	if stage.PromptArg != "" {
+		cmdParts = append(cmdParts, "--arg", stage.PromptArg)
+	}
+	if profile.Headless {
+		cmdParts = append(cmdParts, "--job")
+	} else if stage.Ephemeral {
+		cmdParts = append(cmdParts, "--ephemeral")
+	}
+	if len(appCfg.Passthrough) > 0 {
+		cmdParts = append(cmdParts, "--")
+		cmdParts = append(cmdParts, appCfg.Passthrough...)
+	}
+
+	execCmd := exec.Command(cmdParts[0], cmdParts[1:]...)
+	execCmd.Stdout = os.Stdout
+	execCmd.Stderr = os.Stderr
+	return execCmd.Run()
+}
+
+// handlePipelineNext handles POST /api/pipeline/next?id=<id>. It claims the
+// next stage of a completed session and returns what to start, so that the
+// offer menu and automatic starts never launch the same stage twice.
+func handlePipelineNext(app *App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			http.Error(w, "missing id query parameter", http.StatusBadRequest)
+			return
+		}
+
+		sess, ok := app.Sessions.claimNext(id)
+		if !ok {
+			if app.Sessions.get(id) == nil {
+				http.Error(w, "session not found", http.StatusNotFound)
+			} else {
+				http.Error(w, "no next stage to start", http.StatusConflict)
+			}
+			return
+		}
+		slog.Debug("pipeline stage claimed", "session", id, "next", sess.Next, "chain", sess.Chain)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(api.NextStage{
+			Profile:   sess.Next,
+			PromptArg: sess.PromptArg,
+			Chain:     sess.Chain,
+			Ephemeral: sess.Ephemeral,
+		})
+	}
+}
+
+// offerNextStage is called when a session completes. If its profile has a
+// next stage, the stage is either started right away (pipeline.autostart) or
+// offered in a tmux menu. A dismissed offer stays available from the resume
+// menu.
+func offerNextStage(app *App, sess *Session) {
+	if sess.Next == "" {
+		return
+	}
+	cfg := app.Config()
+	if cfg == nil {
+		return
+	}
+
+	veeBinary, err := os.Executable()
+	if err != nil {
+		slog.Warn("pipeline: failed to resolve executable path", "error", err)
+		return
+	}
+	startArgs := []string{"_start-next", "--port", fmt.Sprintf("%d", cfg.Port), "--tmux-socket", configTmuxSocket(cfg), "--session-id", sess.ID}
+
+	if cfg.AutoNext {
+		slog.Debug("pipeline: starting next stage", "session", sess.ID, "next", sess.Next)
+		cmd := exec.Command(veeBinary, startArgs...)
+		cmd.Dir = cfg.ProjectDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			slog.Warn("pipeline: failed to start next stage", "session", sess.ID, "next", sess.Next, "error", err, "output", string(out))
+		}
+		return
+	}
+
+	startCmd := shelljoin(veeBinary)
+	for _, a := range startArgs {
+		startCmd += " " + shelljoin(a)
+	}
+	if _, err := tmuxRunOn(configTmuxSocket(cfg), "display-menu", "-T", "Pipeline",
+		nextStageLabel(sess, app.Profiles()), "y", "run-shell "+shelljoin(startCmd),
+		"Not now", "n", "",
+	); err != nil {
+		slog.Debug("pipeline: failed to show offer menu", "session", sess.ID, "error", err)
+	}
+}
+
+// nextStageLabel describes the next stage of a completed session in menus,
+// with its profile among profiles.
+func nextStageLabel(sess *Session, profiles map[string]Profile) string {
+	label := "⏭ " + sess.Next
+	if p, ok := profiles[sess.Next]; ok {
+		label = fmt.Sprintf("⏭ %s %s", p.Indicator, p.Name)
+	}
+	if sess.PromptArg != "" {
+		label += "  " + truncateRunes(firstLine(sess.PromptArg), 40)
+	}
+	return label
+}
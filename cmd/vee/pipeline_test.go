@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestSplitPipelines(t *testing.T) {
+	t0 := time.Now()
+	issue := &Session{ID: "a", Profile: "issue", Status: "completed", Chain: "42", Next: "design", NextStarted: true, StartedAt: t0}
+	design := &Session{ID: "b", Profile: "design", Status: "completed", Chain: "42", Next: "plan", StartedAt: t0.Add(time.Minute)}
+	other := &Session{ID: "c", Profile: "design", Status: "active", Chain: "7", Next: "plan", StartedAt: t0.Add(2 * time.Minute)}
+	vibe := &Session{ID: "d", Profile: "vibe", Status: "active", StartedAt: t0}
+
+	state := &dashboardState{
+		Active:    []*Session{other, vibe},
+		Completed: []*Session{design, issue},
+	}
+	pipelines := splitPipelines(state)
+
+	if len(state.Active) != 1 || state.Active[0] != vibe || len(state.Completed) != 0 {
+		t.Fatalf("expected only unchained sessions left in sections, got %+v", state)
+	}
+	if len(pipelines) != 2 || pipelines[0].Chain != "42" || pipelines[1].Chain != "7" {
+		t.Fatalf("expected pipelines 42 then 7, got %+v", pipelines)
+	}
+	if s := pipelines[0].Sessions; len(s) != 2 || s[0] != issue || s[1] != design {
+		t.Fatalf("expected issue then design, got %+v", s)
+	}
+	if got := pipelines[0].pending(); got != "plan" {
+		t.Errorf("pending = %q, want plan", got)
+	}
+	if got := pipelines[1].pending(); got != "" {
+		t.Errorf("pending of an active stage = %q, want none", got)
+	}
+}
+
+func TestClaimNextOnce(t *testing.T) {
+	store := newSessionStore()
+	store.create("s1", "design", "🎨", "", "@1", false, "", "", "", nil, nil, ToolPermissions{})
+	store.setPipeline("s1", "42", "plan", "42")
+
+	if _, ok := store.claimNext("s1"); ok {
+		t.Fatal("expected no claim while the session is active")
+	}
+	store.setStatus("s1", "completed")
+	sess, ok := store.claimNext("s1")
+	if !ok || sess.Next != "plan" || sess.PromptArg != "42" {
+		t.Fatalf("expected to claim plan for 42, got %+v, %v", sess, ok)
+	}
+	if _, ok := store.claimNext("s1"); ok {
+		t.Fatal("expected the next stage to be claimed only once")
+	}
+}
+
+func TestNextStageLabelTruncatesByRune(t *testing.T) {
+	sess := &Session{Next: "review", PromptArg: strings.Repeat("é", 50) + "\nsecond line"}
+	label := nextStageLabel(sess, map[string]Profile{"review": {Name: "review", Indicator: "🔍"}})
+	if !utf8.ValidString(label) || strings.Contains(label, "second") {
+		t.Fatalf("unexpected label %q", label)
+	}
+	if want := "⏭ 🔍 review  " + strings.Repeat("é", 39) + "…"; label != want {
+		t.Fatalf("got %q, expected %q", label, want)
+	}
+}
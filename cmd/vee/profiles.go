@@ -14,11 +14,16 @@ import (
 
 // ProfileFrontmatter is the YAML frontmatter target for profile files.
 type ProfileFrontmatter struct {
-	Indicator         string `yaml:"indicator"`
-	Description       string `yaml:"description"`
-	Priority          *int   `yaml:"priority"`
-	DefaultPrompt     string `yaml:"default_prompt"`
-	PromptPlaceholder string `yaml:"prompt_placeholder"`
+	Indicator         string   `yaml:"indicator"`
+	Description       string   `yaml:"description"`
+	Priority          *int     `yaml:"priority"`
+	DefaultPrompt     string   `yaml:"default_prompt"`
+	PromptPlaceholder string   `yaml:"prompt_placeholder"`
+	Groups            []string `yaml:"groups"`
+	Next              string   `yaml:"next"`
+	Headless          bool     `yaml:"headless"`
+	Tools             []string `yaml:"tools"`
+	KB                string   `yaml:"kb"`
 }
 
 // parseProfileFile splits a profile file into frontmatter and body, parses the
@@ -47,6 +52,11 @@ func parseProfileFile(filename string, content []byte) (Profile, error) {
 		return Profile{}, fmt.Errorf("%s: bad frontmatter: %w", filename, err)
 	}
 
+	perms := ToolPermissions{Tools: fm.Tools, KB: fm.KB}
+	if err := validatePermissions(perms); err != nil {
+		return Profile{}, fmt.Errorf("%s: %w", filename, err)
+	}
+
 	priority := math.MaxInt
 	if fm.Priority != nil {
 		priority = *fm.Priority
@@ -60,9 +70,27 @@ func parseProfileFile(filename string, content []byte) (Profile, error) {
 		Prompt:            string(body),
 		DefaultPrompt:     fm.DefaultPrompt,
 		PromptPlaceholder: fm.PromptPlaceholder,
+		Groups:            fm.Groups,
+		Next:              fm.Next,
+		Headless:          fm.Headless,
+		Permissions:       perms,
 	}, nil
 }
 
+// expandPrompt resolves a profile's initial prompt from its default_prompt
+// template and the argument given by the user: "{}" is replaced by the
+// argument, a template without "{}" is used as is, and without a template the
+// argument is the prompt.
+func expandPrompt(defaultPrompt, arg string) string {
+	if defaultPrompt == "" {
+		return arg
+	}
+	if strings.Contains(defaultPrompt, "{}") {
+		return strings.Replace(defaultPrompt, "{}", arg, 1)
+	}
+	return defaultPrompt
+}
+
 // wrapProfileBody wraps a profile body in XML tags for system prompt composition.
 // It prepends a rule explaining the script's role, so the rule is only
 // present when there is actually a script to follow.
@@ -105,14 +133,25 @@ func loadProfilesFromDir(dir string) ([]Profile, error) {
 // initProfileRegistry loads profiles from the filesystem and rebuilds profileRegistry
 // and profileOrder. It is called fresh on every invocation (picker open,
 // new-pane, resume) so edits to profile files take effect without restarting.
+func initProfileRegistry(veePath string) error {
+	registry, order, err := loadProfiles(veePath)
+	if err != nil {
+		return err
+	}
+	profileRegistry, profileOrder = registry, order
+	return nil
+}
+
+// loadProfiles loads profiles from the filesystem, and returns them by name
+// along with their display order.
 //
 // Profiles are merged from two directories:
 //  1. veePath/profiles/ — installed defaults
 //  2. ~/.config/vee/profiles/ — user overrides (same name wins, new names added)
-func initProfileRegistry(veePath string) error {
+func loadProfiles(veePath string) (map[string]Profile, []string, error) {
 	basePrompt, err := promptFS.ReadFile("prompts/base.md")
 	if err != nil {
-		return fmt.Errorf("read base prompt: %w", err)
+		return nil, nil, fmt.Errorf("read base prompt: %w", err)
 	}
 
 	// Start with installed defaults.
@@ -136,7 +175,7 @@ func initProfileRegistry(veePath string) error {
 	}
 
 	if len(byName) == 0 {
-		return fmt.Errorf("no profile files found in %s or ~/.config/vee/profiles/", installedDir)
+		return nil, nil, fmt.Errorf("no profile files found in %s or ~/.config/vee/profiles/", installedDir)
 	}
 
 	// Compose prompts and collect into a slice for sorting.
@@ -150,7 +189,7 @@ func initProfileRegistry(veePath string) error {
 		profiles = append(profiles, m)
 	}
 
-	// Build profileOrder sorted by priority (ascending), then alphabetically.
+	// Build the order sorted by priority (ascending), then alphabetically.
 	sort.Slice(profiles, func(i, j int) bool {
 		if profiles[i].Priority != profiles[j].Priority {
 			return profiles[i].Priority < profiles[j].Priority
@@ -158,12 +197,12 @@ func initProfileRegistry(veePath string) error {
 		return profiles[i].Name < profiles[j].Name
 	})
 
-	profileRegistry = make(map[string]Profile, len(profiles))
-	profileOrder = make([]string, len(profiles))
+	registry := make(map[string]Profile, len(profiles))
+	order := make([]string, len(profiles))
 	for i, m := range profiles {
-		profileRegistry[m.Name] = m
-		profileOrder[i] = m.Name
+		registry[m.Name] = m
+		order[i] = m.Name
 	}
 
-	return nil
+	return registry, order, nil
 }
@@ -4,6 +4,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -82,6 +83,76 @@ Body text.`,
 				Prompt:      "Body text.",
 			},
 		},
+		{
+			name:     "profile with groups",
+			filename: "vibe.md",
+			content: `---
+indicator: "⚡"
+description: "Tasks"
+priority: 20
+groups: [coding, writes]
+---
+Do things.`,
+			wantProfile: Profile{
+				Name:        "vibe",
+				Indicator:   "⚡",
+				Description: "Tasks",
+				Priority:    20,
+				Prompt:      "Do things.",
+				Groups:      []string{"coding", "writes"},
+			},
+		},
+		{
+			name:     "profile with pipeline fields",
+			filename: "design.md",
+			content: `---
+indicator: "🎨"
+description: "Design"
+default_prompt: "Design for issue {}"
+next: plan
+headless: true
+---
+Design things.`,
+			wantProfile: Profile{
+				Name:          "design",
+				Indicator:     "🎨",
+				Description:   "Design",
+				Priority:      math.MaxInt,
+				Prompt:        "Design things.",
+				DefaultPrompt: "Design for issue {}",
+				Next:          "plan",
+				Headless:      true,
+			},
+		},
+		{
+			name:     "profile with tool permissions",
+			filename: "review.md",
+			content: `---
+indicator: "🔍"
+tools: [kb_query, request_suspend]
+kb: read
+---
+Review things.`,
+			wantProfile: Profile{
+				Name:        "review",
+				Indicator:   "🔍",
+				Priority:    math.MaxInt,
+				Prompt:      "Review things.",
+				Permissions: ToolPermissions{Tools: []string{"kb_query", "request_suspend"}, KB: "read"},
+			},
+		},
+		{
+			name:     "unknown tool",
+			filename: "bad.md",
+			content:  "---\ntools: [kb_forget]\n---\nBody.",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown kb access",
+			filename: "bad.md",
+			content:  "---\nkb: readonly\n---\nBody.",
+			wantErr:  true,
+		},
 		{
 			name:     "missing frontmatter",
 			filename: "bad.md",
@@ -124,6 +195,18 @@ Body text.`,
 			if profile.Prompt != tt.wantProfile.Prompt {
 				t.Errorf("Prompt = %q, want %q", profile.Prompt, tt.wantProfile.Prompt)
 			}
+			if profile.Headless != tt.wantProfile.Headless {
+				t.Errorf("Headless = %v, want %v", profile.Headless, tt.wantProfile.Headless)
+			}
+			if profile.Next != tt.wantProfile.Next {
+				t.Errorf("Next = %q, want %q", profile.Next, tt.wantProfile.Next)
+			}
+			if !slices.Equal(profile.Groups, tt.wantProfile.Groups) {
+				t.Errorf("Groups = %v, want %v", profile.Groups, tt.wantProfile.Groups)
+			}
+			if !slices.Equal(profile.Permissions.Tools, tt.wantProfile.Permissions.Tools) || profile.Permissions.KB != tt.wantProfile.Permissions.KB {
+				t.Errorf("Permissions = %+v, want %+v", profile.Permissions, tt.wantProfile.Permissions)
+			}
 		})
 	}
 }
@@ -380,3 +463,18 @@ Custom profile body.`), 0644)
 		}
 	}
 }
+
+func TestExpandPrompt(t *testing.T) {
+	tests := []struct {
+		template, arg, want string
+	}{
+		{"", "fix the parser", "fix the parser"},
+		{"Design for issue {}", "42", "Design for issue 42"},
+		{"Review the open PRs", "ignored", "Review the open PRs"},
+	}
+	for _, tt := range tests {
+		if got := expandPrompt(tt.template, tt.arg); got != tt.want {
+			t.Errorf("expandPrompt(%q, %q) = %q, want %q", tt.template, tt.arg, got, tt.want)
+		}
+	}
+}
@@ -1,15 +1,16 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"net/url"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/lthms/vee/internal/api"
+	"github.com/lthms/vee/internal/feedback"
 )
 
 // PromptViewerCmd is the internal subcommand that displays the system prompt
@@ -21,15 +22,28 @@ type PromptViewerCmd struct {
 
 // promptResult holds the fetched prompt data or an error message.
 type promptResult struct {
+	sessionID    string
 	profile      string
 	indicator    string
 	systemPrompt string
+	feedback     []feedback.RankedEntry // examples injected into the prompt
+	outcome      string                 // "good", "bad", or "" if unmarked
 	errorMsg     string
 }
 
+// outcomeMsg reports the result of marking the session's outcome.
+type outcomeMsg struct {
+	outcome string
+	err     error
+}
+
 // promptViewerModel is the Bubble Tea model for the prompt viewer.
 type promptViewerModel struct {
 	viewport   viewport.Model
+	port       int
+	sessionID  string
+	outcome    string
+	status     string // transient status shown in the footer
 	profile    string
 	indicator  string
 	rawContent string
@@ -67,15 +81,41 @@ var (
 )
 
 func initialPromptViewerModel(result promptResult) promptViewerModel {
+	content := result.systemPrompt
+	if len(result.feedback) > 0 {
+		content += "\n\n" + activeFeedbackSection(result.feedback)
+	}
 	return promptViewerModel{
+		sessionID:  result.sessionID,
+		outcome:    result.outcome,
 		profile:    result.profile,
 		indicator:  result.indicator,
-		rawContent: result.systemPrompt,
+		rawContent: content,
 		errorMsg:   result.errorMsg,
 		ready:      false,
 	}
 }
 
+// activeFeedbackSection renders the feedback examples injected into the
+// session as a Markdown section, with their usage statistics.
+func activeFeedbackSection(entries []feedback.RankedEntry) string {
+	var sb strings.Builder
+	sb.WriteString("# Active feedback examples\n")
+	for _, e := range entries {
+		id := e.ID
+		if len(id) > 8 {
+			id = id[:8]
+		}
+		pinned := ""
+		if e.Pinned {
+			pinned = ", pinned"
+		}
+		sb.WriteString(fmt.Sprintf("\n- `%s` **%s**%s — %s (used %d×, %d good / %d bad sessions)",
+			id, e.Kind, pinned, firstLine(e.Statement), e.Uses, e.GoodSessions, e.BadSessions))
+	}
+	return sb.String()
+}
+
 func (m promptViewerModel) Init() tea.Cmd {
 	return nil
 }
@@ -118,6 +158,15 @@ func (m promptViewerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleSearchInput(msg)
 		}
 		return m.handleNormalInput(msg)
+
+	case outcomeMsg:
+		if msg.err != nil {
+			m.status = "failed to mark outcome: " + msg.err.Error()
+			return m, nil
+		}
+		m.outcome = msg.outcome
+		m.status = ""
+		return m, nil
 	}
 
 	return m, nil
@@ -198,6 +247,12 @@ func (m promptViewerModel) handleNormalInput(msg tea.KeyMsg) (tea.Model, tea.Cmd
 			case 'j', 'k', 'd', 'u':
 				// Swallow vi scroll bindings
 				return m, nil
+			case '+':
+				return m, m.markOutcome("good")
+			case '-':
+				return m, m.markOutcome("bad")
+			case '0':
+				return m, m.markOutcome("")
 			case '/':
 				m.searching = true
 				m.filter = ""
@@ -231,6 +286,21 @@ func (m promptViewerModel) handleNormalInput(msg tea.KeyMsg) (tea.Model, tea.Cmd
 	return m, nil
 }
 
+// markOutcome returns a command recording the session's outcome with the
+// daemon, used to rank feedback examples by how their sessions turned out.
+func (m promptViewerModel) markOutcome(outcome string) tea.Cmd {
+	if m.sessionID == "" {
+		return nil
+	}
+	port, sessionID := m.port, m.sessionID
+	return func() tea.Msg {
+		if err := daemonAPI(port).SetOutcome(sessionID, outcome); err != nil {
+			return outcomeMsg{err: err}
+		}
+		return outcomeMsg{outcome: outcome}
+	}
+}
+
 func (m *promptViewerModel) updateMatches() {
 	m.matches = nil
 	if m.filter == "" {
@@ -324,6 +394,9 @@ func (m promptViewerModel) View() string {
 
 	// Header
 	title := fmt.Sprintf(" %s %s — System Prompt", m.indicator, m.profile)
+	if m.outcome != "" {
+		title += pvHelpStyle.Render(" · marked " + m.outcome)
+	}
 	if m.filter != "" && len(m.matches) > 0 {
 		title += pvSearchStyle.Render(fmt.Sprintf(" [%s] ", m.filter)) +
 			pvHelpStyle.Render(fmt.Sprintf("%d/%d", m.matchIdx+1, len(m.matches)))
@@ -357,7 +430,11 @@ func (m promptViewerModel) renderFooter() string {
 		position = fmt.Sprintf(" %.0f%%", pct)
 	}
 
-	help := "↑/↓ scroll  g/G top/bottom  / search  q quit"
+	if m.status != "" {
+		return " " + pvErrorStyle.Render(m.status)
+	}
+
+	help := "↑/↓ scroll  g/G top/bottom  / search  +/-/0 mark good/bad/clear  q quit"
 	if m.filter != "" {
 		help = "n/N match  Esc clear  " + help
 	}
@@ -384,33 +461,23 @@ func (m promptViewerModel) viewError() string {
 func (cmd *PromptViewerCmd) Run() error {
 	result := cmd.fetchPrompt()
 	m := initialPromptViewerModel(result)
+	m.port = cmd.Port
 	p := tea.NewProgram(m, tea.WithAltScreen())
 	_, err := p.Run()
 	return err
 }
 
 func (cmd *PromptViewerCmd) fetchPrompt() promptResult {
-	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/api/session/prompt?window=%s",
-		cmd.Port, url.QueryEscape(cmd.WindowID)))
-	if err != nil {
-		return promptResult{errorMsg: "Could not reach the daemon."}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusNotFound {
+	result, err := daemonAPI(cmd.Port).SessionPrompt(cmd.WindowID)
+	if api.IsStatus(err, http.StatusNotFound) {
 		return promptResult{errorMsg: "No session in this window."}
 	}
-	if resp.StatusCode != http.StatusOK {
-		return promptResult{errorMsg: fmt.Sprintf("Daemon returned %d.", resp.StatusCode)}
+	var se *api.StatusError
+	if errors.As(err, &se) {
+		return promptResult{errorMsg: fmt.Sprintf("Daemon returned %d.", se.Code)}
 	}
-
-	var result struct {
-		Profile      string `json:"profile"`
-		Indicator    string `json:"indicator"`
-		SystemPrompt string `json:"system_prompt"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return promptResult{errorMsg: "Failed to decode response."}
+	if err != nil {
+		return promptResult{errorMsg: "Could not reach the daemon."}
 	}
 
 	if result.SystemPrompt == "" {
@@ -418,8 +485,11 @@ func (cmd *PromptViewerCmd) fetchPrompt() promptResult {
 	}
 
 	return promptResult{
+		sessionID:    result.SessionID,
 		profile:      result.Profile,
 		indicator:    result.Indicator,
 		systemPrompt: result.SystemPrompt,
+		feedback:     result.Feedback,
+		outcome:      result.Outcome,
 	}
 }
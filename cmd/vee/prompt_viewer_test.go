@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lthms/vee/internal/feedback"
 )
 
 func TestParseHeading(t *testing.T) {
@@ -289,6 +290,41 @@ func TestPromptViewerModelInit(t *testing.T) {
 	}
 }
 
+func TestPromptViewerModelActiveFeedback(t *testing.T) {
+	result := promptResult{
+		sessionID:    "sess-1",
+		profile:      "normal",
+		systemPrompt: "Test prompt content",
+		outcome:      "good",
+		feedback: []feedback.RankedEntry{
+			{
+				Entry:        feedback.Entry{ID: "0123456789abcdef", Kind: "bad", Statement: "No emojis\nin commits", Pinned: true},
+				Uses:         3,
+				GoodSessions: 2,
+				BadSessions:  1,
+			},
+		},
+	}
+
+	m := initialPromptViewerModel(result)
+
+	if !strings.HasPrefix(m.rawContent, "Test prompt content\n\n# Active feedback examples") {
+		t.Fatalf("expected active feedback section after the prompt, got %q", m.rawContent)
+	}
+	if !strings.Contains(m.rawContent, "`01234567` **bad**, pinned — No emojis (used 3×, 2 good / 1 bad sessions)") {
+		t.Errorf("unexpected feedback line in %q", m.rawContent)
+	}
+	if m.outcome != "good" || m.sessionID != "sess-1" {
+		t.Errorf("outcome/session not carried over: %q %q", m.outcome, m.sessionID)
+	}
+
+	// Marking an outcome updates the title once the daemon confirms.
+	updated, _ := m.Update(outcomeMsg{outcome: "bad"})
+	if got := updated.(promptViewerModel).outcome; got != "bad" {
+		t.Errorf("outcome = %q, want %q", got, "bad")
+	}
+}
+
 func TestPromptViewerModelWithError(t *testing.T) {
 	result := promptResult{
 		errorMsg: "Could not reach the daemon.",
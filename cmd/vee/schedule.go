@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lthms/vee/internal/api"
+	"github.com/lthms/vee/internal/cron"
+)
+
+// scheduleTickInterval is how often the scheduler looks for due schedules.
+const scheduleTickInterval = 20 * time.Second
+
+// ScheduleStatus is the state of a schedule, as shown on the dashboard.
+type ScheduleStatus = api.ScheduleStatus
+
+type scheduleEntry struct {
+	ScheduleStatus
+	cron *cron.Schedule
+}
+
+// scheduler starts the sessions of [schedule "name"] sections when their
+// cron expression fires. The configuration is re-read on every tick, so
+// edits to .vee/config apply without a restart.
+type scheduler struct {
+	mu      sync.Mutex
+	entries map[string]*scheduleEntry
+	order   []string
+
+	load    func() []ScheduleConfig
+	running func(name string) bool
+	launch  func(ScheduleConfig) error
+	events  *eventBus
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{
+		entries: make(map[string]*scheduleEntry),
+	}
+}
+
+// sync replaces the known schedules with cfgs. Schedules whose expression
+// is unchanged keep their next run time.
+func (s *scheduler) sync(cfgs []ScheduleConfig, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make(map[string]*scheduleEntry, len(cfgs))
+	order := make([]string, 0, len(cfgs))
+	for _, c := range cfgs {
+		e, ok := s.entries[c.Name]
+		if !ok || e.Cron != c.Cron {
+			e = &scheduleEntry{}
+			if sched, err := cron.Parse(c.Cron); err != nil {
+				slog.Warn("invalid schedule", "schedule", c.Name, "cron", c.Cron, "error", err)
+				e.Error = err.Error()
+			} else {
+				e.cron = sched
+				e.NextRun = sched.Next(now)
+			}
+			if ok {
+				e.LastRun, e.LastResult = s.entries[c.Name].LastRun, s.entries[c.Name].LastResult
+			}
+		}
+		e.Name, e.Cron, e.Profile, e.Prompt, e.Ephemeral = c.Name, c.Cron, c.Profile, c.Prompt, c.Ephemeral
+		entries[c.Name] = e
+		order = append(order, c.Name)
+	}
+	s.entries = entries
+	s.order = order
+}
+
+// due returns the schedules whose next run has come, and moves their next
+// run forward. A run missed while the daemon was down is not caught up.
+func (s *scheduler) due(now time.Time) []ScheduleConfig {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []ScheduleConfig
+	for _, name := range s.order {
+		e := s.entries[name]
+		if e.cron == nil || e.NextRun.IsZero() || now.Before(e.NextRun) {
+			continue
+		}
+		e.NextRun = e.cron.Next(now)
+		due = append(due, ScheduleConfig{Name: e.Name, Cron: e.Cron, Profile: e.Profile, Prompt: e.Prompt, Ephemeral: e.Ephemeral})
+	}
+	return due
+}
+
+func (s *scheduler) record(name, result string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[name]; ok {
+		e.LastRun = at
+		e.LastResult = result
+	}
+}
+
+// tick re-reads the schedules and starts the ones that are due, skipping a
+// schedule whose previous run is still going. Schedules that changed are
+// published as a schedules event.
+func (s *scheduler) tick(now time.Time) {
+	before := s.list()
+	defer func() {
+		if after := s.list(); !slices.Equal(before, after) {
+			s.events.publish(evSchedules, map[string]any{"schedules": after})
+		}
+	}()
+
+	s.sync(s.load(), now)
+	for _, c := range s.due(now) {
+		if s.running(c.Name) {
+			slog.Info("schedule skipped, previous run still active", "schedule", c.Name)
+			s.record(c.Name, "skipped", now)
+			continue
+		}
+		slog.Info("starting scheduled session", "schedule", c.Name, "profile", c.Profile)
+		if err := s.launch(c); err != nil {
+			slog.Warn("failed to start scheduled session", "schedule", c.Name, "error", err)
+			s.record(c.Name, "failed", now)
+			continue
+		}
+		s.record(c.Name, "started", now)
+	}
+}
+
+// list returns the state of every schedule, in configuration order.
+func (s *scheduler) list() []ScheduleStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]ScheduleStatus, 0, len(s.order))
+	for _, name := range s.order {
+		result = append(result, s.entries[name].ScheduleStatus)
+	}
+	return result
+}
+
+// run ticks until ctx is cancelled.
+func (s *scheduler) run(ctx context.Context) {
+	s.tick(time.Now())
+	ticker := time.NewTicker(scheduleTickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.tick(now)
+		}
+	}
+}
+
+// startScheduler runs the project's schedules for the lifetime of ctx.
+// Scheduled sessions go through _new-pane, like sessions started from the
+// picker; headless profiles are queued as background jobs.
+func startScheduler(ctx context.Context, app *App) {
+	s := app.Schedules
+	s.load = func() []ScheduleConfig {
+		cfg, err := readProjectTOMLIn(app.projectDir())
+		if err != nil {
+			return nil
+		}
+		return cfg.Schedules
+	}
+	s.running = func(name string) bool {
+		return app.Sessions.scheduleActive(name) || app.Jobs.scheduleActive(name)
+	}
+	s.launch = func(c ScheduleConfig) error {
+		return launchScheduled(app.Config(), app.Profiles(), c)
+	}
+	go s.run(ctx)
+}
+
+// launchScheduled starts the session of a schedule, with its profile among
+// profiles.
+func launchScheduled(cfg *AppConfig, profiles map[string]Profile, c ScheduleConfig) error {
+	if cfg == nil {
+		return fmt.Errorf("config not set")
+	}
+	profile, ok := profiles[c.Profile]
+	if !ok {
+		return fmt.Errorf("unknown profile: %s", c.Profile)
+	}
+
+	veeBinary, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	cmdParts := []string{veeBinary, "_new-pane",
+		"--vee-path", cfg.VeePath,
+		"--port", fmt.Sprintf("%d", cfg.Port),
+		"--tmux-socket", configTmuxSocket(cfg),
+		"--profile", profile.Name,
+		"--schedule", c.Name,
+	}
+	if prompt := expandPrompt(profile.DefaultPrompt, c.Prompt); prompt != "" {
+		cmdParts = append(cmdParts, "--prompt", prompt)
+	}
+	if c.Prompt != "" {
+		cmdParts = append(cmdParts, "--arg", c.Prompt)
+	}
+	if profile.Headless {
+		cmdParts = append(cmdParts, "--job")
+	} else if c.Ephemeral {
+		cmdParts = append(cmdParts, "--ephemeral")
+	}
+	if len(cfg.Passthrough) > 0 {
+		cmdParts = append(cmdParts, "--")
+		cmdParts = append(cmdParts, cfg.Passthrough...)
+	}
+
+	cmd := exec.Command(cmdParts[0], cmdParts[1:]...)
+	cmd.Dir = cfg.ProjectDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, out)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedulerTick(t *testing.T) {
+	start := time.Date(2025, 3, 5, 1, 59, 30, 0, time.UTC)
+	cfgs := []ScheduleConfig{
+		{Name: "triage", Cron: "0 2 * * *", Profile: "issue", Prompt: "Triage new issues"},
+		{Name: "broken", Cron: "every night", Profile: "vibe"},
+	}
+
+	var launched []string
+	active := false
+	s := newScheduler()
+	s.load = func() []ScheduleConfig { return cfgs }
+	s.running = func(name string) bool { return active }
+	s.launch = func(c ScheduleConfig) error {
+		launched = append(launched, c.Name)
+		return nil
+	}
+
+	s.tick(start)
+	list := s.list()
+	if len(list) != 2 || list[1].Error == "" {
+		t.Fatalf("expected both schedules, the second one invalid, got %+v", list)
+	}
+	if want := time.Date(2025, 3, 5, 2, 0, 0, 0, time.UTC); !list[0].NextRun.Equal(want) {
+		t.Fatalf("expected next run at %v, got %v", want, list[0].NextRun)
+	}
+	if len(launched) != 0 {
+		t.Fatalf("expected nothing launched before 02:00, got %v", launched)
+	}
+
+	s.tick(start.Add(time.Minute))
+	if len(launched) != 1 || launched[0] != "triage" {
+		t.Fatalf("expected triage launched at 02:00, got %v", launched)
+	}
+	if got := s.list()[0]; got.LastResult != "started" || !got.NextRun.Equal(time.Date(2025, 3, 6, 2, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected a started run and the next one tomorrow, got %+v", got)
+	}
+
+	// The previous run is still going the next night
+	active = true
+	s.tick(time.Date(2025, 3, 6, 2, 0, 10, 0, time.UTC))
+	if len(launched) != 1 {
+		t.Fatalf("expected the run to be skipped, got %v", launched)
+	}
+	if got := s.list()[0].LastResult; got != "skipped" {
+		t.Fatalf("expected last result skipped, got %q", got)
+	}
+
+	// Removing a schedule from the config drops it
+	cfgs = cfgs[:1]
+	s.tick(time.Date(2025, 3, 6, 3, 0, 0, 0, time.UTC))
+	if list := s.list(); len(list) != 1 || list[0].LastResult != "skipped" {
+		t.Fatalf("expected one schedule keeping its history, got %+v", list)
+	}
+}
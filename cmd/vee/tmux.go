@@ -27,7 +27,13 @@ func veeRuntimeDir() string {
 
 // tmuxSocketPath returns the full path to the tmux socket file.
 func tmuxSocketPath() string {
-	return filepath.Join(veeRuntimeDir(), tmuxSocketName)
+	return tmuxSocketPathFor(tmuxSocketName)
+}
+
+// tmuxSocketPathFor returns the full path to the tmux socket of the instance
+// named socket.
+func tmuxSocketPathFor(socket string) string {
+	return filepath.Join(veeRuntimeDir(), socket)
 }
 
 // ensureRuntimeDir creates the vee runtime directory if it doesn't exist.
@@ -37,13 +43,24 @@ func ensureRuntimeDir() error {
 
 // tmuxCmd builds an exec.Cmd for tmux using the instance socket path.
 func tmuxCmd(args ...string) *exec.Cmd {
-	return exec.Command("tmux", append([]string{"-S", tmuxSocketPath()}, args...)...)
+	return tmuxCmdOn(tmuxSocketName, args...)
+}
+
+// tmuxCmdOn builds an exec.Cmd for the tmux server of the instance named
+// socket. The daemon uses it, as it may serve several instances.
+func tmuxCmdOn(socket string, args ...string) *exec.Cmd {
+	return exec.Command("tmux", append([]string{"-S", tmuxSocketPathFor(socket)}, args...)...)
 }
 
 // tmuxRun executes a tmux command and returns its combined output.
 func tmuxRun(args ...string) (string, error) {
-	cmd := tmuxCmd(args...)
-	out, err := cmd.CombinedOutput()
+	return tmuxRunOn(tmuxSocketName, args...)
+}
+
+// tmuxRunOn executes a tmux command on the tmux server of the instance
+// named socket and returns its combined output.
+func tmuxRunOn(socket string, args ...string) (string, error) {
+	out, err := tmuxCmdOn(socket, args...).CombinedOutput()
 	return strings.TrimSpace(string(out)), err
 }
 
@@ -81,34 +98,29 @@ func tmuxKillWindow(target string) error {
 	return err
 }
 
-// tmuxKillWindowByID kills a tmux window by its stable window ID (e.g. "@3").
-func tmuxKillWindowByID(windowID string) error {
-	_, err := tmuxRun("kill-window", "-t", windowID)
-	return err
-}
-
 // tmuxGracefulClose gracefully terminates a Claude process in a tmux window
 // by sending Ctrl-C (to interrupt any ongoing work) then /exit (to trigger
 // a clean shutdown that persists the session index). Falls back to killing
 // the window after a timeout. Blocks for several seconds — call from a goroutine.
-func tmuxGracefulClose(windowID string) {
+// socket names the tmux server of the window's instance.
+func tmuxGracefulClose(socket, windowID string) {
 	// Move window to a hidden background session so it disappears
 	// from the status bar and Ctrl-b n/p navigation immediately
 	bgName := tmuxSessionName + "-bg"
-	if cmd := tmuxCmd("has-session", "-t", bgName); cmd.Run() != nil {
-		tmuxRun("new-session", "-d", "-s", bgName)
+	if cmd := tmuxCmdOn(socket, "has-session", "-t", bgName); cmd.Run() != nil {
+		tmuxRunOn(socket, "new-session", "-d", "-s", bgName)
 	}
-	tmuxRun("move-window", "-s", windowID, "-t", bgName+":")
-	tmuxRun("select-window", "-t", tmuxSessionName+":0")
+	tmuxRunOn(socket, "move-window", "-s", windowID, "-t", bgName+":")
+	tmuxRunOn(socket, "select-window", "-t", tmuxSessionName+":0")
 
 	// Send /exit to the (now hidden) window — window IDs are global
-	tmuxRun("send-keys", "-t", windowID, "-l", "/exit")
+	tmuxRunOn(socket, "send-keys", "-t", windowID, "-l", "/exit")
 	time.Sleep(100 * time.Millisecond)
-	tmuxRun("send-keys", "-t", windowID, "Enter")
+	tmuxRunOn(socket, "send-keys", "-t", windowID, "Enter")
 	// Give Claude time to persist session data
 	time.Sleep(10 * time.Second)
 	// Fallback if window is still alive
-	tmuxKillWindowByID(windowID)
+	tmuxRunOn(socket, "kill-window", "-t", windowID)
 }
 
 // tmuxSetWindowOption sets a per-window user option (@-prefixed) on a tmux window.
@@ -117,40 +129,24 @@ func tmuxSetWindowOption(windowID, key, value string) error {
 	return err
 }
 
-// tmuxUnsetWindowOption removes a per-window user option from a tmux window.
-func tmuxUnsetWindowOption(windowID, key string) error {
-	_, err := tmuxRun("set-option", "-t", windowID, "-p", "-u", "@"+key)
-	return err
-}
-
-// syncWindowOptions pushes the session's dynamic state to tmux per-window options.
-func syncWindowOptions(sess *Session) error {
+// syncWindowOptions pushes the session's dynamic state to tmux per-window
+// options, on the tmux server of the instance named socket.
+func syncWindowOptions(socket string, sess *Session) error {
 	if sess.WindowTarget == "" {
 		return nil
 	}
 	wid := sess.WindowTarget
-
-	// @vee-working
-	if sess.Working {
-		tmuxSetWindowOption(wid, "vee-working", "1")
-	} else {
-		tmuxUnsetWindowOption(wid, "vee-working")
-	}
-
-	// @vee-notif
-	if sess.HasNotification {
-		tmuxSetWindowOption(wid, "vee-notif", "1")
-	} else {
-		tmuxUnsetWindowOption(wid, "vee-notif")
-	}
-
-	// @vee-perm
-	if sess.PermissionMode != "" && sess.PermissionMode != "default" {
-		tmuxSetWindowOption(wid, "vee-perm", sess.PermissionMode)
-	} else {
-		tmuxUnsetWindowOption(wid, "vee-perm")
+	set := func(key string, on bool, value string) {
+		if on {
+			tmuxRunOn(socket, "set-option", "-t", wid, "-p", "@"+key, value)
+		} else {
+			tmuxRunOn(socket, "set-option", "-t", wid, "-p", "-u", "@"+key)
+		}
 	}
 
+	set("vee-working", sess.Working, "1")
+	set("vee-notif", sess.HasNotification, "1")
+	set("vee-perm", sess.PermissionMode != "" && sess.PermissionMode != "default", sess.PermissionMode)
 	return nil
 }
 
@@ -231,6 +227,18 @@ func tmuxConfigure(veeBinary string, port int, veePath string, passthrough []str
 		return fmt.Errorf("tmux bind-key r: %w", err)
 	}
 
+	// Ctrl-b F: fork the session in the current window into another profile
+	forkCmd := fmt.Sprintf("%s _fork-session --port %d --tmux-socket %s --window-id #{window_id}", shelljoin(veeBinary), port, tmuxSocketName)
+	if _, err := tmuxRun("bind-key", "-T", "prefix", "F", "run-shell", forkCmd); err != nil {
+		return fmt.Errorf("tmux bind-key F: %w", err)
+	}
+
+	// Ctrl-b j: background jobs, opening the selected job's log
+	jobMenuCmd := fmt.Sprintf("%s _job-menu --port %d --tmux-socket %s", shelljoin(veeBinary), port, tmuxSocketName)
+	if _, err := tmuxRun("bind-key", "-T", "prefix", "j", "run-shell", jobMenuCmd); err != nil {
+		return fmt.Errorf("tmux bind-key j: %w", err)
+	}
+
 	// Ctrl-b l: show logs in a popup (Esc or q to dismiss)
 	logPopupCmd := fmt.Sprintf("%s _log-viewer --tmux-socket %s", shelljoin(veeBinary), tmuxSocketName)
 	if _, err := tmuxRun("bind-key", "-T", "prefix", "l", "display-popup", "-E", "-w", "90%", "-h", "80%", logPopupCmd); err != nil {
@@ -249,6 +257,18 @@ func tmuxConfigure(veeBinary string, port int, veePath string, passthrough []str
 		return fmt.Errorf("tmux bind-key i: %w", err)
 	}
 
+	// Ctrl-b f: feedback explorer popup
+	feedbackCmd := fmt.Sprintf("%s _feedback-explorer --port %d --project %s", shelljoin(veeBinary), port, shelljoin(projectDir))
+	if _, err := tmuxRun("bind-key", "-T", "prefix", "f", "display-popup", "-E", "-w", "100", "-h", "30", feedbackCmd); err != nil {
+		return fmt.Errorf("tmux bind-key f: %w", err)
+	}
+
+	// Ctrl-b h: session history popup
+	historyCmd := fmt.Sprintf("%s _history --project %s", shelljoin(veeBinary), shelljoin(projectDir))
+	if _, err := tmuxRun("bind-key", "-T", "prefix", "h", "display-popup", "-E", "-w", "90%", "-h", "80%", historyCmd); err != nil {
+		return fmt.Errorf("tmux bind-key h: %w", err)
+	}
+
 	// Ctrl-b p: prompt viewer popup (shows system prompt for current session)
 	// display-popup doesn't expand #{window_id}, so we use run-shell to
 	// capture it first and then launch the popup.
@@ -257,6 +277,12 @@ func tmuxConfigure(veeBinary string, port int, veePath string, passthrough []str
 		return fmt.Errorf("tmux bind-key p: %w", err)
 	}
 
+	// Ctrl-b a: the calls the session in the current window made to Vee's tools
+	toolCallsCmd := fmt.Sprintf(`tmux -S %s display-popup -E -w 90%% -h 80%% '%s _tool-calls --port %d --window-id #{window_id}'`, tmuxSocketPath(), shelljoin(veeBinary), port)
+	if _, err := tmuxRun("bind-key", "-T", "prefix", "a", "run-shell", toolCallsCmd); err != nil {
+		return fmt.Errorf("tmux bind-key a: %w", err)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/lthms/vee/internal/api"
+	"github.com/lthms/vee/internal/history"
+)
+
+// ToolCallsViewerCmd is the internal subcommand that lists the calls the
+// session in the current tmux window made to Vee's MCP tools, rendered
+// inside a tmux display-popup.
+type ToolCallsViewerCmd struct {
+	Port     int    `short:"p" default:"2700" name:"port"`
+	WindowID string `required:"" name:"window-id"`
+}
+
+var tcErrorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#f7768e"))
+
+// toolCallsViewerModel is the Bubble Tea model for the tool call viewer.
+type toolCallsViewerModel struct {
+	viewport  viewport.Model
+	profile   string
+	indicator string
+	calls     []history.ToolCall
+	errorMsg  string
+	ready     bool
+	width     int
+	height    int
+}
+
+func (m toolCallsViewerModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m toolCallsViewerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		viewportHeight := max(m.height-3, 1) // title + divider, footer
+		if !m.ready {
+			m.viewport = viewport.New(m.width, viewportHeight)
+			m.viewport.YPosition = 2
+			m.ready = true
+		} else {
+			m.viewport.Width = m.width
+			m.viewport.Height = viewportHeight
+		}
+		m.viewport.SetContent(renderToolCalls(m.calls, m.width))
+		m.viewport.GotoBottom()
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case "g":
+			m.viewport.GotoTop()
+			return m, nil
+		case "G":
+			m.viewport.GotoBottom()
+			return m, nil
+		}
+		if m.ready {
+			var cmd tea.Cmd
+			m.viewport, cmd = m.viewport.Update(msg)
+			return m, cmd
+		}
+	}
+	return m, nil
+}
+
+// renderToolCalls renders one line per call: when it was made, the tool,
+// its latency and arguments, then its result.
+func renderToolCalls(calls []history.ToolCall, width int) string {
+	if len(calls) == 0 {
+		return pvHelpStyle.Render(" No tool calls yet.")
+	}
+	var b strings.Builder
+	for i, c := range calls {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		at := c.CalledAt
+		if len(at) >= 19 {
+			at = at[11:19] // time of day, UTC
+		}
+		head := fmt.Sprintf(" %s  %-15s %5dms  ", at, c.Tool, c.DurationMS)
+		b.WriteString(pvHelpStyle.Render(head))
+		b.WriteString(truncateRunes(c.Arguments, width-len([]rune(head))-1))
+		b.WriteString("\n")
+		result := "   → " + truncateRunes(c.Result, width-6)
+		if c.IsError {
+			b.WriteString(tcErrorStyle.Render(result))
+		} else {
+			b.WriteString(result)
+		}
+	}
+	return b.String()
+}
+
+func (m toolCallsViewerModel) View() string {
+	if m.errorMsg != "" {
+		return "\n " + pvErrorStyle.Render(m.errorMsg)
+	}
+	if !m.ready {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(pvTitleStyle.Render(fmt.Sprintf(" %s %s — Tool calls (%d)", m.indicator, m.profile, len(m.calls))))
+	b.WriteString("\n")
+	b.WriteString(pvHelpStyle.Render(strings.Repeat("─", m.width)))
+	b.WriteString("\n")
+	b.WriteString(m.viewport.View())
+	b.WriteString("\n")
+	b.WriteString(" " + pvHelpStyle.Render("↑/↓ scroll  g/G top/bottom  q quit"))
+	return b.String()
+}
+
+func (cmd *ToolCallsViewerCmd) Run() error {
+	m := cmd.fetchToolCalls()
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	_, err := p.Run()
+	return err
+}
+
+func (cmd *ToolCallsViewerCmd) fetchToolCalls() toolCallsViewerModel {
+	result, err := daemonAPI(cmd.Port).SessionToolCalls(cmd.WindowID)
+	if api.IsStatus(err, http.StatusNotFound) {
+		return toolCallsViewerModel{errorMsg: "No session in this window."}
+	}
+	var se *api.StatusError
+	if errors.As(err, &se) {
+		return toolCallsViewerModel{errorMsg: fmt.Sprintf("Daemon returned %d.", se.Code)}
+	}
+	if err != nil {
+		return toolCallsViewerModel{errorMsg: "Could not reach the daemon."}
+	}
+	return toolCallsViewerModel{profile: result.Profile, indicator: result.Indicator, calls: result.Calls}
+}
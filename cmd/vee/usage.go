@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lthms/vee/internal/history"
+)
+
+// recordSessionUsage reads the token usage from the transcript of a session,
+// stores it under project, and returns the session's total. Sessions whose
+// transcript was not reported yet have nothing to record.
+func recordSessionUsage(hstore *history.Store, sess *Session, project string) (history.Usage, error) {
+	if sess.TranscriptPath == "" {
+		return history.Usage{}, nil
+	}
+	f, err := os.Open(sess.TranscriptPath)
+	if err != nil {
+		return history.Usage{}, fmt.Errorf("open transcript: %w", err)
+	}
+	defer f.Close()
+
+	entries, err := history.ParseUsage(f)
+	if err != nil {
+		return history.Usage{}, fmt.Errorf("parse transcript: %w", err)
+	}
+	if err := hstore.RecordUsage(sess.ID, sess.Profile, project, entries); err != nil {
+		return history.Usage{}, err
+	}
+	return hstore.SessionUsage(sess.ID)
+}
+
+// updateSessionUsage records the usage of a session and shows its running
+// total on the dashboard.
+func updateSessionUsage(app *App, hstore *history.Store, sess *Session) {
+	usage, err := recordSessionUsage(hstore, sess, app.projectDir())
+	if err != nil {
+		slog.Warn("failed to record session usage", "id", sess.ID, "error", err)
+		return
+	}
+	app.Sessions.setUsage(sess.ID, usage)
+}
+
+// UsageCmd reports the tokens used by sessions, and their estimated cost,
+// grouped by profile, project and day.
+type UsageCmd struct {
+	By   []string `short:"b" enum:"profile,project,day" default:"profile,project,day" help:"Breakdowns to show (profile, project, day)."`
+	Days int      `short:"d" default:"30" help:"Only count the last N days (0 for all time)."`
+	JSON bool     `name:"json" help:"Print the report as JSON."`
+}
+
+// Run prints the usage report.
+func (cmd *UsageCmd) Run() error {
+	hstore, err := openHistoryStore()
+	if err != nil {
+		return err
+	}
+	defer hstore.Close()
+
+	var since string
+	if cmd.Days > 0 {
+		since = time.Now().UTC().AddDate(0, 0, -(cmd.Days - 1)).Format("2006-01-02")
+	}
+
+	report := make(map[string][]history.UsageRow)
+	for _, by := range cmd.By {
+		rows, err := hstore.UsageReport(by, since)
+		if err != nil {
+			return err
+		}
+		report[by] = rows
+	}
+
+	if cmd.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	for i, by := range cmd.By {
+		if i > 0 {
+			fmt.Println()
+		}
+		printUsageTable(by, report[by])
+	}
+	return nil
+}
+
+// printUsageTable prints one breakdown of the usage report, with a total.
+func printUsageTable(by string, rows []history.UsageRow) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tsessions\tinput\toutput\tcache write\tcache read\tcost\n", by)
+	var total history.Usage
+	for _, r := range rows {
+		key := r.Key
+		if by == "project" {
+			key = shortenHome(key)
+		}
+		if key == "" {
+			key = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n", key, r.Sessions,
+			formatTokens(r.Input), formatTokens(r.Output), formatTokens(r.CacheCreation), formatTokens(r.CacheRead), formatCost(r.Cost))
+		total = total.Add(r.Usage)
+	}
+	fmt.Fprintf(tw, "total\t\t%s\t%s\t%s\t%s\t%s\n",
+		formatTokens(total.Input), formatTokens(total.Output), formatTokens(total.CacheCreation), formatTokens(total.CacheRead), formatCost(total.Cost))
+	tw.Flush()
+}
+
+// shortenHome replaces the home directory at the start of a path with ~.
+func shortenHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	if rest, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return filepath.Join("~", rest)
+	}
+	return path
+}
+
+// formatTokens abbreviates a token count (e.g. 1.2M).
+func formatTokens(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+// formatCost formats an estimated cost in US dollars.
+func formatCost(c float64) string {
+	return fmt.Sprintf("$%.2f", c)
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/lthms/vee/internal/api"
+	"github.com/lthms/vee/internal/feedback"
+	"github.com/lthms/vee/internal/history"
+	"github.com/lthms/vee/internal/kb"
+)
+
+// The user-level daemon serves the instances of every project of the user
+// from a single process, when [daemon] shared is set in the user config.
+// Each project keeps its own tmux server, port, socket, sessions, jobs and
+// schedules, but they share one knowledge base worker instead of racing
+// over the pending statements with a worker each. Instances register on
+// the daemon's control socket when they start, keyed by project directory,
+// and the daemon drops them once their tmux server is gone.
+
+// userDaemonReapInterval is how often the user-level daemon looks for
+// instances whose tmux server is gone.
+const userDaemonReapInterval = 30 * time.Second
+
+// userDaemonSocketPath returns the control socket of the user-level daemon.
+func userDaemonSocketPath() string {
+	return filepath.Join(veeRuntimeDir(), "user.sock")
+}
+
+// userDaemonLogPath returns the log file of the user-level daemon.
+func userDaemonLogPath() string {
+	return filepath.Join(veeRuntimeDir(), "user.log")
+}
+
+// ProjectInstance is a project served by the user-level daemon.
+type ProjectInstance struct {
+	api.Project
+
+	app  *App
+	stop func()
+}
+
+// userDaemon holds the instances served by the user-level daemon.
+type userDaemon struct {
+	mu        sync.Mutex
+	instances map[string]*ProjectInstance // by project directory
+
+	ctx     context.Context
+	userCfg *UserConfig
+	kbase   *kb.KnowledgeBase
+	fstore  *feedback.Store
+	hstore  *history.Store
+
+	// alive reports whether the tmux server of an instance is running.
+	alive func(tmuxSocket string) bool
+}
+
+func newUserDaemon(ctx context.Context, userCfg *UserConfig) *userDaemon {
+	return &userDaemon{
+		instances: make(map[string]*ProjectInstance),
+		ctx:       ctx,
+		userCfg:   userCfg,
+		alive: func(tmuxSocket string) bool {
+			return tmuxCmdOn(tmuxSocket, "has-session", "-t", tmuxSessionName).Run() == nil
+		},
+	}
+}
+
+// open starts serving the project described by cfg, replacing the
+// instance a previous run left for the same directory.
+func (d *userDaemon) open(cfg *AppConfig) (*ProjectInstance, error) {
+	if cfg.ProjectDir == "" || cfg.TmuxSocket == "" {
+		return nil, fmt.Errorf("project_dir and tmux_socket are required")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if old, ok := d.instances[cfg.ProjectDir]; ok {
+		slog.Info("replacing project instance", "dir", old.Dir, "port", old.Port)
+		old.stop()
+		delete(d.instances, old.Dir)
+	}
+
+	app := newApp()
+	stop, err := startInstance(d.ctx, app, cfg, d.userCfg, d.kbase, d.fstore, d.hstore)
+	if err != nil {
+		return nil, err
+	}
+	inst := &ProjectInstance{
+		Project: api.Project{
+			Dir:        cfg.ProjectDir,
+			TmuxSocket: cfg.TmuxSocket,
+			Port:       cfg.Port,
+			StartedAt:  time.Now(),
+		},
+		app:  app,
+		stop: stop,
+	}
+	d.instances[inst.Dir] = inst
+	slog.Info("project instance opened", "dir", inst.Dir, "port", inst.Port)
+	return inst, nil
+}
+
+// close stops serving the project in dir.
+func (d *userDaemon) close(dir string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	inst, ok := d.instances[dir]
+	if !ok {
+		return false
+	}
+	inst.stop()
+	delete(d.instances, dir)
+	slog.Info("project instance closed", "dir", dir, "port", inst.Port)
+	return true
+}
+
+// list returns the instances, sorted by project directory.
+func (d *userDaemon) list() []api.Project {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	result := make([]api.Project, 0, len(d.instances))
+	for _, inst := range d.instances {
+		result = append(result, inst.Project)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Dir < result[j].Dir })
+	return result
+}
+
+// publishKBEvent hands a knowledge base event to every instance.
+func (d *userDaemon) publishKBEvent(e kb.Event) {
+	d.mu.Lock()
+	apps := make([]*App, 0, len(d.instances))
+	for _, inst := range d.instances {
+		apps = append(apps, inst.app)
+	}
+	d.mu.Unlock()
+	for _, app := range apps {
+		publishKBEvent(app, e)
+	}
+}
+
+// reap closes the instances whose tmux server is gone, e.g. after a
+// shutdown or a crash.
+func (d *userDaemon) reap() {
+	for _, inst := range d.list() {
+		if !d.alive(inst.TmuxSocket) {
+			slog.Info("tmux server gone, closing project instance", "dir", inst.Dir)
+			d.close(inst.Dir)
+		}
+	}
+}
+
+// handleProjects handles GET /api/projects to list the instances, POST
+// /api/projects to open one (an AppConfig, answered with its port) and
+// DELETE /api/projects?dir=<dir> to close one.
+func (d *userDaemon) handleProjects() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(d.list())
+
+		case http.MethodPost:
+			var cfg AppConfig
+			if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+				http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			inst, err := d.open(&cfg)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(api.OpenedProject{Port: inst.Port})
+
+		case http.MethodDelete:
+			if !d.close(r.URL.Query().Get("dir")) {
+				http.Error(w, "unknown project", http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// runUserDaemon runs the user-level daemon until it is interrupted.
+func runUserDaemon() error {
+	if err := ensureRuntimeDir(); err != nil {
+		return fmt.Errorf("create runtime dir: %w", err)
+	}
+	if userDaemonAlive() {
+		return fmt.Errorf("the user-level daemon is already running")
+	}
+	setupFileLogger(userDaemonLogPath())
+
+	userCfg, err := loadUserConfig()
+	if err != nil {
+		slog.Warn("failed to load user config, using defaults", "error", err)
+		userCfg = hydrateUserConfig(nil)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	d := newUserDaemon(ctx, userCfg)
+
+	if d.kbase, err = openKB(userCfg, d.publishKBEvent); err != nil {
+		return fmt.Errorf("open knowledge base: %w", err)
+	}
+	defer d.kbase.Close()
+	if d.fstore, err = openFeedbackStore(userCfg); err != nil {
+		return err
+	}
+	defer d.fstore.Close()
+	if d.hstore, err = openHistoryStore(); err != nil {
+		return err
+	}
+	defer d.hstore.Close()
+
+	// The only KB worker of the user's projects
+	go d.kbase.RunWorker(ctx)
+
+	path := userDaemonSocketPath()
+	os.Remove(path) // left behind by a daemon that didn't exit cleanly
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		ln.Close()
+		return fmt.Errorf("restrict %s: %w", path, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/projects", d.handleProjects())
+	srv := &http.Server{Handler: mux}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	go func() {
+		ticker := time.NewTicker(userDaemonReapInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.reap()
+			}
+		}
+	}()
+
+	slog.Info("user-level daemon listening", "socket", path)
+	err = srv.Serve(ln)
+	for _, inst := range d.list() {
+		d.close(inst.Dir)
+	}
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// userDaemonClient is the HTTP client talking to the user-level daemon on
+// its control socket, whatever the host of the URL.
+var userDaemonClient = &http.Client{
+	Timeout: 30 * time.Second,
+	Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", userDaemonSocketPath())
+		},
+	},
+}
+
+// userDaemonURL is the base URL of the user-level daemon's API.
+const userDaemonURL = "http://vee"
+
+// userDaemonAPI is the client of the user-level daemon's API.
+var userDaemonAPI = api.New(userDaemonURL, userDaemonClient)
+
+// userDaemonAlive checks whether the user-level daemon is responding.
+func userDaemonAlive() bool {
+	_, err := userDaemonAPI.Projects()
+	return err == nil
+}
+
+// ensureUserDaemon starts the user-level daemon in the background, unless
+// it is already running, and waits for it to answer.
+func ensureUserDaemon(veeBinary string) error {
+	if userDaemonAlive() {
+		return nil
+	}
+	cmd := exec.Command(veeBinary, "daemon", "--user")
+	cmd.Dir, _ = os.UserHomeDir()
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true} // outlive the tmux server
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start user-level daemon: %w", err)
+	}
+	go cmd.Wait()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if userDaemonAlive() {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("user-level daemon did not start within 10s (see %s)", userDaemonLogPath())
+}
+
+// openSharedInstance has the user-level daemon serve the project described
+// by cfg, starting the daemon if needed, and returns the instance's port.
+func openSharedInstance(veeBinary string, cfg *AppConfig) (int, error) {
+	if err := ensureUserDaemon(veeBinary); err != nil {
+		return 0, err
+	}
+	port, err := userDaemonAPI.OpenProject(cfg)
+	if err != nil {
+		return 0, fmt.Errorf("user-level daemon: %w", err)
+	}
+	return port, nil
+}
+
+// closeSharedInstance has the user-level daemon stop serving the project
+// in dir.
+func closeSharedInstance(dir string) {
+	if err := userDaemonAPI.CloseProject(dir); err != nil {
+		slog.Warn("failed to close shared instance", "dir", dir, "error", err)
+	}
+}
+
+// listProjects returns the projects served by the user-level daemon.
+func listProjects() ([]api.Project, error) {
+	projects, err := userDaemonAPI.Projects()
+	var se *api.StatusError
+	if err != nil && !errors.As(err, &se) {
+		return nil, fmt.Errorf("no user-level daemon is running (set shared = true under [daemon] in ~/.config/vee/config)")
+	}
+	return projects, err
+}
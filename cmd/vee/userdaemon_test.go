@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lthms/vee/internal/history"
+)
+
+func TestUserDaemonProjects(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	// Each project is opened with profiles of its own
+	veePath := func(profile string) string {
+		dir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(dir, "profiles"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		content := "---\nindicator: \"🦊\"\ndescription: \"test\"\n---\nBody\n"
+		if err := os.WriteFile(filepath.Join(dir, "profiles", profile+".md"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+	veePaths := map[string]string{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d := newUserDaemon(ctx, hydrateUserConfig(nil))
+	hstore, err := history.Open(filepath.Join(t.TempDir(), "history.db"), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hstore.Close()
+	d.hstore = hstore
+	alive := true
+	d.alive = func(string) bool { return alive }
+	srv := httptest.NewServer(d.handleProjects())
+	defer srv.Close()
+
+	open := func(dir string) int {
+		t.Helper()
+		body := fmt.Sprintf(`{"project_dir":%q,"tmux_socket":"vee-test","vee_path":%q}`, dir, veePaths[dir])
+		resp, err := http.Post(srv.URL, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("open %s: got %d", dir, resp.StatusCode)
+		}
+		var result struct{ Port int }
+		json.NewDecoder(resp.Body).Decode(&result)
+		return result.Port
+	}
+
+	projectA, projectB := t.TempDir(), t.TempDir()
+	veePaths[projectA], veePaths[projectB] = veePath("alpha"), veePath("beta")
+	portA := open(projectA)
+	open(projectB)
+
+	for dir, want := range map[string]string{projectA: "alpha", projectB: "beta"} {
+		if _, ok := d.instances[dir].app.Profiles()[want]; !ok {
+			t.Errorf("profiles of %s = %v, want %s", dir, d.instances[dir].app.Profiles(), want)
+		}
+	}
+
+	// Each instance serves its own project
+	resp, err := daemonClient.Get(fmt.Sprintf("http://127.0.0.1:%d/api/config", portA))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg AppConfig
+	json.NewDecoder(resp.Body).Decode(&cfg)
+	resp.Body.Close()
+	if cfg.ProjectDir != projectA || cfg.Port != portA || cfg.TmuxSocket != "vee-test" {
+		t.Errorf("config of A = %+v", cfg)
+	}
+
+	// Registering a project again replaces its instance
+	if port := open(projectA); port == portA {
+		t.Errorf("reopened A on the same port %d", port)
+	}
+	if _, err := os.Stat(daemonSocketPath(portA)); !os.IsNotExist(err) {
+		t.Errorf("socket of the replaced instance: %v", err)
+	}
+	if got := d.list(); len(got) != 2 || got[0].Dir > got[1].Dir {
+		t.Errorf("list = %+v", got)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, srv.URL+"?dir=/nowhere", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("close unknown project: got %d, want 404", resp.StatusCode)
+	}
+
+	// Instances whose tmux server is gone are dropped
+	alive = false
+	d.reap()
+	if got := d.list(); len(got) != 0 {
+		t.Errorf("after reap: %+v", got)
+	}
+}
@@ -0,0 +1,386 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lthms/vee/internal/feedback"
+	"github.com/lthms/vee/internal/kb"
+)
+
+// StatusError is returned when the daemon answers with an unexpected status.
+type StatusError struct {
+	Code    int
+	Message string // body of the response, trimmed
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("daemon returned %d", e.Code)
+	}
+	return fmt.Sprintf("daemon returned %d: %s", e.Code, e.Message)
+}
+
+// IsStatus reports whether err is a StatusError with the given code.
+func IsStatus(err error, code int) bool {
+	var se *StatusError
+	return errors.As(err, &se) && se.Code == code
+}
+
+// Client calls the daemon API.
+type Client struct {
+	BaseURL string // e.g. "http://127.0.0.1:2700"
+	HTTP    *http.Client
+}
+
+// New returns a client of the daemon at baseURL, sending its requests with
+// hc (http.DefaultClient when nil).
+func New(baseURL string, hc *http.Client) *Client {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), HTTP: hc}
+}
+
+// do sends a request with in encoded as its JSON body, unless nil, and
+// decodes the JSON response into out, unless nil. Any 2xx status is a
+// success.
+func (c *Client) do(method, path string, query url.Values, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(payload)
+	}
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &StatusError{Code: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *Client) get(path string, query url.Values, out any) error {
+	return c.do(http.MethodGet, path, query, nil, out)
+}
+
+func (c *Client) post(path string, query url.Values, in, out any) error {
+	return c.do(http.MethodPost, path, query, in, out)
+}
+
+// byID is the query designating a session or an entry by its ID.
+func byID(id string) url.Values {
+	return url.Values{"id": {id}}
+}
+
+// State returns a snapshot of the daemon's state.
+func (c *Client) State() (*State, error) {
+	var state State
+	if err := c.get("/api/state", nil, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Config returns the configuration of the instance.
+func (c *Client) Config() (*AppConfig, error) {
+	var cfg AppConfig
+	if err := c.get("/api/config", nil, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Session returns the session with the given ID.
+func (c *Client) Session(id string) (*Session, error) {
+	var sess Session
+	if err := c.get("/api/session", byID(id), &sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// SessionByWindow returns the session running in a tmux window.
+func (c *Client) SessionByWindow(window string) (*Session, error) {
+	var sess Session
+	if err := c.get("/api/session", url.Values{"window": {window}}, &sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// CreateSession registers a session started in a tmux window.
+func (c *Client) CreateSession(req CreateSessionRequest) error {
+	return c.post("/api/sessions", nil, req, nil)
+}
+
+// Suspend suspends the session running in a tmux window. Ephemeral sessions
+// are completed instead.
+func (c *Client) Suspend(window string) (*Status, error) {
+	var status Status
+	if err := c.post("/api/suspend", nil, WindowRequest{WindowTarget: window}, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Complete marks the session running in a tmux window as completed.
+func (c *Client) Complete(window string) (*Status, error) {
+	var status Status
+	if err := c.post("/api/complete", nil, WindowRequest{WindowTarget: window}, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Activate reactivates a suspended session in a new tmux window.
+func (c *Client) Activate(sessionID, window string) error {
+	return c.post("/api/activate", nil, ActivateRequest{SessionID: sessionID, WindowTarget: window}, nil)
+}
+
+// SetPreview sets the preview text of a session.
+func (c *Client) SetPreview(sessionID, preview string) error {
+	return c.post("/api/preview", nil, PreviewRequest{SessionID: sessionID, Preview: preview}, nil)
+}
+
+// SetWindowState updates the window indicators of a session.
+func (c *Client) SetWindowState(req WindowStateRequest) error {
+	return c.post("/api/window-state", nil, req, nil)
+}
+
+// SessionEnded reports that the Claude process of a session exited.
+func (c *Client) SessionEnded(sessionID string) error {
+	return c.post("/api/session-ended", nil, SessionEndedRequest{SessionID: sessionID}, nil)
+}
+
+// SessionPrompt returns the system prompt of the session running in a tmux
+// window.
+func (c *Client) SessionPrompt(window string) (*SessionPrompt, error) {
+	var prompt SessionPrompt
+	if err := c.get("/api/session/prompt", url.Values{"window": {window}}, &prompt); err != nil {
+		return nil, err
+	}
+	return &prompt, nil
+}
+
+// SessionToolCalls returns the calls to Vee's MCP tools made by the session
+// running in a tmux window.
+func (c *Client) SessionToolCalls(window string) (*SessionToolCalls, error) {
+	var calls SessionToolCalls
+	if err := c.get("/api/session/tool-calls", url.Values{"window": {window}}, &calls); err != nil {
+		return nil, err
+	}
+	return &calls, nil
+}
+
+// SetOutcome marks how a session turned out.
+func (c *Client) SetOutcome(sessionID, outcome string) error {
+	return c.post("/api/session/outcome", byID(sessionID), OutcomeRequest{Outcome: outcome}, nil)
+}
+
+// NextStage claims the next pipeline stage of a completed session. The
+// daemon answers 409 Conflict when it was already started.
+func (c *Client) NextStage(sessionID string) (*NextStage, error) {
+	var stage NextStage
+	if err := c.post("/api/pipeline/next", byID(sessionID), nil, &stage); err != nil {
+		return nil, err
+	}
+	return &stage, nil
+}
+
+// Jobs returns the background jobs, in submission order.
+func (c *Client) Jobs() ([]Job, error) {
+	var jobs []Job
+	if err := c.get("/api/jobs", nil, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// SubmitJob queues a background job.
+func (c *Client) SubmitJob(req SubmitJobRequest) error {
+	return c.post("/api/jobs", nil, req, nil)
+}
+
+// KBQuery searches the knowledge base.
+func (c *Client) KBQuery(query string) ([]kb.QueryResult, error) {
+	var results []kb.QueryResult
+	if err := c.get("/api/kb/query", url.Values{"q": {query}}, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// KBFetch returns a statement of the knowledge base.
+func (c *Client) KBFetch(id string) (*kb.Statement, error) {
+	var stmt kb.Statement
+	if err := c.get("/api/kb/fetch", byID(id), &stmt); err != nil {
+		return nil, err
+	}
+	return &stmt, nil
+}
+
+// KBIssues returns the open issues of the knowledge base.
+func (c *Client) KBIssues() ([]kb.Issue, error) {
+	var issues []kb.Issue
+	if err := c.get("/api/kb/issues", nil, &issues); err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
+// ResolveIssue resolves a knowledge base issue with the given action.
+func (c *Client) ResolveIssue(id, action string) error {
+	return c.post("/api/kb/issues/resolve", byID(id), ResolveIssueRequest{Action: action}, nil)
+}
+
+// FeedbackSample draws the feedback entries to inject into a session.
+func (c *Client) FeedbackSample(s FeedbackSample) ([]feedback.Entry, error) {
+	var entries []feedback.Entry
+	if err := c.get("/api/feedback/sample", s.Query(), &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Feedback lists the feedback entries matching f.
+func (c *Client) Feedback(f FeedbackFilter) ([]feedback.Entry, error) {
+	var entries []feedback.Entry
+	if err := c.get("/api/feedback", f.Query(), &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// FeedbackRanking lists the feedback entries matching f with their usage
+// statistics, best-scoring first.
+func (c *Client) FeedbackRanking(f FeedbackFilter) ([]feedback.RankedEntry, error) {
+	var entries []feedback.RankedEntry
+	if err := c.get("/api/feedback/ranking", f.Query(), &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// EditFeedback rewords a feedback entry, or changes its kind.
+func (c *Client) EditFeedback(id string, req FeedbackEditRequest) error {
+	return c.post("/api/feedback/edit", byID(id), req, nil)
+}
+
+// ScopeFeedback moves a feedback entry between user and project scope.
+func (c *Client) ScopeFeedback(id string, req FeedbackScopeRequest) error {
+	return c.post("/api/feedback/scope", byID(id), req, nil)
+}
+
+// PinFeedback pins or unpins a feedback entry.
+func (c *Client) PinFeedback(id string, pinned bool) error {
+	return c.post("/api/feedback/pin", byID(id), FeedbackPinRequest{Pinned: pinned}, nil)
+}
+
+// DeleteFeedback deletes a feedback entry.
+func (c *Client) DeleteFeedback(id string) error {
+	return c.post("/api/feedback/delete", byID(id), nil, nil)
+}
+
+// Events follows the event stream until it breaks, calling onOpen once
+// connected and onEvent for every event. A non-empty lastID resumes the
+// stream after that event.
+func (c *Client) Events(lastID string, onOpen func(), onEvent func(Event)) error {
+	req, err := http.NewRequest(http.MethodGet, c.BaseURL+"/api/events", nil)
+	if err != nil {
+		return err
+	}
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{Code: resp.StatusCode}
+	}
+	onOpen()
+	return ReadEvents(resp.Body, onEvent)
+}
+
+// ReadEvents parses a server-sent event stream of Events, until it ends.
+// Comments (keepalives) are skipped.
+func ReadEvents(r io.Reader, onEvent func(Event)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	var data strings.Builder
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "":
+			if data.Len() > 0 {
+				var ev Event
+				if err := json.Unmarshal([]byte(data.String()), &ev); err == nil {
+					onEvent(ev)
+				}
+				data.Reset()
+			}
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+// Projects returns the projects served by the user-level daemon.
+func (c *Client) Projects() ([]Project, error) {
+	var projects []Project
+	if err := c.get("/api/projects", nil, &projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// OpenProject has the user-level daemon serve the project described by
+// cfg, and returns the port of its instance.
+func (c *Client) OpenProject(cfg *AppConfig) (int, error) {
+	var opened OpenedProject
+	if err := c.post("/api/projects", nil, cfg, &opened); err != nil {
+		return 0, err
+	}
+	return opened.Port, nil
+}
+
+// CloseProject has the user-level daemon stop serving the project in dir.
+func (c *Client) CloseProject(dir string) error {
+	return c.do(http.MethodDelete, "/api/projects", url.Values{"dir": {dir}}, nil, nil)
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient(t *testing.T) {
+	var gotQuery string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/feedback/sample", func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		json.NewEncoder(w).Encode([]map[string]string{{"id": "f1", "kind": "good", "statement": "x"}})
+	})
+	mux.HandleFunc("/api/suspend", func(w http.ResponseWriter, r *http.Request) {
+		var req WindowRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.WindowTarget != "@3" {
+			http.Error(w, "no active session for this window", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(Status{Status: "suspended", SessionID: "s1"})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	c := New(srv.URL, nil)
+
+	entries, err := c.FeedbackSample(FeedbackSample{Profile: "vibe", Prompt: "fix a&b=c", Groups: []string{"coding", "go"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].ID != "f1" {
+		t.Errorf("entries = %+v", entries)
+	}
+	for _, want := range []string{"prompt=fix+a%26b%3Dc", "group=coding&group=go", "profile=vibe"} {
+		if !strings.Contains(gotQuery, want) {
+			t.Errorf("query %q lacks %q", gotQuery, want)
+		}
+	}
+
+	status, err := c.Suspend("@3")
+	if err != nil || status.SessionID != "s1" {
+		t.Errorf("Suspend(@3) = %+v, %v", status, err)
+	}
+	_, err = c.Suspend("@4")
+	if !IsStatus(err, http.StatusNotFound) || err.Error() != "daemon returned 404: no active session for this window" {
+		t.Errorf("Suspend(@4) error = %v, want a 404 status error", err)
+	}
+}
+
+func TestReadEvents(t *testing.T) {
+	stream := ": keepalive\n\nid: e-1\nevent: jobs.changed\ndata: {\"id\":\"e-1\",\"type\":\"jobs.changed\",\"data\":{\"jobs\":[]}}\n\n"
+	var got []Event
+	ReadEvents(strings.NewReader(stream), func(ev Event) { got = append(got, ev) })
+	if len(got) != 1 || got[0].ID != "e-1" || got[0].Type != "jobs.changed" || string(got[0].Data) != `{"jobs":[]}` {
+		t.Errorf("events = %+v", got)
+	}
+}
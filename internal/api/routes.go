@@ -0,0 +1,209 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/lthms/vee/internal/feedback"
+	"github.com/lthms/vee/internal/kb"
+)
+
+// Version is the version of the API described by Routes. It changes when a
+// route or a type changes incompatibly.
+const Version = "1.0.0"
+
+// Param is a query parameter of a route.
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+	Repeated    bool // may be given several times
+}
+
+// Route is an endpoint of the daemon API.
+type Route struct {
+	Method      string
+	Path        string
+	Summary     string
+	Query       []Param
+	Request     any    // value of the JSON body's type, nil when there is none
+	Response    any    // value of the JSON response's type, nil when it is not JSON
+	ContentType string // of a response that is not JSON
+	Status      int    // of a successful response, 200 when zero
+}
+
+var (
+	idParam      = Param{Name: "id", Description: "ID of the session or entry", Required: true}
+	windowParam  = Param{Name: "window", Description: "tmux window ID of the session (e.g. @3)", Required: true}
+	sessionParam = Param{Name: "session", Description: "ID of the session", Required: true}
+	filterParams = []Param{
+		{Name: "profile", Description: "only entries of this profile"},
+		{Name: "scope", Description: "only entries of this scope: user or project"},
+		{Name: "project", Description: "only entries of this project directory"},
+		{Name: "kind", Description: "only good or bad entries"},
+	}
+)
+
+// Routes are the endpoints served by the daemon of a project, besides the
+// MCP endpoint of the sessions (/sse).
+var Routes = []Route{
+	{Method: http.MethodGet, Path: "/api/state", Summary: "Snapshot of the daemon's state", Response: State{}},
+	{Method: http.MethodGet, Path: "/api/events", Summary: "Server-sent stream of state changes, resumed with Last-Event-ID",
+		Query:       []Param{{Name: "last_event_id", Description: "resume after this event, like the Last-Event-ID header"}},
+		ContentType: "text/event-stream"},
+	{Method: http.MethodGet, Path: "/api/config", Summary: "Configuration of the instance", Response: AppConfig{}},
+	{Method: http.MethodPost, Path: "/api/sessions", Summary: "Register a session started in a tmux window",
+		Request: CreateSessionRequest{}, Response: Status{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api/session", Summary: "A session, by ID or by tmux window",
+		Query:    []Param{{Name: "id", Description: "ID of the session"}, {Name: "window", Description: "tmux window ID of the session, when id is not given"}},
+		Response: Session{}},
+	{Method: http.MethodPost, Path: "/api/suspend", Summary: "Suspend the session of a window; ephemeral sessions are completed",
+		Request: WindowRequest{}, Response: Status{}},
+	{Method: http.MethodPost, Path: "/api/complete", Summary: "Complete the session of a window",
+		Request: WindowRequest{}, Response: Status{}},
+	{Method: http.MethodPost, Path: "/api/activate", Summary: "Reactivate a suspended session in a new window",
+		Request: ActivateRequest{}, Response: Status{}},
+	{Method: http.MethodPost, Path: "/api/preview", Summary: "Set the preview text of a session",
+		Request: PreviewRequest{}, Response: Status{}},
+	{Method: http.MethodPost, Path: "/api/window-state", Summary: "Update the window indicators of a session",
+		Request: WindowStateRequest{}, Response: Status{}},
+	{Method: http.MethodPost, Path: "/api/session-ended", Summary: "Report that the Claude process of a session exited",
+		Request: SessionEndedRequest{}, Response: Status{}},
+	{Method: http.MethodPost, Path: "/api/hook/preview", Summary: "Claude hook of ephemeral sessions setting the preview",
+		Query: []Param{sessionParam}, Request: HookPreviewRequest{}, Response: Status{}},
+	{Method: http.MethodPost, Path: "/api/hook/window-state", Summary: "Claude hook of ephemeral sessions updating the window indicators",
+		Query: []Param{sessionParam}, Request: HookWindowStateRequest{}, Response: Status{}},
+	{Method: http.MethodPost, Path: "/api/hook/transcript", Summary: "Upload the raw transcript of an ephemeral session",
+		Query: []Param{sessionParam}, Response: Status{}},
+	{Method: http.MethodGet, Path: "/api/session/prompt", Summary: "System prompt of the session of a window",
+		Query: []Param{windowParam}, Response: SessionPrompt{}},
+	{Method: http.MethodGet, Path: "/api/session/tool-calls", Summary: "Calls to Vee's MCP tools made by a session",
+		Query:    []Param{{Name: "id", Description: "ID of the session"}, {Name: "window", Description: "tmux window ID of the session, when id is not given"}},
+		Response: SessionToolCalls{}},
+	{Method: http.MethodPost, Path: "/api/session/outcome", Summary: "Mark how a session turned out",
+		Query: []Param{idParam}, Request: OutcomeRequest{}, Response: Status{}},
+	{Method: http.MethodPost, Path: "/api/pipeline/next", Summary: "Claim the next pipeline stage of a completed session; 409 when already started",
+		Query: []Param{idParam}, Response: NextStage{}},
+	{Method: http.MethodGet, Path: "/api/jobs", Summary: "Background jobs, in submission order", Response: []Job{}},
+	{Method: http.MethodPost, Path: "/api/jobs", Summary: "Queue a background job; 409 when its ID is already taken",
+		Request: SubmitJobRequest{}, Response: Status{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api/kb/query", Summary: "Search the knowledge base",
+		Query: []Param{{Name: "q", Description: "search query", Required: true}}, Response: []kb.QueryResult{}},
+	{Method: http.MethodGet, Path: "/api/kb/fetch", Summary: "A statement of the knowledge base",
+		Query: []Param{idParam}, Response: kb.Statement{}},
+	{Method: http.MethodGet, Path: "/api/kb/issues", Summary: "Open issues of the knowledge base", Response: []kb.Issue{}},
+	{Method: http.MethodPost, Path: "/api/kb/issues/resolve", Summary: "Resolve a knowledge base issue",
+		Query: []Param{idParam}, Request: ResolveIssueRequest{}, Response: Status{}},
+	{Method: http.MethodGet, Path: "/api/feedback", Summary: "Feedback entries", Query: filterParams, Response: []feedback.Entry{}},
+	{Method: http.MethodGet, Path: "/api/feedback/ranking", Summary: "Feedback entries with usage statistics, best-scoring first",
+		Query: filterParams, Response: []feedback.RankedEntry{}},
+	{Method: http.MethodGet, Path: "/api/feedback/sample", Summary: "Feedback entries to inject into a session",
+		Query: []Param{
+			{Name: "profile", Description: "profile of the session", Required: true},
+			{Name: "project", Description: "project directory of the session"},
+			{Name: "n", Description: "entries of the profile (default 5)"},
+			{Name: "pinned", Description: "budget for pinned entries"},
+			{Name: "prompt", Description: "initial prompt, favoring relevant entries"},
+			{Name: "seed", Description: "seed making the draw deterministic"},
+			{Name: "group", Description: "profile group of the session", Repeated: true},
+			{Name: "group_n", Description: "entries targeting the groups"},
+			{Name: "global_n", Description: "entries targeting every profile"},
+		},
+		Response: []feedback.Entry{}},
+	{Method: http.MethodPost, Path: "/api/feedback/edit", Summary: "Reword a feedback entry, or change its kind",
+		Query: []Param{idParam}, Request: FeedbackEditRequest{}, Response: Status{}},
+	{Method: http.MethodPost, Path: "/api/feedback/scope", Summary: "Move a feedback entry between user and project scope",
+		Query: []Param{idParam}, Request: FeedbackScopeRequest{}, Response: Status{}},
+	{Method: http.MethodPost, Path: "/api/feedback/pin", Summary: "Pin or unpin a feedback entry",
+		Query: []Param{idParam}, Request: FeedbackPinRequest{}, Response: Status{}},
+	{Method: http.MethodPost, Path: "/api/feedback/delete", Summary: "Delete a feedback entry",
+		Query: []Param{idParam}, Response: Status{}},
+	{Method: http.MethodPost, Path: "/api/gpg/sign", Summary: "Sign the body with the host's GPG, as a detached armored signature",
+		Query:       []Param{{Name: "key", Description: "signing key ID", Required: true}},
+		ContentType: "application/pgp-signature"},
+	{Method: http.MethodGet, Path: "/api/openapi.json", Summary: "This description", ContentType: "application/json"},
+}
+
+// OpenAPI returns the OpenAPI 3.1 description of the routes.
+func OpenAPI() ([]byte, error) {
+	opts := &jsonschema.ForOptions{
+		TypeSchemas: map[reflect.Type]*jsonschema.Schema{
+			reflect.TypeFor[json.RawMessage](): {Description: "payload of the event, depending on its type"},
+		},
+	}
+	schema := func(v any) (map[string]any, error) {
+		s, err := jsonschema.ForType(reflect.TypeOf(v), opts)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"application/json": map[string]any{"schema": s}}, nil
+	}
+
+	paths := map[string]map[string]any{}
+	for _, r := range Routes {
+		op := map[string]any{"summary": r.Summary}
+
+		var params []map[string]any
+		for _, p := range r.Query {
+			var s any = map[string]string{"type": "string"}
+			if p.Repeated {
+				s = map[string]any{"type": "array", "items": map[string]string{"type": "string"}}
+			}
+			params = append(params, map[string]any{
+				"name": p.Name, "in": "query", "description": p.Description,
+				"required": p.Required, "schema": s,
+			})
+		}
+		if params != nil {
+			op["parameters"] = params
+		}
+
+		if r.Request != nil {
+			content, err := schema(r.Request)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", r.Method, r.Path, err)
+			}
+			op["requestBody"] = map[string]any{"required": true, "content": content}
+		}
+
+		ok := map[string]any{"description": "success"}
+		switch {
+		case r.Response != nil:
+			content, err := schema(r.Response)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", r.Method, r.Path, err)
+			}
+			ok["content"] = content
+		case r.ContentType != "":
+			ok["content"] = map[string]any{r.ContentType: map[string]any{}}
+		}
+		status := r.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		op["responses"] = map[string]any{fmt.Sprint(status): ok}
+
+		if paths[r.Path] == nil {
+			paths[r.Path] = map[string]any{}
+		}
+		paths[r.Path][strings.ToLower(r.Method)] = op
+	}
+
+	return json.MarshalIndent(map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "Vee daemon API",
+			"version":     Version,
+			"description": "Served on $XDG_RUNTIME_DIR/vee/daemon-<port>.sock, and over TCP with a bearer token read from $XDG_RUNTIME_DIR/vee/daemon-<port>.secret.",
+		},
+		"components": map[string]any{
+			"securitySchemes": map[string]any{"bearer": map[string]string{"type": "http", "scheme": "bearer"}},
+		},
+		"security": []map[string][]string{{"bearer": {}}},
+		"paths":    paths,
+	}, "", "  ")
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPI(t *testing.T) {
+	raw, err := OpenAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		OpenAPI string                               `json:"openapi"`
+		Paths   map[string]map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("openapi = %q", doc.OpenAPI)
+	}
+	for _, r := range Routes {
+		if _, ok := doc.Paths[r.Path][strings.ToLower(r.Method)]; !ok {
+			t.Errorf("%s %s is not described", r.Method, r.Path)
+		}
+	}
+
+	// Request and response schemas follow the JSON names of the types.
+	post := doc.Paths["/api/sessions"]["post"]
+	schema, _ := json.Marshal(post["requestBody"])
+	if !strings.Contains(string(schema), `"window_target"`) {
+		t.Errorf("POST /api/sessions request schema lacks window_target: %s", schema)
+	}
+	if _, ok := post["responses"].(map[string]any)["201"]; !ok {
+		t.Errorf("POST /api/sessions responses = %v, want 201", post["responses"])
+	}
+	schema, _ = json.Marshal(doc.Paths["/api/state"]["get"]["responses"])
+	if !strings.Contains(string(schema), `"active_sessions"`) || strings.Contains(string(schema), "SystemPrompt") {
+		t.Errorf("GET /api/state response schema: %s", schema)
+	}
+}
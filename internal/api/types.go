@@ -0,0 +1,340 @@
+// Package api describes the HTTP API of the Vee daemon: the types of its
+// requests and responses, shared by the daemon's handlers and by the host
+// commands calling it, a Client for those commands, and the routes the
+// daemon serves, from which an OpenAPI description is generated.
+package api
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/lthms/vee/internal/feedback"
+	"github.com/lthms/vee/internal/history"
+)
+
+// Session is a Claude session known to the daemon.
+type Session struct {
+	ID              string          `json:"id"`
+	Profile         string          `json:"profile"`
+	Indicator       string          `json:"indicator"`
+	StartedAt       time.Time       `json:"started_at"`
+	Preview         string          `json:"preview"`
+	Status          string          `json:"status"`        // "active", "suspended", or "completed"
+	WindowTarget    string          `json:"window_target"` // tmux window ID (e.g. "@3")
+	Ephemeral       bool            `json:"ephemeral"`
+	ComposePath     string          `json:"compose_path,omitempty"`
+	ComposeProject  string          `json:"compose_project,omitempty"`
+	Working         bool            `json:"working"`
+	HasNotification bool            `json:"has_notification"`
+	PermissionMode  string          `json:"permission_mode"`
+	SystemPrompt    string          `json:"-"`                      // served by /api/session/prompt only
+	FeedbackIDs     []string        `json:"feedback_ids,omitempty"` // feedback entries injected into the system prompt
+	Groups          []string        `json:"groups,omitempty"`       // profile groups, for group-targeted feedback
+	Permissions     ToolPermissions `json:"permissions,omitzero"`   // Vee MCP tools the profile allows
+	TranscriptPath  string          `json:"transcript_path,omitempty"`
+	PromptArg       string          `json:"prompt_arg,omitempty"`   // argument the initial prompt was expanded from
+	Next            string          `json:"next,omitempty"`         // profile offered once the session completes
+	Chain           string          `json:"chain,omitempty"`        // pipeline the session belongs to (its prompt argument)
+	NextStarted     bool            `json:"next_started,omitempty"` // the next stage was started
+	Schedule        string          `json:"schedule,omitempty"`     // schedule that started the session
+	ParentID        string          `json:"parent_id,omitempty"`    // session the conversation was forked from
+	LastActivity    time.Time       `json:"last_activity"`          // last hook event or activation
+	Usage           history.Usage   `json:"usage,omitzero"`         // tokens used so far, from the transcript
+}
+
+// ToolPermissions are the Vee MCP tools a profile may use, declared with
+// tools: and kb: in its frontmatter.
+type ToolPermissions struct {
+	Tools []string `json:"tools,omitempty"` // allowed tools, every tool when nil
+	KB    string   `json:"kb,omitempty"`    // knowledge base access: none, read or write (default)
+}
+
+// AppConfig is the configuration of an instance, which the commands it
+// starts fetch from /api/config.
+type AppConfig struct {
+	VeePath        string   `json:"vee_path"`
+	Port           int      `json:"port"`
+	Passthrough    []string `json:"passthrough"`
+	ProjectConfig  string   `json:"project_config"`
+	IdentityRule   string   `json:"identity_rule"`
+	PlatformsRule  string   `json:"platforms_rule"`
+	MaxExamples    int      `json:"max_examples"`
+	MaxPinned      int      `json:"max_pinned"`
+	GroupExamples  int      `json:"group_examples"`
+	GlobalExamples int      `json:"global_examples"`
+	Seeded         bool     `json:"seeded"`
+	AutoNext       bool     `json:"auto_next"`
+	ProjectDir     string   `json:"project_dir"` // directory of the project the instance serves
+	TmuxSocket     string   `json:"tmux_socket"` // name of the instance's tmux socket
+}
+
+// IndexingTask represents a background processing operation.
+type IndexingTask struct {
+	TaskID    string    `json:"task_id"`
+	Title     string    `json:"title"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Job is a Claude session run non-interactively in the background, with its
+// output captured to a log file.
+type Job struct {
+	ID          string          `json:"id"`
+	Profile     string          `json:"profile"`
+	Indicator   string          `json:"indicator"`
+	Prompt      string          `json:"prompt"`
+	Status      string          `json:"status"` // "queued", "running", "succeeded", "failed", or "cancelled"
+	LogPath     string          `json:"log_path"`
+	Error       string          `json:"error,omitempty"`
+	QueuedAt    time.Time       `json:"queued_at"`
+	StartedAt   time.Time       `json:"started_at,omitzero"`
+	EndedAt     time.Time       `json:"ended_at,omitzero"`
+	Schedule    string          `json:"schedule,omitempty"`   // schedule that queued the job
+	Permissions ToolPermissions `json:"permissions,omitzero"` // Vee MCP tools the profile allows
+}
+
+// ScheduleStatus is the state of a schedule, as shown on the dashboard.
+type ScheduleStatus struct {
+	Name       string    `json:"name"`
+	Cron       string    `json:"cron"`
+	Profile    string    `json:"profile"`
+	Prompt     string    `json:"prompt"`
+	Ephemeral  bool      `json:"ephemeral"`
+	NextRun    time.Time `json:"next_run,omitzero"`
+	LastRun    time.Time `json:"last_run,omitzero"`
+	LastResult string    `json:"last_result,omitempty"` // "started", "skipped", or "failed"
+	Error      string    `json:"error,omitempty"`       // invalid cron expression
+}
+
+// State is a snapshot of the daemon's state, returned by /api/state.
+type State struct {
+	Active     []*Session       `json:"active_sessions"`
+	Suspended  []*Session       `json:"suspended_sessions"`
+	Completed  []*Session       `json:"completed_sessions"`
+	Indexing   []IndexingTask   `json:"indexing_tasks"`
+	Jobs       []Job            `json:"jobs"`
+	Schedules  []ScheduleStatus `json:"schedules"`
+	IssueCount int              `json:"issue_count"`
+	EventID    string           `json:"event_id"` // latest event the state includes
+}
+
+// Event is a state change of the daemon, streamed by /api/events.
+type Event struct {
+	ID   string          `json:"id"` // "<epoch>-<seq>"; the epoch changes on every daemon start
+	Type string          `json:"type"`
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Status is the response of the endpoints changing a session or an entry.
+type Status struct {
+	Status    string `json:"status"`
+	SessionID string `json:"session_id,omitempty"` // session acted upon, when found by its window
+}
+
+// CreateSessionRequest registers a session started in a tmux window.
+type CreateSessionRequest struct {
+	ID             string          `json:"id"`
+	Profile        string          `json:"profile"`
+	Indicator      string          `json:"indicator"`
+	Preview        string          `json:"preview"`
+	WindowTarget   string          `json:"window_target"`
+	Ephemeral      bool            `json:"ephemeral"`
+	ComposePath    string          `json:"compose_path"`
+	ComposeProject string          `json:"compose_project"`
+	SystemPrompt   string          `json:"system_prompt"`
+	FeedbackIDs    []string        `json:"feedback_ids"`
+	Groups         []string        `json:"groups"`
+	PromptArg      string          `json:"prompt_arg"`
+	Next           string          `json:"next"`
+	Chain          string          `json:"chain"`
+	Schedule       string          `json:"schedule"`
+	ParentID       string          `json:"parent_id"`
+	Permissions    ToolPermissions `json:"permissions"`
+}
+
+// WindowRequest designates the session running in a tmux window.
+type WindowRequest struct {
+	WindowTarget string `json:"window_target"`
+}
+
+// ActivateRequest reactivates a suspended session in a new window.
+type ActivateRequest struct {
+	SessionID    string `json:"session_id"`
+	WindowTarget string `json:"window_target"`
+}
+
+// PreviewRequest sets the preview text of a session.
+type PreviewRequest struct {
+	SessionID string `json:"session_id"`
+	Preview   string `json:"preview"`
+}
+
+// SessionEndedRequest reports that the Claude process of a session exited.
+type SessionEndedRequest struct {
+	SessionID string `json:"session_id"`
+}
+
+// WindowStateRequest updates the window indicators of a session. Unset
+// fields are left unchanged.
+type WindowStateRequest struct {
+	SessionID      string `json:"session_id"`
+	Working        *bool  `json:"working,omitempty"`
+	Notification   *bool  `json:"notification,omitempty"`
+	PermissionMode string `json:"permission_mode,omitempty"`
+	Preview        string `json:"preview,omitempty"`
+	TranscriptPath string `json:"transcript_path,omitempty"`
+}
+
+// HookWindowStateRequest is the Claude hook payload posted by ephemeral
+// sessions, whose prompt becomes the session preview.
+type HookWindowStateRequest struct {
+	SessionID      string `json:"session_id"`
+	Working        *bool  `json:"working,omitempty"`
+	Notification   *bool  `json:"notification,omitempty"`
+	PermissionMode string `json:"permission_mode,omitempty"`
+	Prompt         string `json:"prompt,omitempty"`
+}
+
+// HookPreviewRequest is the Claude hook payload carrying the user prompt.
+type HookPreviewRequest struct {
+	Prompt string `json:"prompt"`
+}
+
+// NextStage describes the session to start for the next pipeline stage.
+type NextStage struct {
+	Profile   string `json:"profile"`
+	PromptArg string `json:"prompt_arg"`
+	Chain     string `json:"chain"`
+	Ephemeral bool   `json:"ephemeral"`
+}
+
+// SubmitJobRequest queues a background job.
+type SubmitJobRequest struct {
+	ID          string          `json:"id"`
+	Profile     string          `json:"profile"`
+	Indicator   string          `json:"indicator"`
+	Prompt      string          `json:"prompt"`
+	Args        []string        `json:"args"` // claude arguments, besides the prompt
+	FeedbackIDs []string        `json:"feedback_ids"`
+	Schedule    string          `json:"schedule"`
+	Permissions ToolPermissions `json:"permissions"`
+}
+
+// SessionPrompt is the system prompt of a session, with the feedback
+// examples injected into it and the session's outcome.
+type SessionPrompt struct {
+	SessionID    string                 `json:"session_id"`
+	Profile      string                 `json:"profile"`
+	Indicator    string                 `json:"indicator"`
+	SystemPrompt string                 `json:"system_prompt"`
+	Feedback     []feedback.RankedEntry `json:"feedback"`
+	Outcome      string                 `json:"outcome"`
+}
+
+// SessionToolCalls are the calls a session made to Vee's MCP tools.
+type SessionToolCalls struct {
+	SessionID string             `json:"session_id"`
+	Profile   string             `json:"profile"`
+	Indicator string             `json:"indicator"`
+	Calls     []history.ToolCall `json:"calls"`
+}
+
+// OutcomeRequest marks how a session turned out: "good", "bad", or "" to
+// clear the mark.
+type OutcomeRequest struct {
+	Outcome string `json:"outcome"`
+}
+
+// ResolveIssueRequest resolves a knowledge base issue.
+type ResolveIssueRequest struct {
+	Action string `json:"action"`
+}
+
+// FeedbackEditRequest rewords a feedback entry, or changes its kind.
+type FeedbackEditRequest struct {
+	Kind      string `json:"kind"` // "good" or "bad"
+	Statement string `json:"statement"`
+}
+
+// FeedbackScopeRequest moves a feedback entry between user and project
+// scope.
+type FeedbackScopeRequest struct {
+	Scope   string `json:"scope"`   // "user" or "project"
+	Project string `json:"project"` // absolute path, for project scope
+}
+
+// FeedbackPinRequest pins or unpins a feedback entry.
+type FeedbackPinRequest struct {
+	Pinned bool `json:"pinned"`
+}
+
+// FeedbackSample selects the feedback entries to inject into a session.
+type FeedbackSample struct {
+	Profile string
+	Project string
+	N       int    // entries of the profile
+	Pinned  int    // budget for pinned entries
+	Prompt  string // steers sampling towards relevant entries
+	Seed    string // makes the draw deterministic
+	Groups  []string
+	GroupN  int // entries targeting the groups
+	GlobalN int // entries targeting every profile
+}
+
+// Query encodes the sample as the query of /api/feedback/sample.
+func (s FeedbackSample) Query() url.Values {
+	q := url.Values{}
+	q.Set("profile", s.Profile)
+	q.Set("project", s.Project)
+	q.Set("n", strconv.Itoa(s.N))
+	q.Set("pinned", strconv.Itoa(s.Pinned))
+	for _, g := range s.Groups {
+		q.Add("group", g)
+	}
+	q.Set("group_n", strconv.Itoa(s.GroupN))
+	q.Set("global_n", strconv.Itoa(s.GlobalN))
+	if s.Prompt != "" {
+		q.Set("prompt", s.Prompt)
+	}
+	if s.Seed != "" {
+		q.Set("seed", s.Seed)
+	}
+	return q
+}
+
+// FeedbackFilter restricts the feedback entries listed. Empty fields match
+// everything.
+type FeedbackFilter struct {
+	Profile string
+	Scope   string
+	Project string
+	Kind    string
+}
+
+// Query encodes the filter as the query of /api/feedback and
+// /api/feedback/ranking.
+func (f FeedbackFilter) Query() url.Values {
+	q := url.Values{}
+	for name, v := range map[string]string{"profile": f.Profile, "scope": f.Scope, "project": f.Project, "kind": f.Kind} {
+		if v != "" {
+			q.Set(name, v)
+		}
+	}
+	return q
+}
+
+// Project is a project served by the user-level daemon.
+type Project struct {
+	Dir        string    `json:"dir"`
+	TmuxSocket string    `json:"tmux_socket"`
+	Port       int       `json:"port"`
+	StartedAt  time.Time `json:"started_at"`
+}
+
+// OpenedProject is the response of the user-level daemon once it serves a
+// project.
+type OpenedProject struct {
+	Port int `json:"port"`
+}
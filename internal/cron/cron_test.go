@@ -0,0 +1,74 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// Wednesday
+	from := time.Date(2025, 3, 5, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 3, 5, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 3, 5, 10, 45, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2025, 3, 6, 2, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 3, 5, 11, 0, 0, 0, time.UTC)},
+		{"0 9 * * mon", time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2025, 3, 9, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2025, 3, 6, 9, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2025, 3, 6, 10, 30, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"10-40/10 10 * * *", time.Date(2025, 3, 5, 10, 40, 0, 0, time.UTC)},
+		// Day of month or day of week when both are restricted
+		{"0 0 15 * fri", time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Both when either starts with *: every other day that is a Monday
+		{"0 9 */2 * mon", time.Date(2025, 3, 17, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestNextNever(t *testing.T) {
+	s, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Next(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Fatalf("expected February 30th to never fire, got %v", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"@sometimes",
+		"a * * * *",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q): expected an error", spec)
+		}
+	}
+}
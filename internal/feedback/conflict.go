@@ -0,0 +1,161 @@
+package feedback
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/lthms/vee/internal/kb"
+)
+
+// Similarity thresholds used when recording new feedback. Above
+// DuplicateThreshold, an entry of the same kind is considered the same
+// example; above ConflictThreshold, an entry of the opposite kind is
+// considered to contradict it.
+const (
+	DuplicateThreshold = 0.92
+	ConflictThreshold  = 0.85
+)
+
+// Conflict is an existing entry that a newly recorded statement collides
+// with.
+type Conflict struct {
+	Entry
+	Similarity float64 `json:"similarity"`
+}
+
+// RecordResult describes the outcome of RecordChecked.
+type RecordResult struct {
+	// ID of the recorded entry, or of the existing entry the statement was
+	// merged into.
+	ID string `json:"id"`
+	// Duplicate is the existing entry the statement was merged into, if any.
+	Duplicate *Conflict `json:"duplicate,omitempty"`
+	// Conflicts are existing entries of the opposite kind that closely
+	// match the statement. When non-empty and the record was not forced,
+	// nothing was recorded.
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+}
+
+// Recorded reports whether a new entry was inserted.
+func (r RecordResult) Recorded() bool {
+	return r.ID != "" && r.Duplicate == nil
+}
+
+// RecordChecked records a feedback entry after comparing it with the
+// existing entries for the same profile that are visible from project.
+//
+// A near-duplicate of the same kind is merged instead of inserted: the
+// existing entry is kept, and widened to user scope if the new statement
+// asks for it. A close match of the opposite kind is reported as a
+// conflict and blocks the record unless force is set.
+//
+// Comparison uses embeddings when a model is configured (see
+// EnableRelevance), and falls back to normalized text equality otherwise,
+// or when the model cannot be reached.
+func (s *Store) RecordChecked(profile, kind, statement, scope, project string, force bool) (RecordResult, error) {
+	candidates, stored, err := s.candidates(profile, project)
+	if err != nil {
+		return RecordResult{}, err
+	}
+
+	var embedding, similarities []float64
+	if s.model != nil && len(candidates) > 0 {
+		embedding, similarities, err = s.similarities(statement, candidates, stored)
+		if err != nil {
+			slog.Warn("feedback similarity unavailable, comparing text", "error", err)
+			embedding, similarities = nil, nil
+		}
+	}
+	if similarities == nil {
+		similarities = make([]float64, len(candidates))
+		for i, c := range candidates {
+			if normalizeStatement(c.Statement) == normalizeStatement(statement) {
+				similarities[i] = 1
+			}
+		}
+	}
+
+	var result RecordResult
+	for i, c := range candidates {
+		sim := similarities[i]
+		switch {
+		case c.Kind == kind && sim >= DuplicateThreshold:
+			if result.Duplicate == nil || sim > result.Duplicate.Similarity {
+				result.Duplicate = &Conflict{Entry: c, Similarity: sim}
+			}
+		case c.Kind != kind && sim >= ConflictThreshold:
+			result.Conflicts = append(result.Conflicts, Conflict{Entry: c, Similarity: sim})
+		}
+	}
+	sort.SliceStable(result.Conflicts, func(i, j int) bool {
+		return result.Conflicts[i].Similarity > result.Conflicts[j].Similarity
+	})
+
+	if len(result.Conflicts) > 0 && !force {
+		return result, nil
+	}
+
+	if dup := result.Duplicate; dup != nil {
+		if scope == "user" && dup.Scope == "project" {
+			if err := s.SetScope(dup.ID, "user", ""); err != nil {
+				return RecordResult{}, err
+			}
+			dup.Scope, dup.Project = "user", ""
+		}
+		result.ID = dup.ID
+		return result, nil
+	}
+
+	id, err := s.Record(profile, kind, statement, scope, project)
+	if err != nil {
+		return RecordResult{}, err
+	}
+	if embedding != nil {
+		if _, err := s.db.Exec(
+			`UPDATE feedback SET embedding = ?, embedding_model = ? WHERE id = ?`,
+			kb.EmbeddingToBlob(embedding), s.embeddingModel, id,
+		); err != nil {
+			return RecordResult{}, fmt.Errorf("store embedding: %w", err)
+		}
+	}
+	result.ID = id
+	return result, nil
+}
+
+// candidates returns the entries of a profile visible from project, along
+// with their stored embeddings.
+func (s *Store) candidates(profile, project string) ([]Entry, []storedEmbedding, error) {
+	rows, err := s.db.Query(
+		`SELECT id, profile, kind, statement, scope, project, created_at, pinned, embedding, embedding_model
+		 FROM feedback
+		 WHERE profile = ?
+		   AND (scope = 'user' OR (scope = 'project' AND project = ?))
+		 ORDER BY created_at DESC, id ASC`,
+		profile, project,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query feedback: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	var stored []storedEmbedding
+	for rows.Next() {
+		var e Entry
+		var se storedEmbedding
+		if err := rows.Scan(&e.ID, &e.Profile, &e.Kind, &e.Statement, &e.Scope, &e.Project, &e.CreatedAt, &e.Pinned, &se.blob, &se.model); err != nil {
+			return nil, nil, fmt.Errorf("scan feedback: %w", err)
+		}
+		entries = append(entries, e)
+		stored = append(stored, se)
+	}
+	return entries, stored, rows.Err()
+}
+
+// normalizeStatement folds case and whitespace so trivially different
+// statements compare equal.
+func normalizeStatement(statement string) string {
+	return strings.Join(strings.Fields(strings.ToLower(statement)), " ")
+}
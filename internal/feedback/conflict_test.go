@@ -0,0 +1,146 @@
+package feedback
+
+import (
+	"errors"
+	"testing"
+)
+
+func countEntries(t *testing.T, s *Store) int {
+	t.Helper()
+	entries, err := s.List(ListFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
+
+func TestRecordCheckedMergesDuplicates(t *testing.T) {
+	s := openTestStore(t)
+	s.EnableRelevance(&topicModel{}, "test-model", 0)
+
+	first, err := s.RecordChecked("vibe", "good", "Wrap each migration in a transaction", "project", "/p", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.Recorded() {
+		t.Fatalf("expected first statement to be recorded, got %+v", first)
+	}
+
+	second, err := s.RecordChecked("vibe", "good", "Run every migration inside a transaction", "user", "/p", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Recorded() || second.Duplicate == nil || second.ID != first.ID {
+		t.Fatalf("expected merge into %s, got %+v", first.ID, second)
+	}
+	if n := countEntries(t, s); n != 1 {
+		t.Fatalf("expected 1 entry, got %d", n)
+	}
+
+	e, err := s.Get(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Scope != "user" {
+		t.Fatalf("expected merged entry widened to user scope, got %s", e.Scope)
+	}
+}
+
+func TestRecordCheckedFlagsConflicts(t *testing.T) {
+	s := openTestStore(t)
+	s.EnableRelevance(&topicModel{}, "test-model", 0)
+
+	bad, _ := s.RecordChecked("vibe", "bad", "Skipping the transaction around a migration", "user", "", false)
+
+	result, err := s.RecordChecked("vibe", "good", "Wrap each migration in a transaction", "user", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Recorded() || len(result.Conflicts) != 1 || result.Conflicts[0].ID != bad.ID {
+		t.Fatalf("expected a conflict with %s, got %+v", bad.ID, result)
+	}
+	if n := countEntries(t, s); n != 1 {
+		t.Fatalf("expected conflicting statement not to be recorded, got %d entries", n)
+	}
+
+	forced, err := s.RecordChecked("vibe", "good", "Wrap each migration in a transaction", "user", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !forced.Recorded() || len(forced.Conflicts) != 1 {
+		t.Fatalf("expected forced record with conflict reported, got %+v", forced)
+	}
+
+	other, err := s.RecordChecked("vibe", "good", "Write commit messages in the imperative", "user", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !other.Recorded() || len(other.Conflicts) != 0 {
+		t.Fatalf("expected unrelated statement recorded cleanly, got %+v", other)
+	}
+}
+
+func TestRecordCheckedIgnoresOtherProfilesAndProjects(t *testing.T) {
+	s := openTestStore(t)
+	s.EnableRelevance(&topicModel{}, "test-model", 0)
+
+	s.Record("normal", "bad", "Skipping a migration transaction", "user", "")
+	s.Record("vibe", "bad", "Skipping a migration transaction", "project", "/other")
+
+	result, err := s.RecordChecked("vibe", "good", "Wrap each migration in a transaction", "project", "/p", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Recorded() || len(result.Conflicts) != 0 {
+		t.Fatalf("expected no conflicts outside profile and project, got %+v", result)
+	}
+}
+
+func TestRecordCheckedWithoutModel(t *testing.T) {
+	s := openTestStore(t)
+
+	first, _ := s.RecordChecked("vibe", "good", "Keep functions small", "user", "", false)
+	dup, err := s.RecordChecked("vibe", "good", "  keep functions   SMALL ", "user", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dup.Recorded() || dup.ID != first.ID {
+		t.Fatalf("expected exact duplicate merged, got %+v", dup)
+	}
+
+	conflict, err := s.RecordChecked("vibe", "bad", "Keep functions small", "user", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conflict.Recorded() || len(conflict.Conflicts) != 1 {
+		t.Fatalf("expected conflict on opposite kind, got %+v", conflict)
+	}
+}
+
+func TestRecordCheckedFallsBackToTextWhenModelFails(t *testing.T) {
+	s := openTestStore(t)
+	model := &topicModel{}
+	s.EnableRelevance(model, "test-model", 0)
+
+	first, err := s.RecordChecked("vibe", "good", "Keep functions small", "user", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	model.err = errors.New("ollama unreachable")
+	dup, err := s.RecordChecked("vibe", "good", "keep functions small", "user", "", false)
+	if err != nil {
+		t.Fatalf("expected the record to degrade, got %v", err)
+	}
+	if dup.Recorded() || dup.ID != first.ID {
+		t.Fatalf("expected exact duplicate merged, got %+v", dup)
+	}
+
+	other, err := s.RecordChecked("vibe", "good", "Name tests after behavior", "user", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !other.Recorded() {
+		t.Fatalf("expected a new entry, got %+v", other)
+	}
+}
@@ -3,7 +3,9 @@ package feedback
 import (
 	"database/sql"
 	"fmt"
+	"sync"
 
+	"github.com/lthms/vee/internal/kb"
 	_ "modernc.org/sqlite"
 )
 
@@ -16,11 +18,23 @@ type Entry struct {
 	Scope     string `json:"scope"`
 	Project   string `json:"project"`
 	CreatedAt string `json:"created_at"`
+	Pinned    bool   `json:"pinned"`
 }
 
 // Store provides persistent feedback storage backed by SQLite.
 type Store struct {
 	db *sql.DB
+
+	// Relevance sampling (see EnableRelevance). When model is nil, sampling
+	// uses recency alone.
+	model          kb.Model
+	embeddingModel string
+	relevance      float64
+
+	// Embeddings of the project file's entries, which have no row to cache
+	// them in, by entry ID.
+	mu             sync.Mutex
+	fileEmbeddings map[string]storedEmbedding
 }
 
 // Open opens (or creates) the feedback database at the given path.
@@ -31,12 +45,12 @@ func Open(dbPath string) (*Store, error) {
 		return nil, fmt.Errorf("open feedback db: %w", err)
 	}
 
-	if err := migrate(db); err != nil {
+	if err := migrateDB(db, dbPath); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("migrate feedback db: %w", err)
 	}
 
-	return &Store{db: db}, nil
+	return &Store{db: db, fileEmbeddings: make(map[string]storedEmbedding)}, nil
 }
 
 // Close closes the database.
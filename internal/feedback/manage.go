@@ -0,0 +1,161 @@
+package feedback
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotFound is returned when no feedback entry matches the given ID.
+var ErrNotFound = errors.New("feedback entry not found")
+
+// ListFilter narrows the entries returned by List.
+// Empty fields match everything.
+type ListFilter struct {
+	Profile string
+	Scope   string
+	Project string
+	Kind    string
+}
+
+// List returns all feedback entries matching the filter, newest first.
+func (s *Store) List(f ListFilter) ([]Entry, error) {
+	var where []string
+	var args []any
+	if f.Profile != "" {
+		where = append(where, "profile = ?")
+		args = append(args, f.Profile)
+	}
+	if f.Scope != "" {
+		where = append(where, "scope = ?")
+		args = append(args, f.Scope)
+	}
+	if f.Project != "" {
+		where = append(where, "project = ?")
+		args = append(args, f.Project)
+	}
+	if f.Kind != "" {
+		where = append(where, "kind = ?")
+		args = append(args, f.Kind)
+	}
+
+	query := `SELECT id, profile, kind, statement, scope, project, created_at, pinned FROM feedback`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at DESC, profile ASC, id ASC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list feedback: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.ID, &e.Profile, &e.Kind, &e.Statement, &e.Scope, &e.Project, &e.CreatedAt, &e.Pinned); err != nil {
+			return nil, fmt.Errorf("scan feedback: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Get retrieves a single feedback entry by ID.
+func (s *Store) Get(id string) (*Entry, error) {
+	var e Entry
+	err := s.db.QueryRow(
+		`SELECT id, profile, kind, statement, scope, project, created_at, pinned
+		 FROM feedback WHERE id = ?`, id,
+	).Scan(&e.ID, &e.Profile, &e.Kind, &e.Statement, &e.Scope, &e.Project, &e.CreatedAt, &e.Pinned)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("get feedback %s: %w", id, err)
+	}
+	return &e, nil
+}
+
+// Update rewrites the kind and statement of an existing entry. The stored
+// embedding is cleared so it is recomputed from the new wording.
+func (s *Store) Update(id, kind, statement string) error {
+	if kind != "good" && kind != "bad" {
+		return fmt.Errorf("kind must be 'good' or 'bad', got %q", kind)
+	}
+	if strings.TrimSpace(statement) == "" {
+		return fmt.Errorf("statement must not be empty")
+	}
+
+	result, err := s.db.Exec(
+		`UPDATE feedback SET kind = ?, statement = ?, embedding = NULL, embedding_model = '' WHERE id = ?`,
+		kind, statement, id,
+	)
+	if err != nil {
+		return fmt.Errorf("update feedback: %w", err)
+	}
+	return checkAffected(result, id)
+}
+
+// SetScope moves an entry between user and project scope. project is
+// required for scope "project" and ignored for scope "user".
+func (s *Store) SetScope(id, scope, project string) error {
+	switch scope {
+	case "user":
+		project = ""
+	case "project":
+		if project == "" {
+			return fmt.Errorf("project is required for project scope")
+		}
+	default:
+		return fmt.Errorf("scope must be 'user' or 'project', got %q", scope)
+	}
+
+	result, err := s.db.Exec(
+		`UPDATE feedback SET scope = ?, project = ? WHERE id = ?`,
+		scope, project, id,
+	)
+	if err != nil {
+		return fmt.Errorf("set feedback scope: %w", err)
+	}
+	return checkAffected(result, id)
+}
+
+// SetPinned marks or unmarks an entry as pinned. Pinned entries bypass
+// sampling and are always injected, within their own budget.
+func (s *Store) SetPinned(id string, pinned bool) error {
+	result, err := s.db.Exec(`UPDATE feedback SET pinned = ? WHERE id = ?`, pinned, id)
+	if err != nil {
+		return fmt.Errorf("set feedback pinned: %w", err)
+	}
+	return checkAffected(result, id)
+}
+
+// Delete removes a feedback entry and its usage history.
+func (s *Store) Delete(id string) error {
+	result, err := s.db.Exec(`DELETE FROM feedback WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete feedback: %w", err)
+	}
+	if err := checkAffected(result, id); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`DELETE FROM feedback_usage WHERE entry_id = ?`, id); err != nil {
+		return fmt.Errorf("delete feedback usage: %w", err)
+	}
+	return nil
+}
+
+// checkAffected returns ErrNotFound when an UPDATE or DELETE matched no rows.
+func checkAffected(result sql.Result, id string) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package feedback
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestListFilters(t *testing.T) {
+	s := openTestStore(t)
+
+	s.Record("vibe", "good", "Vibe user", "user", "")
+	s.Record("vibe", "bad", "Vibe project", "project", "/my/project")
+	s.Record("normal", "good", "Normal user", "user", "")
+
+	all, err := s.List(ListFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(all))
+	}
+
+	tests := []struct {
+		name   string
+		filter ListFilter
+		want   string
+	}{
+		{"profile", ListFilter{Profile: "normal"}, "Normal user"},
+		{"scope", ListFilter{Profile: "vibe", Scope: "project"}, "Vibe project"},
+		{"project", ListFilter{Project: "/my/project"}, "Vibe project"},
+		{"kind", ListFilter{Kind: "bad"}, "Vibe project"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := s.List(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("expected 1 entry, got %d", len(entries))
+			}
+			if entries[0].Statement != tt.want {
+				t.Fatalf("statement = %q, want %q", entries[0].Statement, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	s := openTestStore(t)
+
+	id, _ := s.Record("vibe", "good", "Old wording", "user", "")
+
+	if err := s.Update(id, "bad", "New wording"); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := s.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Kind != "bad" || e.Statement != "New wording" {
+		t.Fatalf("unexpected entry after update: %+v", e)
+	}
+}
+
+func TestUpdateRejectsInvalidInput(t *testing.T) {
+	s := openTestStore(t)
+
+	id, _ := s.Record("vibe", "good", "Statement", "user", "")
+
+	if err := s.Update(id, "meh", "Statement"); err == nil {
+		t.Fatal("expected error for invalid kind")
+	}
+	if err := s.Update(id, "good", "   "); err == nil {
+		t.Fatal("expected error for empty statement")
+	}
+}
+
+func TestSetScope(t *testing.T) {
+	s := openTestStore(t)
+
+	id, _ := s.Record("vibe", "good", "Statement", "project", "/my/project")
+
+	if err := s.SetScope(id, "user", "/ignored"); err != nil {
+		t.Fatal(err)
+	}
+	e, _ := s.Get(id)
+	if e.Scope != "user" || e.Project != "" {
+		t.Fatalf("expected user scope with empty project, got %+v", e)
+	}
+
+	if err := s.SetScope(id, "project", "/other/project"); err != nil {
+		t.Fatal(err)
+	}
+	e, _ = s.Get(id)
+	if e.Scope != "project" || e.Project != "/other/project" {
+		t.Fatalf("expected project scope, got %+v", e)
+	}
+
+	if err := s.SetScope(id, "project", ""); err == nil {
+		t.Fatal("expected error for project scope without project")
+	}
+}
+
+func TestDelete(t *testing.T) {
+	s := openTestStore(t)
+
+	id, _ := s.Record("vibe", "good", "Statement", "user", "")
+
+	if err := s.Delete(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(id); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestMissingEntryReturnsErrNotFound(t *testing.T) {
+	s := openTestStore(t)
+
+	if err := s.Update("missing", "good", "Statement"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Update: expected ErrNotFound, got %v", err)
+	}
+	if err := s.SetScope("missing", "user", ""); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("SetScope: expected ErrNotFound, got %v", err)
+	}
+	if err := s.Delete("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Delete: expected ErrNotFound, got %v", err)
+	}
+}
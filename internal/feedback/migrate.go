@@ -3,31 +3,72 @@ package feedback
 import (
 	"database/sql"
 	"fmt"
+
+	"github.com/lthms/vee/internal/migrate"
 )
 
-func migrate(db *sql.DB) error {
-	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS feedback (
-		id         TEXT PRIMARY KEY,
-		profile    TEXT NOT NULL,
-		kind       TEXT NOT NULL CHECK(kind IN ('good','bad')),
-		statement  TEXT NOT NULL,
-		scope      TEXT NOT NULL CHECK(scope IN ('user','project')),
-		project    TEXT NOT NULL DEFAULT '',
-		created_at TEXT NOT NULL
-	)`)
-	if err != nil {
-		return fmt.Errorf("create feedback table: %w", err)
-	}
+// migrations is the schema history of the feedback database. Versions 1 to
+// 4 predate schema versioning and may find their changes already applied.
+var migrations = []migrate.Migration{
+	{Version: 1, Name: "create feedback table", Up: func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS feedback (
+			id         TEXT PRIMARY KEY,
+			profile    TEXT NOT NULL,
+			kind       TEXT NOT NULL CHECK(kind IN ('good','bad')),
+			statement  TEXT NOT NULL,
+			scope      TEXT NOT NULL CHECK(scope IN ('user','project')),
+			project    TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL
+		)`); err != nil {
+			return fmt.Errorf("create feedback table: %w", err)
+		}
 
-	// Migrate legacy "mode" column to "profile" if the old schema exists.
-	var colName string
-	row := db.QueryRow(`SELECT name FROM pragma_table_info('feedback') WHERE name = 'mode'`)
-	if err := row.Scan(&colName); err == nil {
-		_, err := db.Exec(`ALTER TABLE feedback RENAME COLUMN mode TO profile`)
+		// Databases from before profiles were called profiles.
+		legacy, err := migrate.HasColumn(tx, "feedback", "mode")
 		if err != nil {
-			return fmt.Errorf("rename mode→profile column: %w", err)
+			return err
+		}
+		if legacy {
+			if _, err := tx.Exec(`ALTER TABLE feedback RENAME COLUMN mode TO profile`); err != nil {
+				return fmt.Errorf("rename mode→profile column: %w", err)
+			}
+		}
+		return nil
+	}},
+	{Version: 2, Name: "add feedback embeddings", Up: func(tx *sql.Tx) error {
+		if err := migrate.AddColumn(tx, "feedback", "embedding", "BLOB"); err != nil {
+			return err
 		}
-	}
+		return migrate.AddColumn(tx, "feedback", "embedding_model", "TEXT NOT NULL DEFAULT ''")
+	}},
+	{Version: 3, Name: "add pinned flag", Up: func(tx *sql.Tx) error {
+		return migrate.AddColumn(tx, "feedback", "pinned", "INTEGER NOT NULL DEFAULT 0")
+	}},
+	{Version: 4, Name: "track usage and session outcomes", Up: func(tx *sql.Tx) error {
+		// Which entries were injected into which session, and how each
+		// session turned out according to the user.
+		if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS feedback_usage (
+			session_id TEXT NOT NULL,
+			entry_id   TEXT NOT NULL,
+			used_at    TEXT NOT NULL,
+			PRIMARY KEY (session_id, entry_id)
+		)`); err != nil {
+			return fmt.Errorf("create feedback_usage table: %w", err)
+		}
+		if _, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_feedback_usage_entry ON feedback_usage(entry_id)`); err != nil {
+			return fmt.Errorf("create feedback_usage index: %w", err)
+		}
+		if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS session_outcome (
+			session_id TEXT PRIMARY KEY,
+			outcome    TEXT NOT NULL CHECK(outcome IN ('good','bad')),
+			marked_at  TEXT NOT NULL
+		)`); err != nil {
+			return fmt.Errorf("create session_outcome table: %w", err)
+		}
+		return nil
+	}},
+}
 
-	return nil
+func migrateDB(db *sql.DB, dbPath string) error {
+	return migrate.Run(db, dbPath, migrations)
 }
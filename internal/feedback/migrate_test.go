@@ -0,0 +1,223 @@
+package feedback
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lthms/vee/internal/migrate"
+)
+
+// fixtures holds the schema of every past version of the feedback database,
+// each with one entry, keyed by a short description. Versions before
+// schema_version existed are all at version 0.
+var fixtures = map[string][]string{
+	"legacy mode column": {
+		`CREATE TABLE feedback (
+			id TEXT PRIMARY KEY, mode TEXT NOT NULL,
+			kind TEXT NOT NULL CHECK(kind IN ('good','bad')), statement TEXT NOT NULL,
+			scope TEXT NOT NULL CHECK(scope IN ('user','project')),
+			project TEXT NOT NULL DEFAULT '', created_at TEXT NOT NULL)`,
+		`INSERT INTO feedback VALUES ('e1', 'vibe', 'good', 'Keep it short', 'user', '', '2025-01-01')`,
+	},
+	"profile column": {
+		`CREATE TABLE feedback (
+			id TEXT PRIMARY KEY, profile TEXT NOT NULL,
+			kind TEXT NOT NULL CHECK(kind IN ('good','bad')), statement TEXT NOT NULL,
+			scope TEXT NOT NULL CHECK(scope IN ('user','project')),
+			project TEXT NOT NULL DEFAULT '', created_at TEXT NOT NULL)`,
+		`INSERT INTO feedback VALUES ('e1', 'vibe', 'good', 'Keep it short', 'user', '', '2025-01-01')`,
+	},
+	"embeddings": {
+		`CREATE TABLE feedback (
+			id TEXT PRIMARY KEY, profile TEXT NOT NULL,
+			kind TEXT NOT NULL CHECK(kind IN ('good','bad')), statement TEXT NOT NULL,
+			scope TEXT NOT NULL CHECK(scope IN ('user','project')),
+			project TEXT NOT NULL DEFAULT '', created_at TEXT NOT NULL,
+			embedding BLOB, embedding_model TEXT NOT NULL DEFAULT '')`,
+		`INSERT INTO feedback VALUES ('e1', 'vibe', 'good', 'Keep it short', 'user', '', '2025-01-01', NULL, '')`,
+	},
+	"pinned": {
+		`CREATE TABLE feedback (
+			id TEXT PRIMARY KEY, profile TEXT NOT NULL,
+			kind TEXT NOT NULL CHECK(kind IN ('good','bad')), statement TEXT NOT NULL,
+			scope TEXT NOT NULL CHECK(scope IN ('user','project')),
+			project TEXT NOT NULL DEFAULT '', created_at TEXT NOT NULL,
+			embedding BLOB, embedding_model TEXT NOT NULL DEFAULT '',
+			pinned INTEGER NOT NULL DEFAULT 0)`,
+		`INSERT INTO feedback VALUES ('e1', 'vibe', 'good', 'Keep it short', 'user', '', '2025-01-01', NULL, '', 1)`,
+	},
+	"usage tracking": {
+		`CREATE TABLE feedback (
+			id TEXT PRIMARY KEY, profile TEXT NOT NULL,
+			kind TEXT NOT NULL CHECK(kind IN ('good','bad')), statement TEXT NOT NULL,
+			scope TEXT NOT NULL CHECK(scope IN ('user','project')),
+			project TEXT NOT NULL DEFAULT '', created_at TEXT NOT NULL,
+			embedding BLOB, embedding_model TEXT NOT NULL DEFAULT '',
+			pinned INTEGER NOT NULL DEFAULT 0)`,
+		`CREATE TABLE feedback_usage (
+			session_id TEXT NOT NULL, entry_id TEXT NOT NULL, used_at TEXT NOT NULL,
+			PRIMARY KEY (session_id, entry_id))`,
+		`CREATE TABLE session_outcome (
+			session_id TEXT PRIMARY KEY,
+			outcome TEXT NOT NULL CHECK(outcome IN ('good','bad')), marked_at TEXT NOT NULL)`,
+		`INSERT INTO feedback VALUES ('e1', 'vibe', 'good', 'Keep it short', 'user', '', '2025-01-01', NULL, '', 0)`,
+		`INSERT INTO feedback_usage VALUES ('s1', 'e1', '2025-01-01T00:00:00Z')`,
+	},
+}
+
+func writeFixture(t *testing.T, path string, stmts []string) {
+	t.Helper()
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, s := range stmts {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatalf("fixture: %v", err)
+		}
+	}
+}
+
+func TestOpenUpgradesPastVersions(t *testing.T) {
+	for name, stmts := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "feedback.db")
+			writeFixture(t, path, stmts)
+
+			s, err := Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			if v, _ := migrate.Version(s.db); v != len(migrations) {
+				t.Fatalf("expected version %d, got %d", len(migrations), v)
+			}
+			if _, err := os.Stat(migrate.BackupPath(path, 0)); err != nil {
+				t.Fatalf("expected a backup: %v", err)
+			}
+
+			e, err := s.Get("e1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e.Profile != "vibe" || e.Statement != "Keep it short" {
+				t.Fatalf("entry not preserved: %+v", e)
+			}
+			if err := s.SetPinned("e1", true); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.RecordUsage("s2", []string{"e1"}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestOpenRefusesNewerDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feedback.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.db.Exec(`INSERT INTO schema_version (version, name, applied_at) VALUES (?, 'future', '')`, len(migrations)+1); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	if _, err := Open(path); !errors.Is(err, migrate.ErrTooNew) {
+		t.Fatalf("expected ErrTooNew opening a database from a newer version, got %v", err)
+	}
+}
+
+func TestOpenRunsRemainingMigrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feedback.db")
+	writeFixture(t, path, append(fixtures["embeddings"],
+		`CREATE TABLE schema_version (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at TEXT NOT NULL)`,
+		`INSERT INTO schema_version VALUES (1, 'create feedback table', 'before'), (2, 'add feedback embeddings', 'before')`,
+	))
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	rows, err := s.db.Query(`SELECT version, applied_at FROM schema_version ORDER BY version`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var applied []string
+	for rows.Next() {
+		var v int
+		var at string
+		if err := rows.Scan(&v, &at); err != nil {
+			t.Fatal(err)
+		}
+		if (v <= 2) != (at == "before") {
+			t.Errorf("version %d applied at %q", v, at)
+		}
+		applied = append(applied, at)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("expected %d versions, got %d", len(migrations), len(applied))
+	}
+
+	if _, err := os.Stat(migrate.BackupPath(path, 2)); err != nil {
+		t.Fatalf("expected a backup of version 2: %v", err)
+	}
+	if _, err := os.Stat(migrate.BackupPath(path, 0)); !os.IsNotExist(err) {
+		t.Fatalf("expected no backup of version 0, got %v", err)
+	}
+	if e, err := s.Get("e1"); err != nil || e.Pinned {
+		t.Fatalf("entry not preserved: %+v (%v)", e, err)
+	}
+}
+
+func TestOpenUpToDateKeepsBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feedback.db")
+	writeFixture(t, path, fixtures["profile column"])
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Record("vibe", "good", "Added after the upgrade", "user", "")
+	s.Close()
+
+	backupPath := migrate.BackupPath(path, 0)
+	before, err := os.ReadFile(backupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(backupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	after, err := os.ReadFile(backupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Fatal("backup rewritten by an open with nothing to migrate")
+	}
+	if again, err := os.Stat(backupPath); err != nil || !again.ModTime().Equal(info.ModTime()) {
+		t.Fatalf("backup touched by an open with nothing to migrate: %v", err)
+	}
+	if _, err := os.Stat(migrate.BackupPath(path, len(migrations))); !os.IsNotExist(err) {
+		t.Fatalf("expected no backup of the latest version, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package feedback
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFile is the path of the repo-committed feedback file, relative to
+// the project root.
+const ProjectFile = ".vee/feedback.yaml"
+
+// projectFileEntry is a single example in the project feedback file.
+type projectFileEntry struct {
+	Profile   string `yaml:"profile"`
+	Kind      string `yaml:"kind"`
+	Statement string `yaml:"statement"`
+}
+
+// LoadProjectFile reads the feedback file of the given project and returns
+// its examples as project-scope entries. IDs are derived from the content,
+// so they are stable across reads and checkouts. A missing file yields no
+// entries.
+func LoadProjectFile(project string) ([]Entry, error) {
+	path := filepath.Join(project, ProjectFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", ProjectFile, err)
+	}
+
+	var raw []projectFileEntry
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", ProjectFile, err)
+	}
+
+	createdAt := ""
+	if info, err := os.Stat(path); err == nil {
+		createdAt = info.ModTime().Format("2006-01-02")
+	}
+
+	entries := make([]Entry, 0, len(raw))
+	for i, r := range raw {
+		statement := strings.TrimSpace(r.Statement)
+		switch {
+		case r.Profile == "":
+			return nil, fmt.Errorf("%s: entry %d: missing profile", ProjectFile, i+1)
+		case r.Kind != "good" && r.Kind != "bad":
+			return nil, fmt.Errorf("%s: entry %d: kind must be 'good' or 'bad', got %q", ProjectFile, i+1, r.Kind)
+		case statement == "":
+			return nil, fmt.Errorf("%s: entry %d: missing statement", ProjectFile, i+1)
+		}
+		entries = append(entries, Entry{
+			ID:        projectEntryID(r.Profile, r.Kind, statement),
+			Profile:   r.Profile,
+			Kind:      r.Kind,
+			Statement: statement,
+			Scope:     "project",
+			Project:   project,
+			CreatedAt: createdAt,
+		})
+	}
+	return entries, nil
+}
+
+// WriteProjectFile merges entries into the feedback file of the given
+// project, skipping examples already present, and returns how many were
+// added. The file is kept sorted by profile so diffs stay readable, and left
+// untouched when there is nothing to add.
+func WriteProjectFile(project string, entries []Entry) (int, error) {
+	existing, err := LoadProjectFile(project)
+	if err != nil {
+		return 0, err
+	}
+
+	seen := make(map[string]bool)
+	var out []projectFileEntry
+	for _, e := range existing {
+		seen[e.ID] = true
+		out = append(out, projectFileEntry{Profile: e.Profile, Kind: e.Kind, Statement: e.Statement})
+	}
+
+	added := 0
+	for _, e := range entries {
+		statement := strings.TrimSpace(e.Statement)
+		id := projectEntryID(e.Profile, e.Kind, statement)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, projectFileEntry{Profile: e.Profile, Kind: e.Kind, Statement: statement})
+		added++
+	}
+
+	if added == 0 {
+		return 0, nil
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Profile < out[j].Profile
+	})
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(out); err != nil {
+		return 0, fmt.Errorf("encode %s: %w", ProjectFile, err)
+	}
+	enc.Close()
+
+	path := filepath.Join(project, ProjectFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, fmt.Errorf("create %s: %w", filepath.Dir(ProjectFile), err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return 0, fmt.Errorf("write %s: %w", ProjectFile, err)
+	}
+	return added, nil
+}
+
+// projectEntryPrefix starts the IDs of the examples of the project file.
+const projectEntryPrefix = "project-file:"
+
+// projectEntryID derives a stable ID for an example of the project file.
+func projectEntryID(profile, kind, statement string) string {
+	sum := sha256.Sum256([]byte(profile + "\x00" + kind + "\x00" + statement))
+	return fmt.Sprintf("%s%x", projectEntryPrefix, sum[:8])
+}
+
+// isProjectEntryID reports whether id is that of an example of the project
+// file, which has no row in the database.
+func isProjectEntryID(id string) bool {
+	return strings.HasPrefix(id, projectEntryPrefix)
+}
@@ -0,0 +1,190 @@
+package feedback
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeProjectFile(t *testing.T, project, content string) {
+	t.Helper()
+	path := filepath.Join(project, ProjectFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadProjectFile(t *testing.T) {
+	project := t.TempDir()
+
+	entries, err := LoadProjectFile(project)
+	if err != nil || entries != nil {
+		t.Fatalf("missing file: got %v, %v", entries, err)
+	}
+
+	writeProjectFile(t, project, `
+- profile: vibe
+  kind: good
+  statement: Run make check before committing
+- profile: "*"
+  kind: bad
+  statement: Never push to main
+`)
+
+	entries, err = LoadProjectFile(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Profile != "vibe" || e.Kind != "good" || e.Scope != "project" || e.Project != project {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+
+	again, _ := LoadProjectFile(project)
+	if again[0].ID != e.ID {
+		t.Fatal("expected stable IDs across reads")
+	}
+}
+
+func TestLoadProjectFileRejectsInvalidKind(t *testing.T) {
+	project := t.TempDir()
+	writeProjectFile(t, project, "- profile: vibe\n  kind: meh\n  statement: x\n")
+
+	if _, err := LoadProjectFile(project); err == nil {
+		t.Fatal("expected error for invalid kind")
+	}
+}
+
+func TestWriteProjectFileMerges(t *testing.T) {
+	project := t.TempDir()
+	writeProjectFile(t, project, "- profile: vibe\n  kind: good\n  statement: Existing\n")
+
+	added, err := WriteProjectFile(project, []Entry{
+		{Profile: "normal", Kind: "bad", Statement: "New"},
+		{Profile: "vibe", Kind: "good", Statement: "Existing"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 1 {
+		t.Fatalf("expected 1 added entry, got %d", added)
+	}
+
+	entries, err := LoadProjectFile(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Profile != "normal" || entries[1].Profile != "vibe" {
+		t.Fatalf("expected entries sorted by profile, got %s, %s", entries[0].Profile, entries[1].Profile)
+	}
+}
+
+func TestSampleMergesProjectEntries(t *testing.T) {
+	s := openTestStore(t)
+	project := t.TempDir()
+
+	s.Record("vibe", "good", "Local", "user", "")
+	s.Record("vibe", "good", "Shared", "project", project)
+	writeProjectFile(t, project, `
+- profile: vibe
+  kind: good
+  statement: Shared
+- profile: vibe
+  kind: bad
+  statement: From file
+- profile: normal
+  kind: good
+  statement: Other profile
+`)
+	fileEntries, err := LoadProjectFile(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := s.SampleWith(SampleOptions{Profile: "vibe", Project: project, N: 10, ProjectEntries: fileEntries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int)
+	for _, e := range entries {
+		counts[e.Statement]++
+	}
+	if len(entries) != 3 || counts["Local"] != 1 || counts["Shared"] != 1 || counts["From file"] != 1 {
+		t.Fatalf("unexpected sample: %v", counts)
+	}
+}
+
+func TestSampleCachesProjectEntryEmbeddings(t *testing.T) {
+	s := openTestStore(t)
+	model := &topicModel{}
+	s.EnableRelevance(model, "test-model", 0.5)
+	project := t.TempDir()
+
+	s.Record("vibe", "good", "Local", "user", "")
+	writeProjectFile(t, project, `
+- profile: vibe
+  kind: good
+  statement: Wrap each migration in a transaction
+- profile: vibe
+  kind: bad
+  statement: From file
+`)
+	fileEntries, err := LoadProjectFile(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := SampleOptions{Profile: "vibe", Project: project, N: 1, Prompt: "Add a migration", ProjectEntries: fileEntries}
+	for range 2 {
+		if _, err := s.SampleWith(opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(model.calls) != 2 || len(model.calls[0]) != 4 || len(model.calls[1]) != 1 {
+		t.Fatalf("expected the file entries to be embedded once, got %v", model.calls)
+	}
+}
+
+func TestSessionEntriesResolvesProjectEntries(t *testing.T) {
+	s := openTestStore(t)
+	project := t.TempDir()
+
+	local, _ := s.Record("vibe", "good", "Local", "user", "")
+	writeProjectFile(t, project, `
+- profile: vibe
+  kind: bad
+  statement: From file
+`)
+	fileEntries, err := LoadProjectFile(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromFile := fileEntries[0].ID
+
+	if err := s.RecordUsage("sess-1", []string{fromFile, local, "project-file:gone"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetOutcome("sess-1", "good"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := s.SessionEntries("sess-1", fileEntries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].ID != fromFile || entries[1].ID != local {
+		t.Fatalf("expected the file entry then the local one, got %+v", entries)
+	}
+	if entries[0].Statement != "From file" || entries[0].Uses != 1 || entries[0].GoodSessions != 1 {
+		t.Fatalf("expected the file entry with its usage, got %+v", entries[0])
+	}
+}
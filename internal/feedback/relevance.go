@@ -0,0 +1,113 @@
+package feedback
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/lthms/vee/internal/kb"
+)
+
+// minWeight keeps every candidate reachable, even the least relevant one
+// when relevance fully dominates the blend.
+const minWeight = 0.01
+
+// storedEmbedding is the raw embedding column of a feedback row.
+type storedEmbedding struct {
+	blob  []byte
+	model string
+}
+
+// EnableRelevance turns on prompt-aware sampling. Entries are embedded
+// lazily with model and cached alongside the row, tagged with
+// embeddingModel so a model change triggers re-embedding. weight in [0, 1]
+// controls how much prompt similarity counts against recency; 0 disables
+// relevance entirely.
+func (s *Store) EnableRelevance(model kb.Model, embeddingModel string, weight float64) {
+	s.model = model
+	s.embeddingModel = embeddingModel
+	s.relevance = math.Min(1, math.Max(0, weight))
+}
+
+// similarities returns the embedding of text and its cosine similarity with
+// each entry. Missing or stale entry embeddings are computed in the same
+// batch as text and persisted, in memory for the entries of the project
+// file.
+func (s *Store) similarities(text string, entries []Entry, stored []storedEmbedding) ([]float64, []float64, error) {
+	embeddings := make([][]float64, len(entries))
+	texts := []string{text}
+	var pending []int
+	for i, se := range stored {
+		if se.blob == nil && isProjectEntryID(entries[i].ID) {
+			se = s.fileEmbedding(entries[i].ID)
+		}
+		if se.blob != nil && se.model == s.embeddingModel {
+			embeddings[i] = kb.BlobToEmbedding(se.blob)
+			continue
+		}
+		texts = append(texts, entries[i].Statement)
+		pending = append(pending, i)
+	}
+
+	vectors, err := s.model.Embed(texts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("embed: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return nil, nil, fmt.Errorf("embed: expected %d vectors, got %d", len(texts), len(vectors))
+	}
+
+	for j, i := range pending {
+		embeddings[i] = vectors[j+1]
+		if isProjectEntryID(entries[i].ID) {
+			s.setFileEmbedding(entries[i].ID, storedEmbedding{kb.EmbeddingToBlob(vectors[j+1]), s.embeddingModel})
+			continue
+		}
+		if _, err := s.db.Exec(
+			`UPDATE feedback SET embedding = ?, embedding_model = ? WHERE id = ?`,
+			kb.EmbeddingToBlob(vectors[j+1]), s.embeddingModel, entries[i].ID,
+		); err != nil {
+			return nil, nil, fmt.Errorf("store embedding: %w", err)
+		}
+	}
+
+	similarities := make([]float64, len(entries))
+	for i, emb := range embeddings {
+		similarities[i] = kb.CosineSimilarity(vectors[0], emb)
+	}
+	return vectors[0], similarities, nil
+}
+
+// fileEmbedding returns the cached embedding of a project file entry.
+func (s *Store) fileEmbedding(id string) storedEmbedding {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fileEmbeddings[id]
+}
+
+// setFileEmbedding caches the embedding of a project file entry.
+func (s *Store) setFileEmbedding(id string, se storedEmbedding) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fileEmbeddings[id] = se
+}
+
+// blendWeights mixes recency weights with prompt similarities. Similarities
+// are rescaled to [0, 1] across the candidates so that relevance stays
+// discriminative even when raw cosine scores are bunched together.
+func blendWeights(recency, similarities []float64, relevance float64) []float64 {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, sim := range similarities {
+		lo = math.Min(lo, sim)
+		hi = math.Max(hi, sim)
+	}
+
+	weights := make([]float64, len(recency))
+	for i := range recency {
+		norm := 1.0
+		if hi > lo {
+			norm = (similarities[i] - lo) / (hi - lo)
+		}
+		weights[i] = math.Max(minWeight, (1-relevance)*recency[i]+relevance*norm)
+	}
+	return weights
+}
@@ -0,0 +1,140 @@
+package feedback
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// topicModel embeds texts on two axes: database-related and git-related.
+type topicModel struct {
+	mu    sync.Mutex
+	calls [][]string
+	err   error
+}
+
+func (m *topicModel) Embed(texts []string) ([][]float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, texts)
+	if m.err != nil {
+		return nil, m.err
+	}
+	out := make([][]float64, len(texts))
+	for i, t := range texts {
+		if strings.Contains(strings.ToLower(t), "migration") {
+			out[i] = []float64{1, 0}
+		} else {
+			out[i] = []float64{0, 1}
+		}
+	}
+	return out, nil
+}
+
+func seedTopics(t *testing.T, s *Store) {
+	t.Helper()
+	for range 5 {
+		s.Record("vibe", "good", "Write commit messages in the imperative", "user", "")
+	}
+	s.Record("vibe", "good", "Wrap each migration in a transaction", "user", "")
+}
+
+func TestSampleWithFavorsRelevantEntries(t *testing.T) {
+	s := openTestStore(t)
+	s.EnableRelevance(&topicModel{}, "test-model", 1)
+	seedTopics(t, s)
+
+	hits := 0
+	for range 50 {
+		entries, err := s.SampleWith(SampleOptions{Profile: "vibe", N: 1, Prompt: "Add a database migration"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) == 1 && strings.Contains(entries[0].Statement, "migration") {
+			hits++
+		}
+	}
+	if hits < 40 {
+		t.Fatalf("expected the migration example to dominate, got %d/50", hits)
+	}
+}
+
+func TestSampleWithCachesEmbeddings(t *testing.T) {
+	s := openTestStore(t)
+	model := &topicModel{}
+	s.EnableRelevance(model, "test-model", 0.5)
+	seedTopics(t, s)
+
+	opts := SampleOptions{Profile: "vibe", N: 2, Prompt: "Add a migration"}
+	if _, err := s.SampleWith(opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SampleWith(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(model.calls) != 2 {
+		t.Fatalf("expected 2 embed calls, got %d", len(model.calls))
+	}
+	if len(model.calls[0]) != 7 {
+		t.Fatalf("first call should embed prompt + 6 entries, got %d texts", len(model.calls[0]))
+	}
+	if len(model.calls[1]) != 1 {
+		t.Fatalf("second call should embed only the prompt, got %d texts", len(model.calls[1]))
+	}
+
+	// A model change invalidates the cache.
+	s.EnableRelevance(model, "other-model", 0.5)
+	if _, err := s.SampleWith(opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(model.calls[2]); got != 7 {
+		t.Fatalf("expected re-embedding after model change, got %d texts", got)
+	}
+}
+
+func TestUpdateClearsEmbedding(t *testing.T) {
+	s := openTestStore(t)
+	model := &topicModel{}
+	s.EnableRelevance(model, "test-model", 0.5)
+	seedTopics(t, s)
+
+	opts := SampleOptions{Profile: "vibe", N: 2, Prompt: "Add a migration"}
+	if _, err := s.SampleWith(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := s.List(ListFilter{})
+	if err := s.Update(entries[0].ID, "good", "Reworded"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SampleWith(opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(model.calls[1]); got != 2 {
+		t.Fatalf("expected prompt + edited entry to be embedded, got %d texts", got)
+	}
+}
+
+func TestSampleWithFallsBackWithoutModel(t *testing.T) {
+	s := openTestStore(t)
+	seedTopics(t, s)
+
+	entries, err := s.SampleWith(SampleOptions{Profile: "vibe", N: 3, Prompt: "Add a migration"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+
+	s.EnableRelevance(&topicModel{err: fmt.Errorf("ollama down")}, "test-model", 0.5)
+	entries, err = s.SampleWith(SampleOptions{Profile: "vibe", N: 3, Prompt: "Add a migration"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected fallback to return 3 entries, got %d", len(entries))
+	}
+}
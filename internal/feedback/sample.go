@@ -1,48 +1,196 @@
 package feedback
 
 import (
+	"hash/fnv"
+	"log/slog"
 	"math"
 	"math/rand"
+	"slices"
+	"strings"
 	"time"
 )
 
-// Sample returns up to n feedback entries for the given profile, with recency bias.
-// Entries are selected where profile matches AND (scope='user' OR (scope='project' AND project matches)).
-func (s *Store) Sample(profile, project string, n int) ([]Entry, error) {
+// AllProfiles is the profile value of entries that apply to every profile.
+const AllProfiles = "*"
+
+// groupPrefix marks profile values that target a profile group.
+const groupPrefix = "group:"
+
+// GroupTarget returns the profile value of entries that apply to every
+// profile in the given group.
+func GroupTarget(group string) string {
+	return groupPrefix + group
+}
+
+// Sampling tiers, in the order their entries are returned.
+const (
+	tierProfile = iota
+	tierGroup
+	tierGlobal
+	tierCount
+)
+
+// SampleOptions describes a sampling request.
+type SampleOptions struct {
+	Profile string
+	Project string
+	N       int // quota for entries recorded for the profile itself
+
+	// Groups lists the profile groups the profile belongs to. Up to GroupN
+	// entries targeting any of them are sampled.
+	Groups []string
+	GroupN int
+
+	// GlobalN is the quota for entries targeting all profiles.
+	GlobalN int
+
+	// Prompt is the session's initial prompt. When set and relevance
+	// sampling is enabled, entries similar to it are favored.
+	Prompt string
+
+	// MaxPinned caps how many pinned entries are included. Pinned entries
+	// are always taken first, newest first, and do not count against the
+	// quotas.
+	MaxPinned int
+
+	// Seed makes the draw deterministic: the same seed over the same
+	// entries yields the same selection. Empty means a random draw.
+	Seed string
+
+	// ProjectEntries are extra project-scope candidates, typically read from
+	// the project's feedback file (see LoadProjectFile). Entries whose
+	// profile is not targeted, or that duplicate a stored entry, are ignored.
+	ProjectEntries []Entry
+}
+
+// SampleWith returns up to opts.MaxPinned pinned entries, followed by
+// entries sampled from the profile, its groups and all profiles, each within
+// its own quota. Only entries in user scope or in the given project are
+// considered. Selection blends prompt similarity with recency when a prompt
+// is given and relevance sampling is enabled; otherwise, or when the prompt
+// cannot be embedded, entries are drawn by recency alone (see
+// recencyWeights).
+func (s *Store) SampleWith(opts SampleOptions) ([]Entry, error) {
+	targets := []any{opts.Profile, AllProfiles}
+	for _, g := range opts.Groups {
+		targets = append(targets, GroupTarget(g))
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(targets)), ", ")
+
 	rows, err := s.db.Query(
-		`SELECT id, profile, kind, statement, scope, project, created_at
+		`SELECT id, profile, kind, statement, scope, project, created_at, pinned, embedding, embedding_model
 		 FROM feedback
-		 WHERE profile = ?
-		   AND (scope = 'user' OR (scope = 'project' AND project = ?))`,
-		profile, project,
+		 WHERE profile IN (`+placeholders+`)
+		   AND (scope = 'user' OR (scope = 'project' AND project = ?))
+		 ORDER BY created_at DESC, id ASC`,
+		append(targets, opts.Project)...,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var entries []Entry
+	var pinned, entries []Entry
+	var stored []storedEmbedding
+	var pools [tierCount][]int // indices into entries, per tier
+	seen := make(map[[2]string]bool)
+	add := func(e Entry, se storedEmbedding) {
+		seen[[2]string{e.Profile, e.Statement}] = true
+		if e.Pinned {
+			if len(pinned) < opts.MaxPinned {
+				pinned = append(pinned, e)
+			}
+			return
+		}
+		tier := tierGroup
+		switch e.Profile {
+		case opts.Profile:
+			tier = tierProfile
+		case AllProfiles:
+			tier = tierGlobal
+		}
+		pools[tier] = append(pools[tier], len(entries))
+		entries = append(entries, e)
+		stored = append(stored, se)
+	}
+
 	for rows.Next() {
 		var e Entry
-		if err := rows.Scan(&e.ID, &e.Profile, &e.Kind, &e.Statement, &e.Scope, &e.Project, &e.CreatedAt); err != nil {
+		var se storedEmbedding
+		if err := rows.Scan(&e.ID, &e.Profile, &e.Kind, &e.Statement, &e.Scope, &e.Project, &e.CreatedAt, &e.Pinned, &se.blob, &se.model); err != nil {
 			return nil, err
 		}
-		entries = append(entries, e)
+		add(e, se)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	if len(entries) <= n {
-		return entries, nil
+	for _, e := range opts.ProjectEntries {
+		if !slices.Contains(targets, any(e.Profile)) || seen[[2]string{e.Profile, e.Statement}] {
+			continue
+		}
+		add(e, storedEmbedding{})
+	}
+
+	quotas := [tierCount]int{opts.N, opts.GroupN, opts.GlobalN}
+	needsDraw := false
+	for tier, pool := range pools {
+		if len(pool) > quotas[tier] {
+			needsDraw = true
+		}
+	}
+
+	result := pinned
+	if !needsDraw {
+		for _, pool := range pools {
+			for _, i := range pool {
+				result = append(result, entries[i])
+			}
+		}
+		return result, nil
+	}
+
+	rng := newRand(opts.Seed)
+	weights := recencyWeights(entries)
+	if opts.Prompt != "" && s.model != nil && s.relevance > 0 {
+		_, similarities, err := s.similarities(opts.Prompt, entries, stored)
+		if err != nil {
+			slog.Debug("feedback relevance unavailable, sampling by recency", "error", err)
+		} else {
+			weights = blendWeights(weights, similarities, s.relevance)
+		}
 	}
 
-	return weightedSample(entries, n), nil
+	for tier, pool := range pools {
+		tierEntries := make([]Entry, len(pool))
+		tierWeights := make([]float64, len(pool))
+		for j, i := range pool {
+			tierEntries[j] = entries[i]
+			tierWeights[j] = weights[i]
+		}
+		if len(tierEntries) <= quotas[tier] {
+			result = append(result, tierEntries...)
+			continue
+		}
+		result = append(result, drawWeighted(rng, tierEntries, tierWeights, quotas[tier])...)
+	}
+	return result, nil
 }
 
-// weightedSample selects n entries using weighted random sampling with
-// recency bias: weight = 1.0 / (1.0 + days_since_creation).
-func weightedSample(entries []Entry, n int) []Entry {
+// newRand returns a generator seeded from seed, or a randomly seeded one
+// when seed is empty.
+func newRand(seed string) *rand.Rand {
+	if seed == "" {
+		return rand.New(rand.NewSource(rand.Int63()))
+	}
+	h := fnv.New64a()
+	h.Write([]byte(seed))
+	return rand.New(rand.NewSource(int64(h.Sum64())))
+}
+
+// recencyWeights computes 1.0 / (1.0 + days_since_creation) for each entry.
+func recencyWeights(entries []Entry) []float64 {
 	now := time.Now()
 	weights := make([]float64, len(entries))
 	for i, e := range entries {
@@ -54,7 +202,12 @@ func weightedSample(entries []Entry, n int) []Entry {
 		days := math.Max(0, now.Sub(created).Hours()/24)
 		weights[i] = 1.0 / (1.0 + days)
 	}
+	return weights
+}
 
+// drawWeighted selects n entries without replacement, each draw picking an
+// entry with probability proportional to its weight.
+func drawWeighted(rng *rand.Rand, entries []Entry, weights []float64, n int) []Entry {
 	selected := make([]Entry, 0, n)
 	used := make([]bool, len(entries))
 
@@ -71,7 +224,7 @@ func weightedSample(entries []Entry, n int) []Entry {
 		}
 
 		// Pick a random point
-		r := rand.Float64() * total
+		r := rng.Float64() * total
 		var cumulative float64
 		for i, w := range weights {
 			if used[i] {
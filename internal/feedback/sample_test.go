@@ -1,8 +1,10 @@
 package feedback
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -36,7 +38,7 @@ func TestSampleReturnsAllWhenUnderLimit(t *testing.T) {
 	s.Record("vibe", "good", "Be concise", "user", "")
 	s.Record("vibe", "bad", "No emojis", "user", "")
 
-	entries, err := s.Sample("vibe", "", 5)
+	entries, err := s.SampleWith(SampleOptions{Profile: "vibe", N: 5})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -53,7 +55,7 @@ func TestSampleRespectsMaxCount(t *testing.T) {
 		s.Record("vibe", "good", "Statement", "user", "")
 	}
 
-	entries, err := s.Sample("vibe", "", 3)
+	entries, err := s.SampleWith(SampleOptions{Profile: "vibe", N: 3})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -68,7 +70,7 @@ func TestSampleFiltersMode(t *testing.T) {
 	s.Record("vibe", "good", "Vibe feedback", "user", "")
 	s.Record("normal", "good", "Normal feedback", "user", "")
 
-	entries, err := s.Sample("vibe", "", 10)
+	entries, err := s.SampleWith(SampleOptions{Profile: "vibe", N: 10})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -87,7 +89,7 @@ func TestSampleScopeFiltering(t *testing.T) {
 	s.Record("vibe", "good", "Project-scoped matching", "project", "/my/project")
 	s.Record("vibe", "good", "Project-scoped other", "project", "/other/project")
 
-	entries, err := s.Sample("vibe", "/my/project", 10)
+	entries, err := s.SampleWith(SampleOptions{Profile: "vibe", Project: "/my/project", N: 10})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -119,7 +121,7 @@ func TestSampleRecencyBias(t *testing.T) {
 	recentCount := 0
 	iterations := 100
 	for range iterations {
-		entries, err := s.Sample("vibe", "", 5)
+		entries, err := s.SampleWith(SampleOptions{Profile: "vibe", N: 5})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -138,6 +140,153 @@ func TestSampleRecencyBias(t *testing.T) {
 	}
 }
 
+func TestSamplePinnedAlwaysIncluded(t *testing.T) {
+	s := openTestStore(t)
+
+	old := time.Now().AddDate(-1, 0, 0).Format("2006-01-02")
+	insertWithDate(t, s, "vibe", "good", "Pinned rule", "user", "", old)
+	pinned, _ := s.List(ListFilter{})
+	if err := s.SetPinned(pinned[0].ID, true); err != nil {
+		t.Fatal(err)
+	}
+	for range 10 {
+		s.Record("vibe", "good", "Regular", "user", "")
+	}
+
+	for range 20 {
+		entries, err := s.SampleWith(SampleOptions{Profile: "vibe", N: 3, MaxPinned: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 4 {
+			t.Fatalf("expected 1 pinned + 3 sampled entries, got %d", len(entries))
+		}
+		if !entries[0].Pinned || entries[0].Statement != "Pinned rule" {
+			t.Fatalf("expected pinned entry first, got %+v", entries[0])
+		}
+	}
+}
+
+func TestSamplePinnedBudget(t *testing.T) {
+	s := openTestStore(t)
+
+	for range 4 {
+		id, _ := s.Record("vibe", "good", "Pinned", "user", "")
+		s.SetPinned(id, true)
+	}
+
+	entries, err := s.SampleWith(SampleOptions{Profile: "vibe", N: 5, MaxPinned: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected pinned budget of 2, got %d", len(entries))
+	}
+
+	entries, _ = s.SampleWith(SampleOptions{Profile: "vibe", N: 5})
+	if len(entries) != 0 {
+		t.Fatalf("expected no pinned entries without a budget, got %d", len(entries))
+	}
+}
+
+func TestSampleSeedIsDeterministic(t *testing.T) {
+	s := openTestStore(t)
+
+	for i := range 20 {
+		s.Record("vibe", "good", fmt.Sprintf("Statement %d", i), "user", "")
+	}
+
+	ids := func(seed string) string {
+		entries, err := s.SampleWith(SampleOptions{Profile: "vibe", N: 5, Seed: seed})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	first := ids("session-a")
+	for range 5 {
+		if got := ids("session-a"); got != first {
+			t.Fatalf("same seed produced different samples:\n%s\n%s", first, got)
+		}
+	}
+
+	differs := false
+	for i := range 5 {
+		if ids(fmt.Sprintf("session-%d", i)) != first {
+			differs = true
+		}
+	}
+	if !differs {
+		t.Fatal("expected different seeds to produce different samples")
+	}
+}
+
+func TestSampleGroupsAndGlobalQuotas(t *testing.T) {
+	s := openTestStore(t)
+
+	for range 5 {
+		s.Record("vibe", "good", "Profile", "user", "")
+		s.Record(GroupTarget("coding"), "good", "Group", "user", "")
+		s.Record(GroupTarget("planning"), "good", "Other group", "user", "")
+		s.Record(AllProfiles, "good", "Global", "user", "")
+	}
+
+	entries, err := s.SampleWith(SampleOptions{
+		Profile: "vibe",
+		N:       3,
+		Groups:  []string{"coding"},
+		GroupN:  2,
+		GlobalN: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]int)
+	for _, e := range entries {
+		counts[e.Statement]++
+	}
+	if counts["Profile"] != 3 || counts["Group"] != 2 || counts["Global"] != 1 {
+		t.Fatalf("unexpected tier counts: %v", counts)
+	}
+	if counts["Other group"] != 0 {
+		t.Fatal("should not include entries from groups the profile is not in")
+	}
+
+	// Entries come back profile first, then group, then global.
+	want := []string{"Profile", "Profile", "Profile", "Group", "Group", "Global"}
+	for i, e := range entries {
+		if e.Statement != want[i] {
+			t.Fatalf("entry %d: got %q, want %q", i, e.Statement, want[i])
+		}
+	}
+}
+
+func TestSampleGlobalExcludedWithoutQuota(t *testing.T) {
+	s := openTestStore(t)
+
+	s.Record("vibe", "good", "Profile", "user", "")
+	s.Record(AllProfiles, "good", "Global", "user", "")
+	id, _ := s.Record(AllProfiles, "good", "Pinned global", "user", "")
+	s.SetPinned(id, true)
+
+	entries, err := s.SampleWith(SampleOptions{Profile: "vibe", N: 5, MaxPinned: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected pinned global + profile entry, got %d", len(entries))
+	}
+	if entries[0].Statement != "Pinned global" || entries[1].Statement != "Profile" {
+		t.Fatalf("unexpected entries: %q, %q", entries[0].Statement, entries[1].Statement)
+	}
+}
+
 func TestRecord(t *testing.T) {
 	s := openTestStore(t)
 
@@ -150,7 +299,7 @@ func TestRecord(t *testing.T) {
 	}
 
 	// Verify it was stored
-	entries, err := s.Sample("vibe", "", 10)
+	entries, err := s.SampleWith(SampleOptions{Profile: "vibe", N: 10})
 	if err != nil {
 		t.Fatal(err)
 	}
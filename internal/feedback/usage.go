@@ -0,0 +1,202 @@
+package feedback
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// RankedEntry is a feedback entry together with its usage statistics.
+type RankedEntry struct {
+	Entry
+	Uses         int     `json:"uses"`          // sessions the entry was injected into
+	GoodSessions int     `json:"good_sessions"` // of those, sessions marked good
+	BadSessions  int     `json:"bad_sessions"`  // of those, sessions marked bad
+	Score        float64 `json:"score"`
+}
+
+// score estimates how often an entry leads to a good session, with
+// Laplace smoothing so unrated entries sit at a neutral 0.5.
+func score(good, bad int) float64 {
+	return float64(good+1) / float64(good+bad+2)
+}
+
+// RecordUsage records that the given entries were injected into a session.
+// Recording the same pair twice is a no-op.
+func (s *Store) RecordUsage(sessionID string, entryIDs []string) error {
+	if len(entryIDs) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	usedAt := time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	for _, id := range entryIDs {
+		if _, err := tx.Exec(
+			`INSERT OR IGNORE INTO feedback_usage (session_id, entry_id, used_at) VALUES (?, ?, ?)`,
+			sessionID, id, usedAt,
+		); err != nil {
+			return fmt.Errorf("record feedback usage: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SetOutcome marks how a session turned out: "good", "bad", or "" to clear
+// a previous mark.
+func (s *Store) SetOutcome(sessionID, outcome string) error {
+	switch outcome {
+	case "":
+		_, err := s.db.Exec(`DELETE FROM session_outcome WHERE session_id = ?`, sessionID)
+		if err != nil {
+			return fmt.Errorf("clear session outcome: %w", err)
+		}
+		return nil
+	case "good", "bad":
+	default:
+		return fmt.Errorf("outcome must be 'good' or 'bad', got %q", outcome)
+	}
+
+	_, err := s.db.Exec(
+		`INSERT INTO session_outcome (session_id, outcome, marked_at) VALUES (?, ?, ?)
+		 ON CONFLICT(session_id) DO UPDATE SET outcome = excluded.outcome, marked_at = excluded.marked_at`,
+		sessionID, outcome, time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+	)
+	if err != nil {
+		return fmt.Errorf("set session outcome: %w", err)
+	}
+	return nil
+}
+
+// Outcome returns the outcome recorded for a session, or "" if unmarked.
+func (s *Store) Outcome(sessionID string) (string, error) {
+	var outcome string
+	err := s.db.QueryRow(`SELECT outcome FROM session_outcome WHERE session_id = ?`, sessionID).Scan(&outcome)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("get session outcome: %w", err)
+	}
+	return outcome, nil
+}
+
+// SessionEntries returns the entries injected into a session, with their
+// usage statistics, in the order they were recorded. Entries of the project
+// file are resolved from projectEntries (see LoadProjectFile); those no
+// longer in it, like deleted entries, are left out.
+func (s *Store) SessionEntries(sessionID string, projectEntries []Entry) ([]RankedEntry, error) {
+	rows, err := s.db.Query(
+		`SELECT u.entry_id, f.profile, f.kind, f.statement, f.scope, f.project, f.created_at, f.pinned
+		 FROM feedback_usage u LEFT JOIN feedback f ON f.id = u.entry_id
+		 WHERE u.session_id = ?
+		 ORDER BY u.rowid`, sessionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("session feedback: %w", err)
+	}
+	defer rows.Close()
+
+	fromFile := make(map[string]Entry, len(projectEntries))
+	for _, e := range projectEntries {
+		fromFile[e.ID] = e
+	}
+
+	var entries []Entry
+	for rows.Next() {
+		var id string
+		var profile, kind, statement, scope, project, createdAt sql.NullString
+		var pinned sql.NullBool
+		if err := rows.Scan(&id, &profile, &kind, &statement, &scope, &project, &createdAt, &pinned); err != nil {
+			return nil, fmt.Errorf("scan feedback: %w", err)
+		}
+		if !profile.Valid {
+			if e, ok := fromFile[id]; ok {
+				entries = append(entries, e)
+			}
+			continue
+		}
+		entries = append(entries, Entry{
+			ID: id, Profile: profile.String, Kind: kind.String, Statement: statement.String,
+			Scope: scope.String, Project: project.String, CreatedAt: createdAt.String, Pinned: pinned.Bool,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return s.withUsage(entries)
+}
+
+// Ranked returns the entries matching the filter with their usage
+// statistics, best-scoring first. Ties are broken by usage, then recency.
+func (s *Store) Ranked(f ListFilter) ([]RankedEntry, error) {
+	entries, err := s.List(f)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked, err := s.withUsage(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Uses > ranked[j].Uses
+	})
+	return ranked, nil
+}
+
+// withUsage attaches usage statistics to each entry.
+func (s *Store) withUsage(entries []Entry) ([]RankedEntry, error) {
+	rows, err := s.db.Query(
+		`SELECT u.entry_id,
+		        COUNT(*),
+		        COALESCE(SUM(CASE WHEN o.outcome = 'good' THEN 1 ELSE 0 END), 0),
+		        COALESCE(SUM(CASE WHEN o.outcome = 'bad' THEN 1 ELSE 0 END), 0)
+		 FROM feedback_usage u
+		 LEFT JOIN session_outcome o ON o.session_id = u.session_id
+		 GROUP BY u.entry_id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("feedback usage: %w", err)
+	}
+	defer rows.Close()
+
+	type counts struct{ uses, good, bad int }
+	usage := make(map[string]counts)
+	for rows.Next() {
+		var id string
+		var c counts
+		if err := rows.Scan(&id, &c.uses, &c.good, &c.bad); err != nil {
+			return nil, fmt.Errorf("scan feedback usage: %w", err)
+		}
+		usage[id] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ranked := make([]RankedEntry, len(entries))
+	for i, e := range entries {
+		c := usage[e.ID]
+		ranked[i] = RankedEntry{
+			Entry:        e,
+			Uses:         c.uses,
+			GoodSessions: c.good,
+			BadSessions:  c.bad,
+			Score:        score(c.good, c.bad),
+		}
+	}
+	return ranked, nil
+}
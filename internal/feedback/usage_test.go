@@ -0,0 +1,116 @@
+package feedback
+
+import "testing"
+
+func TestRecordUsageAndSessionEntries(t *testing.T) {
+	s := openTestStore(t)
+
+	a, _ := s.Record("vibe", "good", "First", "user", "")
+	b, _ := s.Record("vibe", "bad", "Second", "user", "")
+
+	if err := s.RecordUsage("sess-1", []string{a, b}); err != nil {
+		t.Fatal(err)
+	}
+	// Recording the same pair again is a no-op.
+	if err := s.RecordUsage("sess-1", []string{a}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := s.SessionEntries("sess-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].ID != a || entries[1].ID != b {
+		t.Fatalf("unexpected order: %s, %s", entries[0].ID, entries[1].ID)
+	}
+	if entries[0].Uses != 1 {
+		t.Fatalf("expected 1 use, got %d", entries[0].Uses)
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	s := openTestStore(t)
+
+	if o, err := s.Outcome("sess-1"); err != nil || o != "" {
+		t.Fatalf("expected no outcome, got %q (%v)", o, err)
+	}
+
+	if err := s.SetOutcome("sess-1", "good"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetOutcome("sess-1", "bad"); err != nil {
+		t.Fatal(err)
+	}
+	if o, _ := s.Outcome("sess-1"); o != "bad" {
+		t.Fatalf("expected bad, got %q", o)
+	}
+
+	if err := s.SetOutcome("sess-1", ""); err != nil {
+		t.Fatal(err)
+	}
+	if o, _ := s.Outcome("sess-1"); o != "" {
+		t.Fatalf("expected cleared outcome, got %q", o)
+	}
+
+	if err := s.SetOutcome("sess-1", "meh"); err == nil {
+		t.Fatal("expected error for invalid outcome")
+	}
+}
+
+func TestRankedOrdersByOutcome(t *testing.T) {
+	s := openTestStore(t)
+
+	helpful, _ := s.Record("vibe", "good", "Helpful", "user", "")
+	harmful, _ := s.Record("vibe", "good", "Harmful", "user", "")
+	unused, _ := s.Record("vibe", "good", "Unused", "user", "")
+
+	for _, sess := range []string{"s1", "s2", "s3"} {
+		s.RecordUsage(sess, []string{helpful, harmful})
+	}
+	s.SetOutcome("s1", "good")
+	s.SetOutcome("s2", "good")
+	s.SetOutcome("s3", "bad")
+	for _, sess := range []string{"s4", "s5"} {
+		s.RecordUsage(sess, []string{harmful})
+		s.SetOutcome(sess, "bad")
+	}
+
+	ranked, err := s.Ranked(ListFilter{Profile: "vibe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranked) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(ranked))
+	}
+
+	order := []string{ranked[0].ID, ranked[1].ID, ranked[2].ID}
+	want := []string{helpful, unused, harmful}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("rank %d: got %s, want %s", i, order[i], want[i])
+		}
+	}
+	if ranked[2].Uses != 5 || ranked[2].GoodSessions != 2 || ranked[2].BadSessions != 3 {
+		t.Fatalf("unexpected stats for harmful entry: %+v", ranked[2])
+	}
+}
+
+func TestDeleteRemovesUsage(t *testing.T) {
+	s := openTestStore(t)
+
+	id, _ := s.Record("vibe", "good", "Statement", "user", "")
+	s.RecordUsage("sess-1", []string{id})
+
+	if err := s.Delete(id); err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	s.db.QueryRow(`SELECT COUNT(*) FROM feedback_usage`).Scan(&n)
+	if n != 0 {
+		t.Fatalf("expected usage rows to be deleted, got %d", n)
+	}
+}
@@ -0,0 +1,282 @@
+package history
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ListFilter narrows down List. Empty fields match everything.
+type ListFilter struct {
+	Profile string
+	Project string
+	Query   string // full-text search across transcripts
+	Limit   int    // 0 means no limit
+}
+
+// TranscriptFile returns where the archived transcript of a session lives.
+func (s *Store) TranscriptFile(id string) string {
+	return filepath.Join(s.dir, id+".jsonl")
+}
+
+// SaveTranscript stores a transcript received from elsewhere (e.g. streamed
+// out of a container) as the archived copy of a session, and returns its
+// path. The session itself is recorded by a later call to Archive.
+func (s *Store) SaveTranscript(id string, r io.Reader) (string, error) {
+	path := s.TranscriptFile(id)
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("create transcript: %w", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return "", fmt.Errorf("write transcript: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("write transcript: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", fmt.Errorf("save transcript: %w", err)
+	}
+	return path, nil
+}
+
+// Archive records a session and indexes its transcript. src is the path of
+// the live transcript, copied into the archive; when empty, a previously
+// archived copy is kept. Archiving the same session again (e.g. after it was
+// resumed) replaces the earlier record.
+func (s *Store) Archive(sess Session, src string) error {
+	var messages []Message
+	transcript := ""
+	if src != "" {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return fmt.Errorf("read transcript: %w", err)
+		}
+		transcript = s.TranscriptFile(sess.ID)
+		if src != transcript {
+			if err := os.WriteFile(transcript, data, 0600); err != nil {
+				return fmt.Errorf("copy transcript: %w", err)
+			}
+		}
+		if messages, err = ParseTranscript(bytes.NewReader(data)); err != nil {
+			return fmt.Errorf("parse transcript: %w", err)
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`INSERT INTO sessions (id, profile, indicator, project, preview, status, started_at, ended_at, transcript, messages)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(id) DO UPDATE SET
+		   profile = excluded.profile, indicator = excluded.indicator, project = excluded.project,
+		   preview = excluded.preview, status = excluded.status, ended_at = excluded.ended_at,
+		   transcript = CASE WHEN excluded.transcript = '' THEN sessions.transcript ELSE excluded.transcript END,
+		   messages = CASE WHEN excluded.transcript = '' THEN sessions.messages ELSE excluded.messages END`,
+		sess.ID, sess.Profile, sess.Indicator, sess.Project, sess.Preview, sess.Status,
+		sess.StartedAt, sess.EndedAt, transcript, len(messages),
+	); err != nil {
+		return fmt.Errorf("record session: %w", err)
+	}
+
+	if transcript != "" {
+		if _, err := tx.Exec(`DELETE FROM transcript_fts WHERE session_id = ?`, sess.ID); err != nil {
+			return fmt.Errorf("clear transcript index: %w", err)
+		}
+		for i, m := range messages {
+			if _, err := tx.Exec(
+				`INSERT INTO transcript_fts (text, session_id, role, seq) VALUES (?, ?, ?, ?)`,
+				m.Text, sess.ID, m.Role, i,
+			); err != nil {
+				return fmt.Errorf("index transcript: %w", err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// List returns archived sessions matching the filter, most recently ended
+// first. With a query, only sessions whose transcript matches are returned,
+// each with an excerpt of its best match.
+func (s *Store) List(f ListFilter) ([]Session, error) {
+	if strings.TrimSpace(f.Query) != "" {
+		return s.search(f)
+	}
+
+	where, args := f.where("")
+	query := `SELECT ` + sessionColumns("") + ` FROM sessions` + where + ` ORDER BY ended_at DESC, id ASC`
+	if f.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", f.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var sess Session
+		if err := rows.Scan(sess.fields()...); err != nil {
+			return nil, fmt.Errorf("scan session: %w", err)
+		}
+		sessions = append(sessions, sess)
+	}
+	return sessions, rows.Err()
+}
+
+// search runs a full-text query and keeps the best-ranked match of each
+// session.
+func (s *Store) search(f ListFilter) ([]Session, error) {
+	where, args := f.where("s.")
+	if where == "" {
+		where = " WHERE "
+	} else {
+		where += " AND "
+	}
+	rows, err := s.db.Query(
+		`SELECT `+sessionColumns("s.")+`, snippet(transcript_fts, 0, '[', ']', '…', 16)
+		 FROM transcript_fts JOIN sessions s ON s.id = transcript_fts.session_id`+
+			where+`transcript_fts MATCH ?
+		 ORDER BY rank`,
+		append(args, ftsQuery(f.Query))...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("search transcripts: %w", err)
+	}
+	defer rows.Close()
+
+	seen := make(map[string]bool)
+	var sessions []Session
+	for rows.Next() {
+		var sess Session
+		if err := rows.Scan(append(sess.fields(), &sess.Snippet)...); err != nil {
+			return nil, fmt.Errorf("scan session: %w", err)
+		}
+		if seen[sess.ID] {
+			continue
+		}
+		seen[sess.ID] = true
+		sessions = append(sessions, sess)
+		if f.Limit > 0 && len(sessions) == f.Limit {
+			break
+		}
+	}
+	return sessions, rows.Err()
+}
+
+// Get returns a single archived session.
+func (s *Store) Get(id string) (*Session, error) {
+	var sess Session
+	err := s.db.QueryRow(`SELECT `+sessionColumns("")+` FROM sessions WHERE id = ?`, id).Scan(sess.fields()...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("get session: %w", err)
+	}
+	return &sess, nil
+}
+
+// Resolve returns the ID of the archived session starting with prefix, so
+// that the short IDs shown in listings can be used to refer to sessions.
+func (s *Store) Resolve(prefix string) (string, error) {
+	rows, err := s.db.Query(`SELECT id FROM sessions WHERE substr(id, 1, length(?)) = ? LIMIT 2`, prefix, prefix)
+	if err != nil {
+		return "", fmt.Errorf("resolve session: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return "", fmt.Errorf("scan session: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	switch len(ids) {
+	case 0:
+		return "", ErrNotFound
+	case 1:
+		return ids[0], nil
+	default:
+		return "", fmt.Errorf("session prefix %q is ambiguous", prefix)
+	}
+}
+
+// Messages returns the conversation of an archived session, or nil if its
+// transcript was never archived.
+func (s *Store) Messages(id string) ([]Message, error) {
+	sess, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if sess.Transcript == "" {
+		return nil, nil
+	}
+	f, err := os.Open(sess.Transcript)
+	if err != nil {
+		return nil, fmt.Errorf("open transcript: %w", err)
+	}
+	defer f.Close()
+	return ParseTranscript(f)
+}
+
+func (f ListFilter) where(prefix string) (string, []any) {
+	var where []string
+	var args []any
+	if f.Profile != "" {
+		where = append(where, prefix+"profile = ?")
+		args = append(args, f.Profile)
+	}
+	if f.Project != "" {
+		where = append(where, prefix+"project = ?")
+		args = append(args, f.Project)
+	}
+	if len(where) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(where, " AND "), args
+}
+
+func sessionColumns(prefix string) string {
+	cols := []string{"id", "profile", "indicator", "project", "preview", "status", "started_at", "ended_at", "transcript", "messages"}
+	for i, c := range cols {
+		cols[i] = prefix + c
+	}
+	return strings.Join(cols, ", ")
+}
+
+func (sess *Session) fields() []any {
+	return []any{&sess.ID, &sess.Profile, &sess.Indicator, &sess.Project, &sess.Preview, &sess.Status,
+		&sess.StartedAt, &sess.EndedAt, &sess.Transcript, &sess.Messages}
+}
+
+// ftsQuery turns free text into an FTS5 query matching all of its words,
+// quoting each so punctuation is not read as query syntax.
+func ftsQuery(q string) string {
+	words := strings.Fields(q)
+	for i, w := range words {
+		words[i] = `"` + strings.ReplaceAll(w, `"`, `""`) + `"`
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,144 @@
+// Package history archives the transcripts of finished sessions and indexes
+// them for full-text search. It also accounts for the tokens the sessions
+// used, and keeps an audit log of the calls they made to Vee's MCP tools.
+package history
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/lthms/vee/internal/migrate"
+	_ "modernc.org/sqlite"
+)
+
+// ErrNotFound is returned when no archived session has the requested ID.
+var ErrNotFound = errors.New("session not found")
+
+// Session is an archived session.
+type Session struct {
+	ID         string `json:"id"`
+	Profile    string `json:"profile"`
+	Indicator  string `json:"indicator"`
+	Project    string `json:"project"`
+	Preview    string `json:"preview"`
+	Status     string `json:"status"` // "suspended" or "completed" when archived
+	StartedAt  string `json:"started_at"`
+	EndedAt    string `json:"ended_at"`
+	Transcript string `json:"transcript"` // archived copy, "" if the transcript was never reported
+	Messages   int    `json:"messages"`
+	Snippet    string `json:"snippet,omitempty"` // matching excerpt, set by search
+}
+
+// Store provides the session archive, backed by SQLite for metadata and the
+// search index, and by a directory holding a copy of each transcript.
+type Store struct {
+	db  *sql.DB
+	dir string
+}
+
+var migrations = []migrate.Migration{
+	{Version: 1, Name: "create sessions and transcript index", Up: func(tx *sql.Tx) error {
+		stmts := []string{
+			`CREATE TABLE sessions (
+				id         TEXT PRIMARY KEY,
+				profile    TEXT NOT NULL,
+				indicator  TEXT NOT NULL DEFAULT '',
+				project    TEXT NOT NULL DEFAULT '',
+				preview    TEXT NOT NULL DEFAULT '',
+				status     TEXT NOT NULL,
+				started_at TEXT NOT NULL,
+				ended_at   TEXT NOT NULL,
+				transcript TEXT NOT NULL DEFAULT '',
+				messages   INTEGER NOT NULL DEFAULT 0
+			)`,
+			`CREATE INDEX idx_sessions_ended ON sessions(ended_at)`,
+			`CREATE VIRTUAL TABLE transcript_fts USING fts5(
+				text,
+				session_id UNINDEXED,
+				role UNINDEXED,
+				seq UNINDEXED
+			)`,
+		}
+		for _, s := range stmts {
+			if _, err := tx.Exec(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{Version: 2, Name: "create usage", Up: func(tx *sql.Tx) error {
+		stmts := []string{
+			`CREATE TABLE usage (
+				message_id     TEXT PRIMARY KEY,
+				session_id     TEXT NOT NULL,
+				profile        TEXT NOT NULL DEFAULT '',
+				project        TEXT NOT NULL DEFAULT '',
+				day            TEXT NOT NULL DEFAULT '',
+				model          TEXT NOT NULL DEFAULT '',
+				input          INTEGER NOT NULL DEFAULT 0,
+				output         INTEGER NOT NULL DEFAULT 0,
+				cache_creation INTEGER NOT NULL DEFAULT 0,
+				cache_read     INTEGER NOT NULL DEFAULT 0,
+				cost           REAL NOT NULL DEFAULT 0
+			)`,
+			`CREATE INDEX idx_usage_session ON usage(session_id)`,
+			`CREATE INDEX idx_usage_day ON usage(day)`,
+		}
+		for _, s := range stmts {
+			if _, err := tx.Exec(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{Version: 3, Name: "create tool calls", Up: func(tx *sql.Tx) error {
+		stmts := []string{
+			`CREATE TABLE tool_calls (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				session_id  TEXT NOT NULL,
+				profile     TEXT NOT NULL DEFAULT '',
+				tool        TEXT NOT NULL,
+				arguments   TEXT NOT NULL DEFAULT '',
+				result      TEXT NOT NULL DEFAULT '',
+				is_error    INTEGER NOT NULL DEFAULT 0,
+				duration_ms INTEGER NOT NULL DEFAULT 0,
+				called_at   TEXT NOT NULL
+			)`,
+			`CREATE INDEX idx_tool_calls_session ON tool_calls(session_id)`,
+		}
+		for _, s := range stmts {
+			if _, err := tx.Exec(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+}
+
+// Open opens (or creates) the archive database at dbPath. Transcripts are
+// copied into transcriptDir.
+func Open(dbPath, transcriptDir string) (*Store, error) {
+	if err := os.MkdirAll(transcriptDir, 0700); err != nil {
+		return nil, fmt.Errorf("create transcript dir: %w", err)
+	}
+
+	dsn := dbPath + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open history db: %w", err)
+	}
+
+	if err := migrate.Run(db, dbPath, migrations); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate history db: %w", err)
+	}
+
+	return &Store{db: db, dir: transcriptDir}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
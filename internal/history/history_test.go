@@ -0,0 +1,155 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleTranscript = `{"type":"summary","summary":"Fix flaky test"}
+{"type":"user","timestamp":"2025-03-01T10:00:00Z","message":{"role":"user","content":"Why does TestWorker flake on CI?"}}
+{"type":"user","isMeta":true,"message":{"role":"user","content":"<local-command-stdout></local-command-stdout>"}}
+{"type":"assistant","timestamp":"2025-03-01T10:00:05Z","message":{"role":"assistant","content":[{"type":"thinking","thinking":"hmm"},{"type":"text","text":"Let me look at the worker."},{"type":"tool_use","name":"Read","input":{"file_path":"worker.go"}}]}}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","content":[{"type":"text","text":"package kb"}]}]}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"The ticker races with Close."}]}}
+not json
+`
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	dir := t.TempDir()
+	s, err := Open(filepath.Join(dir, "history.db"), filepath.Join(dir, "transcripts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func writeTranscript(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseTranscript(t *testing.T) {
+	messages, err := ParseTranscript(strings.NewReader(sampleTranscript))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Message{
+		{Role: "user", Text: "Why does TestWorker flake on CI?"},
+		{Role: "assistant", Text: "Let me look at the worker."},
+		{Role: "tool", Text: `Read {"file_path":"worker.go"}`},
+		{Role: "tool", Text: "package kb"},
+		{Role: "assistant", Text: "The ticker races with Close."},
+	}
+	if len(messages) != len(want) {
+		t.Fatalf("expected %d messages, got %d: %+v", len(want), len(messages), messages)
+	}
+	for i, m := range messages {
+		if m.Role != want[i].Role || m.Text != want[i].Text {
+			t.Errorf("message %d: got %s %q, want %s %q", i, m.Role, m.Text, want[i].Role, want[i].Text)
+		}
+	}
+}
+
+func TestArchiveAndSearch(t *testing.T) {
+	s := openTestStore(t)
+	src := writeTranscript(t, sampleTranscript)
+
+	err := s.Archive(Session{
+		ID: "s1", Profile: "vibe", Project: "/p", Preview: "Why does TestWorker flake",
+		Status: "completed", StartedAt: "2025-03-01T10:00:00Z", EndedAt: "2025-03-01T11:00:00Z",
+	}, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Archive(Session{
+		ID: "s2", Profile: "plan", Project: "/p", Status: "suspended",
+		StartedAt: "2025-03-02T10:00:00Z", EndedAt: "2025-03-02T11:00:00Z",
+	}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := s.List(ListFilter{Project: "/p"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].ID != "s2" {
+		t.Fatalf("expected both sessions, most recent first, got %+v", all)
+	}
+
+	vibe, _ := s.List(ListFilter{Profile: "vibe"})
+	if len(vibe) != 1 || vibe[0].Messages != 5 {
+		t.Fatalf("expected one vibe session with 5 messages, got %+v", vibe)
+	}
+
+	hits, err := s.List(ListFilter{Query: "ticker close"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].ID != "s1" || !strings.Contains(hits[0].Snippet, "[ticker]") {
+		t.Fatalf("expected a match in s1 with a snippet, got %+v", hits)
+	}
+
+	if hits, _ := s.List(ListFilter{Query: `"unbalanced (`}); len(hits) != 0 {
+		t.Fatalf("expected no match for punctuation, got %+v", hits)
+	}
+
+	messages, err := s.Messages("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 5 {
+		t.Fatalf("expected 5 messages from the archived copy, got %d", len(messages))
+	}
+	if _, err := os.Stat(s.TranscriptFile("s1")); err != nil {
+		t.Fatalf("expected archived copy: %v", err)
+	}
+
+	if id, err := s.Resolve("s"); err == nil {
+		t.Fatalf("expected ambiguous prefix, got %s", id)
+	}
+	if id, err := s.Resolve("s1"); err != nil || id != "s1" {
+		t.Fatalf("expected s1, got %s, %v", id, err)
+	}
+	if _, err := s.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestArchiveAgainKeepsTranscript(t *testing.T) {
+	s := openTestStore(t)
+
+	path, err := s.SaveTranscript("s1", strings.NewReader(sampleTranscript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess := Session{ID: "s1", Profile: "vibe", Status: "suspended", StartedAt: "2025-03-01T10:00:00Z", EndedAt: "2025-03-01T11:00:00Z"}
+	if err := s.Archive(sess, path); err != nil {
+		t.Fatal(err)
+	}
+
+	sess.Status = "completed"
+	if err := s.Archive(sess, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Get("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != "completed" || got.Transcript != path || got.Messages != 5 {
+		t.Fatalf("expected transcript kept across re-archive, got %+v", got)
+	}
+	if hits, _ := s.List(ListFilter{Query: "worker"}); len(hits) != 1 {
+		t.Fatalf("expected index kept, got %d hits", len(hits))
+	}
+}
@@ -0,0 +1,49 @@
+package history
+
+import "fmt"
+
+// ToolCall is a call a session made to one of Vee's MCP tools.
+type ToolCall struct {
+	SessionID  string `json:"session_id"`
+	Profile    string `json:"profile"`
+	Tool       string `json:"tool"`
+	Arguments  string `json:"arguments"` // JSON arguments, as sent
+	Result     string `json:"result"`    // first line of the result
+	IsError    bool   `json:"is_error"`
+	DurationMS int64  `json:"duration_ms"`
+	CalledAt   string `json:"called_at"`
+}
+
+// RecordToolCall appends a call to the audit log.
+func (s *Store) RecordToolCall(c ToolCall) error {
+	if _, err := s.db.Exec(
+		`INSERT INTO tool_calls (session_id, profile, tool, arguments, result, is_error, duration_ms, called_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.SessionID, c.Profile, c.Tool, c.Arguments, c.Result, c.IsError, c.DurationMS, c.CalledAt,
+	); err != nil {
+		return fmt.Errorf("record tool call: %w", err)
+	}
+	return nil
+}
+
+// ToolCalls returns the calls a session made, oldest first.
+func (s *Store) ToolCalls(sessionID string) ([]ToolCall, error) {
+	rows, err := s.db.Query(
+		`SELECT session_id, profile, tool, arguments, result, is_error, duration_ms, called_at
+		 FROM tool_calls WHERE session_id = ? ORDER BY id`, sessionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("tool calls: %w", err)
+	}
+	defer rows.Close()
+
+	var calls []ToolCall
+	for rows.Next() {
+		var c ToolCall
+		if err := rows.Scan(&c.SessionID, &c.Profile, &c.Tool, &c.Arguments, &c.Result, &c.IsError, &c.DurationMS, &c.CalledAt); err != nil {
+			return nil, fmt.Errorf("scan tool call: %w", err)
+		}
+		calls = append(calls, c)
+	}
+	return calls, rows.Err()
+}
@@ -0,0 +1,30 @@
+package history
+
+import "testing"
+
+func TestToolCalls(t *testing.T) {
+	s := openTestStore(t)
+
+	calls := []ToolCall{
+		{SessionID: "s1", Profile: "normal", Tool: "kb_query", Arguments: `{"query":"tests"}`, Result: "Found 2 statements", DurationMS: 12, CalledAt: "2025-03-01T10:00:00Z"},
+		{SessionID: "s2", Profile: "vibe", Tool: "request_suspend", Arguments: `{}`, Result: "Session suspended.", CalledAt: "2025-03-01T10:00:01Z"},
+		{SessionID: "s1", Profile: "normal", Tool: "kb_remember", Arguments: `{"content":"x"}`, Result: "not allowed", IsError: true, CalledAt: "2025-03-01T10:00:02Z"},
+	}
+	for _, c := range calls {
+		if err := s.RecordToolCall(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := s.ToolCalls("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != calls[0] || got[1] != calls[2] {
+		t.Errorf("ToolCalls(s1) = %+v, want the calls of s1 in order", got)
+	}
+
+	if got, err := s.ToolCalls("s3"); err != nil || len(got) != 0 {
+		t.Errorf("ToolCalls(s3) = %+v, %v, want none", got, err)
+	}
+}
@@ -0,0 +1,139 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// Message is a readable entry of a conversation.
+type Message struct {
+	Role      string `json:"role"` // "user", "assistant", or "tool"
+	Text      string `json:"text"`
+	Timestamp string `json:"timestamp,omitempty"`
+}
+
+// maxToolText bounds how much of a tool call or result is kept: enough to
+// recognize it, without drowning the conversation in file contents.
+const maxToolText = 500
+
+// transcriptLine is the subset of a Claude transcript line we read.
+type transcriptLine struct {
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp"`
+	IsMeta    bool   `json:"isMeta"`
+	Message   struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+// contentBlock is a block of a structured message content.
+type contentBlock struct {
+	Type    string          `json:"type"`
+	Text    string          `json:"text"`
+	Name    string          `json:"name"`
+	Input   json.RawMessage `json:"input"`
+	Content json.RawMessage `json:"content"`
+}
+
+// ParseTranscript reads a Claude transcript (one JSON object per line) and
+// returns its conversation: user prompts, assistant replies, and a short
+// account of tool calls and results. Lines that are not part of the
+// conversation, or cannot be decoded, are skipped.
+func ParseTranscript(r io.Reader) ([]Message, error) {
+	br := bufio.NewReader(r)
+	var messages []Message
+	for {
+		raw, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(raw)) > 0 {
+			messages = append(messages, parseLine(raw)...)
+		}
+		if errors.Is(err, io.EOF) {
+			return messages, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func parseLine(raw []byte) []Message {
+	var line transcriptLine
+	if err := json.Unmarshal(raw, &line); err != nil {
+		return nil
+	}
+	if (line.Type != "user" && line.Type != "assistant") || line.IsMeta {
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(line.Message.Content, &text); err == nil {
+		if text = strings.TrimSpace(text); text == "" {
+			return nil
+		}
+		return []Message{{Role: line.Type, Text: text, Timestamp: line.Timestamp}}
+	}
+
+	var blocks []contentBlock
+	if err := json.Unmarshal(line.Message.Content, &blocks); err != nil {
+		return nil
+	}
+
+	var messages []Message
+	for _, b := range blocks {
+		m := Message{Role: line.Type, Timestamp: line.Timestamp}
+		switch b.Type {
+		case "text":
+			m.Text = strings.TrimSpace(b.Text)
+		case "tool_use":
+			m.Role = "tool"
+			m.Text = truncate(b.Name+" "+compactJSON(b.Input), maxToolText)
+		case "tool_result":
+			m.Role = "tool"
+			m.Text = truncate(toolResultText(b.Content), maxToolText)
+		}
+		if m.Text != "" {
+			messages = append(messages, m)
+		}
+	}
+	return messages
+}
+
+// toolResultText extracts the text of a tool result, which is either a
+// string or a list of text blocks.
+func toolResultText(content json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return strings.TrimSpace(text)
+	}
+	var blocks []contentBlock
+	if err := json.Unmarshal(content, &blocks); err != nil {
+		return ""
+	}
+	var parts []string
+	for _, b := range blocks {
+		if b.Type == "text" && b.Text != "" {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n"))
+}
+
+func compactJSON(raw json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,231 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Usage counts the tokens of one or more model responses, with their
+// estimated cost in US dollars.
+type Usage struct {
+	Input         int64   `json:"input"`
+	Output        int64   `json:"output"`
+	CacheCreation int64   `json:"cache_creation"`
+	CacheRead     int64   `json:"cache_read"`
+	Cost          float64 `json:"cost"`
+}
+
+// Total returns the number of tokens, cached ones included.
+func (u Usage) Total() int64 {
+	return u.Input + u.Output + u.CacheCreation + u.CacheRead
+}
+
+// Add returns the sum of two usages.
+func (u Usage) Add(o Usage) Usage {
+	return Usage{
+		Input:         u.Input + o.Input,
+		Output:        u.Output + o.Output,
+		CacheCreation: u.CacheCreation + o.CacheCreation,
+		CacheRead:     u.CacheRead + o.CacheRead,
+		Cost:          u.Cost + o.Cost,
+	}
+}
+
+// UsageEntry is the usage of one model response of a transcript.
+type UsageEntry struct {
+	MessageID string
+	Model     string
+	Day       string // YYYY-MM-DD, in UTC
+	Usage
+}
+
+// UsageRow is a line of a usage report.
+type UsageRow struct {
+	Key      string `json:"key"` // profile, project or day
+	Sessions int    `json:"sessions"`
+	Usage
+}
+
+// usageLine is the subset of a transcript line carrying token usage.
+type usageLine struct {
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp"`
+	Message   struct {
+		ID    string `json:"id"`
+		Model string `json:"model"`
+		Usage struct {
+			InputTokens              int64 `json:"input_tokens"`
+			OutputTokens             int64 `json:"output_tokens"`
+			CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+			CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// ParseUsage reads the token usage of the assistant responses of a Claude
+// transcript. A response split over several lines is counted once, with
+// its last reported usage.
+func ParseUsage(r io.Reader) ([]UsageEntry, error) {
+	br := bufio.NewReader(r)
+	var entries []UsageEntry
+	index := make(map[string]int)
+	for {
+		raw, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(raw)) > 0 {
+			if e, ok := parseUsageLine(raw); ok {
+				if i, seen := index[e.MessageID]; seen {
+					entries[i] = e
+				} else {
+					index[e.MessageID] = len(entries)
+					entries = append(entries, e)
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func parseUsageLine(raw []byte) (UsageEntry, bool) {
+	var line usageLine
+	if err := json.Unmarshal(raw, &line); err != nil {
+		return UsageEntry{}, false
+	}
+	if line.Type != "assistant" || line.Message.ID == "" {
+		return UsageEntry{}, false
+	}
+	u := line.Message.Usage
+	e := UsageEntry{
+		MessageID: line.Message.ID,
+		Model:     line.Message.Model,
+		Usage: Usage{
+			Input:         u.InputTokens,
+			Output:        u.OutputTokens,
+			CacheCreation: u.CacheCreationInputTokens,
+			CacheRead:     u.CacheReadInputTokens,
+		},
+	}
+	if e.Total() == 0 {
+		return UsageEntry{}, false
+	}
+	if len(line.Timestamp) >= 10 {
+		e.Day = line.Timestamp[:10]
+	}
+	e.Cost = EstimateCost(e.Model, e.Usage)
+	return e, true
+}
+
+// modelPrice is the price of a model family, in US dollars per million
+// tokens.
+type modelPrice struct {
+	family                          string
+	input, output, cacheWrite, read float64
+}
+
+// modelPrices are the list prices of the Claude model families. Models are
+// matched by the first family their name contains.
+var modelPrices = []modelPrice{
+	{"opus", 15, 75, 18.75, 1.5},
+	{"sonnet", 3, 15, 3.75, 0.3},
+	{"haiku", 0.8, 4, 1, 0.08},
+}
+
+// EstimateCost returns the cost of a usage at the list price of the model,
+// or 0 for an unknown model.
+func EstimateCost(model string, u Usage) float64 {
+	model = strings.ToLower(model)
+	for _, p := range modelPrices {
+		if strings.Contains(model, p.family) {
+			return (float64(u.Input)*p.input +
+				float64(u.Output)*p.output +
+				float64(u.CacheCreation)*p.cacheWrite +
+				float64(u.CacheRead)*p.read) / 1e6
+		}
+	}
+	return 0
+}
+
+// RecordUsage stores the usage entries of a session. Entries are keyed by
+// message, so recording a transcript again only updates it, and messages
+// carried over into another session (e.g. a fork) stay counted once, for
+// the session they first appeared in.
+func (s *Store) RecordUsage(sessionID, profile, project string, entries []UsageEntry) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, e := range entries {
+		if _, err := tx.Exec(
+			`INSERT INTO usage (message_id, session_id, profile, project, day, model, input, output, cache_creation, cache_read, cost)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			 ON CONFLICT(message_id) DO UPDATE SET
+			   input = excluded.input, output = excluded.output,
+			   cache_creation = excluded.cache_creation, cache_read = excluded.cache_read, cost = excluded.cost
+			 WHERE usage.session_id = excluded.session_id`,
+			e.MessageID, sessionID, profile, project, e.Day, e.Model,
+			e.Input, e.Output, e.CacheCreation, e.CacheRead, e.Cost,
+		); err != nil {
+			return fmt.Errorf("record usage: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SessionUsage returns the usage recorded for a session.
+func (s *Store) SessionUsage(sessionID string) (Usage, error) {
+	var u Usage
+	err := s.db.QueryRow(
+		`SELECT `+usageSums+` FROM usage WHERE session_id = ?`, sessionID,
+	).Scan(&u.Input, &u.Output, &u.CacheCreation, &u.CacheRead, &u.Cost)
+	if err != nil {
+		return Usage{}, fmt.Errorf("session usage: %w", err)
+	}
+	return u, nil
+}
+
+// UsageReport returns the recorded usage grouped by "profile", "project" or
+// "day", from the given day (YYYY-MM-DD, "" for all time). Rows are sorted
+// by cost, except days, which are sorted by date.
+func (s *Store) UsageReport(by, since string) ([]UsageRow, error) {
+	order := "SUM(cost) DESC, key ASC"
+	switch by {
+	case "profile", "project":
+	case "day":
+		order = "key ASC"
+	default:
+		return nil, fmt.Errorf("unknown usage grouping %q", by)
+	}
+
+	rows, err := s.db.Query(
+		`SELECT `+by+` AS key, COUNT(DISTINCT session_id), `+usageSums+`
+		 FROM usage WHERE day >= ? GROUP BY key ORDER BY `+order, since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("usage report: %w", err)
+	}
+	defer rows.Close()
+
+	var report []UsageRow
+	for rows.Next() {
+		var r UsageRow
+		if err := rows.Scan(&r.Key, &r.Sessions, &r.Input, &r.Output, &r.CacheCreation, &r.CacheRead, &r.Cost); err != nil {
+			return nil, fmt.Errorf("scan usage: %w", err)
+		}
+		report = append(report, r)
+	}
+	return report, rows.Err()
+}
+
+const usageSums = `COALESCE(SUM(input), 0), COALESCE(SUM(output), 0), COALESCE(SUM(cache_creation), 0), COALESCE(SUM(cache_read), 0), COALESCE(SUM(cost), 0)`
@@ -0,0 +1,80 @@
+package history
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const usageTranscript = `{"type":"user","timestamp":"2025-03-01T10:00:00Z","message":{"role":"user","content":"Hi"}}
+{"type":"assistant","timestamp":"2025-03-01T10:00:05Z","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":100,"output_tokens":10,"cache_read_input_tokens":1000}}}
+{"type":"assistant","timestamp":"2025-03-01T10:00:06Z","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":100,"output_tokens":50,"cache_read_input_tokens":1000}}}
+{"type":"assistant","timestamp":"2025-03-02T09:00:00Z","message":{"id":"msg_2","model":"claude-opus-4-1","usage":{"input_tokens":10,"output_tokens":20,"cache_creation_input_tokens":200}}}
+not json
+`
+
+func TestParseUsage(t *testing.T) {
+	entries, err := ParseUsage(strings.NewReader(usageTranscript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 responses, got %+v", entries)
+	}
+	if e := entries[0]; e.MessageID != "msg_1" || e.Output != 50 || e.CacheRead != 1000 || e.Day != "2025-03-01" {
+		t.Errorf("expected the last usage of msg_1, got %+v", e)
+	}
+	// 100×3 + 50×15 + 1000×0.3 per million
+	if want := 0.00135; math.Abs(entries[0].Cost-want) > 1e-9 {
+		t.Errorf("cost = %v, want %v", entries[0].Cost, want)
+	}
+}
+
+func TestUsageReport(t *testing.T) {
+	s := openTestStore(t)
+
+	entries, err := ParseUsage(strings.NewReader(usageTranscript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RecordUsage("s1", "normal", "/p/a", entries); err != nil {
+		t.Fatal(err)
+	}
+	// Recording again, and in a fork carrying the same messages, does not
+	// count them twice
+	if err := s.RecordUsage("s1", "normal", "/p/a", entries); err != nil {
+		t.Fatal(err)
+	}
+	fork := append(entries[:2:2], UsageEntry{MessageID: "msg_3", Day: "2025-03-02", Usage: Usage{Input: 5, Output: 5}})
+	if err := s.RecordUsage("s2", "vibe", "/p/b", fork); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := s.SessionUsage("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Input != 110 || u.Output != 70 || u.CacheCreation != 200 || u.CacheRead != 1000 {
+		t.Errorf("session usage = %+v", u)
+	}
+
+	byProfile, err := s.UsageReport("profile", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byProfile) != 2 || byProfile[0].Key != "normal" || byProfile[1].Key != "vibe" || byProfile[1].Total() != 10 {
+		t.Errorf("by profile = %+v", byProfile)
+	}
+
+	byDay, err := s.UsageReport("day", "2025-03-02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byDay) != 1 || byDay[0].Key != "2025-03-02" || byDay[0].Sessions != 2 {
+		t.Errorf("by day = %+v", byDay)
+	}
+
+	if _, err := s.UsageReport("model; DROP TABLE usage", ""); err == nil {
+		t.Error("expected an error for an unknown grouping")
+	}
+}
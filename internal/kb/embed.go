@@ -6,9 +6,9 @@ import (
 	"math"
 )
 
-// cosineSimilarity computes the cosine similarity between two vectors.
+// CosineSimilarity computes the cosine similarity between two vectors.
 // Returns 0 if either vector has zero magnitude.
-func cosineSimilarity(a, b []float64) float64 {
+func CosineSimilarity(a, b []float64) float64 {
 	if len(a) != len(b) || len(a) == 0 {
 		return 0
 	}
@@ -24,8 +24,8 @@ func cosineSimilarity(a, b []float64) float64 {
 	return dot / (math.Sqrt(magA) * math.Sqrt(magB))
 }
 
-// embeddingToBlob serializes a float64 slice into a binary blob (little-endian).
-func embeddingToBlob(emb []float64) []byte {
+// EmbeddingToBlob serializes a float64 slice into a binary blob (little-endian).
+func EmbeddingToBlob(emb []float64) []byte {
 	buf := make([]byte, len(emb)*8)
 	for i, v := range emb {
 		binary.LittleEndian.PutUint64(buf[i*8:], math.Float64bits(v))
@@ -33,8 +33,8 @@ func embeddingToBlob(emb []float64) []byte {
 	return buf
 }
 
-// blobToEmbedding deserializes a binary blob back to a float64 slice.
-func blobToEmbedding(blob []byte) []float64 {
+// BlobToEmbedding deserializes a binary blob back to a float64 slice.
+func BlobToEmbedding(blob []byte) []float64 {
 	n := len(blob) / 8
 	emb := make([]float64, n)
 	for i := 0; i < n; i++ {
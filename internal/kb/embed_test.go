@@ -21,9 +21,9 @@ func TestCosineSimilarity(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := cosineSimilarity(tt.a, tt.b)
+			got := CosineSimilarity(tt.a, tt.b)
 			if math.Abs(got-tt.expected) > 1e-10 {
-				t.Errorf("cosineSimilarity(%v, %v) = %f, want %f", tt.a, tt.b, got, tt.expected)
+				t.Errorf("CosineSimilarity(%v, %v) = %f, want %f", tt.a, tt.b, got, tt.expected)
 			}
 		})
 	}
@@ -31,8 +31,8 @@ func TestCosineSimilarity(t *testing.T) {
 
 func TestEmbeddingBlobRoundtrip(t *testing.T) {
 	original := []float64{0.1, -0.5, 3.14159, 0, -1e10, 1e-10}
-	blob := embeddingToBlob(original)
-	restored := blobToEmbedding(blob)
+	blob := EmbeddingToBlob(original)
+	restored := BlobToEmbedding(blob)
 
 	if len(restored) != len(original) {
 		t.Fatalf("length mismatch: %d vs %d", len(restored), len(original))
@@ -15,12 +15,29 @@ type Model interface {
 
 // Config holds KB initialization parameters.
 type Config struct {
-	DBPath         string  // path to SQLite file
-	Model          Model   // embedding backend
-	EmbeddingModel string  // model name stored alongside embeddings for stale detection
-	Threshold      float64 // minimum cosine similarity to include (0 = default 0.3)
-	MaxResults     int     // max query results returned (0 = default 10)
-	DupThreshold   float64 // cosine similarity above which a pair is flagged as duplicate (0 = default 0.85)
+	DBPath         string      // path to SQLite file
+	Model          Model       // embedding backend
+	EmbeddingModel string      // model name stored alongside embeddings for stale detection
+	Threshold      float64     // minimum cosine similarity to include (0 = default 0.3)
+	MaxResults     int         // max query results returned (0 = default 10)
+	DupThreshold   float64     // cosine similarity above which a pair is flagged as duplicate (0 = default 0.85)
+	OnEvent        func(Event) // called on background progress (optional)
+}
+
+// Event types reported through Config.OnEvent.
+const (
+	EventIndexing          = "indexing"           // the worker started processing a pending statement
+	EventIndexed           = "indexed"            // the worker is done with a pending statement, promoted or not
+	EventStatementPromoted = "statement_promoted" // a statement became active
+	EventIssueOpened       = "issue_opened"       // the worker flagged a pair of statements
+)
+
+// Event reports a change made by the knowledge base, mostly by its worker.
+type Event struct {
+	Type       string
+	ID         string // statement ID, or issue ID for EventIssueOpened
+	Content    string // statement content, for EventIndexing
+	OpenIssues int    // open issues once this one is opened, for EventIssueOpened
 }
 
 // KnowledgeBase provides persistent statement storage backed by SQLite
@@ -33,6 +50,7 @@ type KnowledgeBase struct {
 	maxResults     int
 	dupThreshold   float64
 	notifyCh       chan struct{} // signals the worker that a new statement was inserted
+	onEvent        func(Event)
 }
 
 // QueryResult is a single search hit from KNN search.
@@ -56,7 +74,7 @@ func Open(cfg Config) (*KnowledgeBase, error) {
 		return nil, fmt.Errorf("open db: %w", err)
 	}
 
-	if err := migrate(db); err != nil {
+	if err := migrateDB(db, cfg.DBPath); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("migrate: %w", err)
 	}
@@ -82,9 +100,17 @@ func Open(cfg Config) (*KnowledgeBase, error) {
 		maxResults:     maxResults,
 		dupThreshold:   dupThreshold,
 		notifyCh:       make(chan struct{}, 1),
+		onEvent:        cfg.OnEvent,
 	}, nil
 }
 
+// emit reports an event to Config.OnEvent, if set.
+func (kb *KnowledgeBase) emit(e Event) {
+	if kb.onEvent != nil {
+		kb.onEvent(e)
+	}
+}
+
 // NotifyCh returns the channel that signals new pending statements.
 func (kb *KnowledgeBase) NotifyCh() <-chan struct{} {
 	return kb.notifyCh
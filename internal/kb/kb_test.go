@@ -2,12 +2,16 @@ package kb
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/lthms/vee/internal/migrate"
 )
 
 // stubModel implements Model for testing.
@@ -62,7 +66,7 @@ func addAndPromote(t *testing.T, kbase *KnowledgeBase, content, source, sourceTy
 		t.Fatalf("AddStatement: %v", err)
 	}
 	if emb != nil {
-		blob := embeddingToBlob(emb)
+		blob := EmbeddingToBlob(emb)
 		_, err = kbase.db.Exec(
 			`UPDATE statements SET embedding = ?, model = ?, status = 'active' WHERE id = ?`,
 			blob, kbase.embeddingModel, result.ID,
@@ -108,6 +112,51 @@ func TestOpen_MigrateIdempotent(t *testing.T) {
 	kb2.Close()
 }
 
+func TestOpen_UpgradesUnversionedDB(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "kb.db")
+
+	// Schema of databases created before schema_version existed.
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		`CREATE TABLE statements (
+			id TEXT PRIMARY KEY, content TEXT NOT NULL, source TEXT NOT NULL DEFAULT '',
+			source_type TEXT NOT NULL DEFAULT 'manual', status TEXT NOT NULL DEFAULT 'pending',
+			embedding BLOB, model TEXT NOT NULL DEFAULT '', created_at TEXT NOT NULL,
+			last_verified TEXT NOT NULL DEFAULT '')`,
+		`CREATE TABLE issues (
+			id TEXT PRIMARY KEY, type TEXT NOT NULL, status TEXT NOT NULL DEFAULT 'open',
+			statement_a TEXT NOT NULL, statement_b TEXT NOT NULL, score REAL NOT NULL DEFAULT 0,
+			created_at TEXT NOT NULL, resolved_at TEXT NOT NULL DEFAULT '')`,
+		`INSERT INTO statements (id, content, created_at) VALUES ('s1', 'Tests live next to the code', '2025-01-01')`,
+	} {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatalf("fixture: %v", err)
+		}
+	}
+	db.Close()
+
+	kbase, err := Open(Config{DBPath: dbPath, Model: newStub()})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer kbase.Close()
+
+	if v, _ := migrate.Version(kbase.db); v != len(migrations) {
+		t.Fatalf("expected version %d, got %d", len(migrations), v)
+	}
+	if _, err := os.Stat(migrate.BackupPath(dbPath, 0)); err != nil {
+		t.Fatalf("expected a backup: %v", err)
+	}
+	var content string
+	if err := kbase.db.QueryRow(`SELECT content FROM statements WHERE id = 's1'`).Scan(&content); err != nil {
+		t.Fatalf("statement not preserved: %v", err)
+	}
+}
+
 func TestOpen_NilModel(t *testing.T) {
 	dir := t.TempDir()
 	_, err := Open(Config{
@@ -615,6 +664,68 @@ func TestWorker_CreatesIssueForDuplicates(t *testing.T) {
 	}
 }
 
+func TestWorker_ReportsEvents(t *testing.T) {
+	stub := newStub()
+	stub.embedFn = func(texts []string) ([][]float64, error) {
+		results := make([][]float64, len(texts))
+		for i := range texts {
+			results[i] = []float64{1.0, 0.0, 0.0}
+		}
+		return results, nil
+	}
+	dir := t.TempDir()
+	var events []Event
+	kbase, err := Open(Config{
+		DBPath:         filepath.Join(dir, "kb.db"),
+		Model:          stub,
+		EmbeddingModel: "test-model",
+		OnEvent:        func(e Event) { events = append(events, e) },
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer kbase.Close()
+
+	r1, _ := kbase.AddStatement("Statement one", "src", "manual")
+	r2, _ := kbase.AddStatement("Statement two", "src", "manual")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	kbase.processPending(ctx)
+
+	// The worker picks either statement first; the other is a duplicate.
+	first, second := r1.ID, r2.ID
+	if len(events) > 0 && events[0].ID == r2.ID {
+		first, second = r2.ID, r1.ID
+	}
+	var got []string
+	for _, e := range events {
+		switch e.Type {
+		case EventIssueOpened:
+			got = append(got, e.Type)
+			if e.OpenIssues != 1 {
+				t.Errorf("issue opened event counts %d open issues, want 1", e.OpenIssues)
+			}
+		default:
+			got = append(got, e.Type+":"+e.ID)
+		}
+	}
+	want := []string{
+		EventIndexing + ":" + first,
+		EventStatementPromoted + ":" + first,
+		EventIndexed + ":" + first,
+		EventIndexing + ":" + second,
+		EventIssueOpened,
+		EventIndexed + ":" + second,
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	if !strings.HasPrefix(events[0].Content, "Statement ") {
+		t.Errorf("indexing event content = %q", events[0].Content)
+	}
+}
+
 func TestWorker_SkipsOnEmbeddingFailure(t *testing.T) {
 	stub := newStub()
 	stub.embedFn = func(texts []string) ([][]float64, error) {
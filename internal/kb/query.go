@@ -38,8 +38,8 @@ func (kb *KnowledgeBase) Query(query string) ([]QueryResult, error) {
 			continue
 		}
 
-		emb := blobToEmbedding(embBlob)
-		score := cosineSimilarity(queryEmb, emb)
+		emb := BlobToEmbedding(embBlob)
+		score := CosineSimilarity(queryEmb, emb)
 
 		if score < kb.threshold {
 			continue
@@ -84,6 +84,7 @@ func (kb *KnowledgeBase) PromoteStatement(id string) error {
 		return fmt.Errorf("statement not found: %s", id)
 	}
 	slog.Info("statement promoted", "id", id)
+	kb.emit(Event{Type: EventStatementPromoted, ID: id})
 	return nil
 }
 
@@ -53,7 +53,9 @@ func (kb *KnowledgeBase) processPending(ctx context.Context) {
 			return // no more pending statements to process this cycle
 		}
 
+		kb.emit(Event{Type: EventIndexing, ID: stmt.id, Content: stmt.content})
 		promoted := kb.processOne(ctx, stmt)
+		kb.emit(Event{Type: EventIndexed, ID: stmt.id})
 		if !promoted {
 			seen[stmt.id] = true
 		}
@@ -103,7 +105,7 @@ func (kb *KnowledgeBase) processOne(ctx context.Context, row *pendingRow) bool {
 			return false
 		}
 
-		blob := embeddingToBlob(emb)
+		blob := EmbeddingToBlob(emb)
 		_, err = kb.db.Exec(
 			`UPDATE statements SET embedding = ?, model = ? WHERE id = ?`,
 			blob, kb.embeddingModel, row.id,
@@ -122,7 +124,7 @@ func (kb *KnowledgeBase) processOne(ctx context.Context, row *pendingRow) bool {
 
 	// Step 2: check for duplicates against all statements with embeddings
 	// (both active and other pending — avoids blind spots)
-	newEmb := blobToEmbedding(row.embedding)
+	newEmb := BlobToEmbedding(row.embedding)
 	hasDup := false
 
 	rows, err := kb.db.Query(
@@ -143,8 +145,8 @@ func (kb *KnowledgeBase) processOne(ctx context.Context, row *pendingRow) bool {
 			continue
 		}
 
-		candEmb := blobToEmbedding(candBlob)
-		score := cosineSimilarity(newEmb, candEmb)
+		candEmb := BlobToEmbedding(candBlob)
+		score := CosineSimilarity(newEmb, candEmb)
 
 		if score >= kb.dupThreshold {
 			// Check if an issue already exists for this pair
@@ -165,6 +167,11 @@ func (kb *KnowledgeBase) processOne(ctx context.Context, row *pendingRow) bool {
 					slog.Warn("worker: failed to create issue", "id", row.id, "candidate", candID, "error", err)
 				} else {
 					slog.Info("worker: duplicate issue created", "issue", issueID, "a", row.id, "b", candID, "score", fmt.Sprintf("%.3f", score))
+					n, err := kb.OpenIssueCount()
+					if err != nil {
+						slog.Warn("worker: failed to count open issues", "error", err)
+					}
+					kb.emit(Event{Type: EventIssueOpened, ID: issueID, OpenIssues: n})
 				}
 			}
 			hasDup = true
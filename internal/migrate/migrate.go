@@ -0,0 +1,186 @@
+// Package migrate applies versioned schema migrations to Vee's SQLite
+// databases.
+//
+// Each database records the migrations applied to it in a schema_version
+// table. Migrations are numbered from 1 and applied in order, each in its
+// own transaction. Databases created before versioning was introduced have
+// no schema_version table and start at version 0, so the migrations that
+// mirror their history must tolerate finding their changes already applied
+// (see HasColumn and AddColumn).
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Migration is a single schema change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *sql.Tx) error
+}
+
+// ErrTooNew is returned when a database was migrated by a newer version of
+// Vee than the running one.
+var ErrTooNew = errors.New("database schema is newer than this version of vee supports")
+
+// Run brings the database at dbPath up to the latest migration. When
+// migrations are pending on a non-empty database, a copy is first written
+// next to it (see BackupPath) so a failed or unwanted upgrade can be rolled
+// back by hand. Processes opening the same database at once apply each
+// migration only once.
+func Run(db *sql.DB, dbPath string, migrations []Migration) error {
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return fmt.Errorf("migration %q has version %d, expected %d", m.Name, m.Version, i+1)
+		}
+	}
+
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_version (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("create schema_version table: %w", err)
+	}
+
+	current, err := Version(db)
+	if err != nil {
+		return err
+	}
+	latest := len(migrations)
+	if current > latest {
+		return fmt.Errorf("%w: database is at version %d, latest known is %d", ErrTooNew, current, latest)
+	}
+	if current == latest {
+		return nil
+	}
+
+	empty, err := isEmpty(db)
+	if err != nil {
+		return err
+	}
+	if !empty && dbPath != "" {
+		if err := backup(db, BackupPath(dbPath, current)); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range migrations[current:] {
+		if err := apply(db, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Version returns the latest migration applied to the database, or 0 if
+// none was.
+func Version(db *sql.DB) (int, error) {
+	var version int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// BackupPath returns where Run copies the database at dbPath before
+// migrating it from the given version.
+func BackupPath(dbPath string, version int) string {
+	return fmt.Sprintf("%s.v%d.bak", dbPath, version)
+}
+
+// HasColumn reports whether a table has the given column.
+func HasColumn(tx *sql.Tx, table, column string) (bool, error) {
+	var n int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("inspect %s.%s: %w", table, column, err)
+	}
+	return n > 0, nil
+}
+
+// AddColumn adds a column to a table unless it already exists.
+func AddColumn(tx *sql.Tx, table, column, def string) error {
+	exists, err := HasColumn(tx, table, column)
+	if err != nil || exists {
+		return err
+	}
+	if _, err := tx.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + def); err != nil {
+		return fmt.Errorf("add %s.%s column: %w", table, column, err)
+	}
+	return nil
+}
+
+// apply runs a migration and records it in the same transaction, unless
+// another process applied it in the meantime. The transaction takes the
+// write lock with its first statement, as BEGIN IMMEDIATE would (database/sql
+// cannot issue it), so that the version it reads next cannot change under it.
+func apply(db *sql.DB, m Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE schema_version SET version = version WHERE 0`); err != nil {
+		return fmt.Errorf("lock database: %w", err)
+	}
+	var current int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&current); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if current >= m.Version {
+		return nil
+	}
+
+	if err := m.Up(tx); err != nil {
+		return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.Version, m.Name, time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+	); err != nil {
+		return fmt.Errorf("record migration %d: %w", m.Version, err)
+	}
+	return tx.Commit()
+}
+
+// isEmpty reports whether the database holds no tables besides
+// schema_version, i.e. it was just created.
+func isEmpty(db *sql.DB) (bool, error) {
+	var n int
+	err := db.QueryRow(
+		`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT IN ('schema_version') AND name NOT LIKE 'sqlite_%'`,
+	).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("inspect database: %w", err)
+	}
+	return n == 0, nil
+}
+
+// backup writes a consistent copy of the database to path, replacing any
+// previous backup of the same version. The copy is written under a temporary
+// name and renamed into place, so a concurrent backup is never truncated.
+func backup(db *sql.DB, path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create backup: %w", err)
+	}
+	tmp.Close()
+	// VACUUM INTO accepts an existing file as long as it is empty
+	if _, err := db.Exec(`VACUUM INTO ?`, tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("backup database: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("backup database: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func openDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func exec(query string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "create items", Up: exec(`CREATE TABLE items (id INTEGER PRIMARY KEY)`)},
+	{Version: 2, Name: "add label", Up: func(tx *sql.Tx) error {
+		return AddColumn(tx, "items", "label", "TEXT NOT NULL DEFAULT ''")
+	}},
+}
+
+func TestRunAppliesInOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db := openDB(t, path)
+
+	if err := Run(db, path, testMigrations[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO items (id) VALUES (1)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(BackupPath(path, 0)); !os.IsNotExist(err) {
+		t.Fatal("expected no backup of a fresh database")
+	}
+
+	if err := Run(db, path, testMigrations); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := Version(db); v != 2 {
+		t.Fatalf("expected version 2, got %d", v)
+	}
+	if _, err := db.Exec(`UPDATE items SET label = 'x'`); err != nil {
+		t.Fatalf("expected label column: %v", err)
+	}
+
+	backup := openDB(t, BackupPath(path, 1))
+	if v, _ := Version(backup); v != 1 {
+		t.Fatalf("expected backup at version 1, got %d", v)
+	}
+	var n int
+	if err := backup.QueryRow(`SELECT COUNT(*) FROM items`).Scan(&n); err != nil || n != 1 {
+		t.Fatalf("expected backup to hold the data, got %d rows, %v", n, err)
+	}
+
+	// Running again is a no-op.
+	if err := Run(db, path, testMigrations); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunRefusesNewerDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db := openDB(t, path)
+
+	if err := Run(db, path, testMigrations); err != nil {
+		t.Fatal(err)
+	}
+	err := Run(db, path, testMigrations[:1])
+	if !errors.Is(err, ErrTooNew) {
+		t.Fatalf("expected ErrTooNew, got %v", err)
+	}
+}
+
+func TestRunRollsBackFailedMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db := openDB(t, path)
+
+	broken := []Migration{
+		testMigrations[0],
+		{Version: 2, Name: "broken", Up: func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`CREATE TABLE other (id INTEGER)`); err != nil {
+				return err
+			}
+			return errors.New("boom")
+		}},
+	}
+	if err := Run(db, path, broken); err == nil {
+		t.Fatal("expected error")
+	}
+	if v, _ := Version(db); v != 1 {
+		t.Fatalf("expected version 1 after failure, got %d", v)
+	}
+	var n int
+	db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'other'`).Scan(&n)
+	if n != 0 {
+		t.Fatal("expected failed migration to be rolled back")
+	}
+}
+
+func TestRunRejectsMisnumberedMigrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db := openDB(t, path)
+
+	err := Run(db, path, []Migration{{Version: 2, Name: "gap", Up: exec(`SELECT 1`)}})
+	if err == nil {
+		t.Fatal("expected error for a gap in versions")
+	}
+}
+
+func TestRunConcurrentProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	seed := openDB(t, path)
+	if err := Run(seed, path, testMigrations[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := seed.Exec(`INSERT INTO items (id) VALUES (1)`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each process holds its own connection and applies the slow migration
+	// while the other one waits for the lock
+	var runs atomic.Int32
+	slow := append(testMigrations[:2:2], Migration{Version: 3, Name: "slow", Up: func(tx *sql.Tx) error {
+		runs.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		_, err := tx.Exec(`CREATE TABLE slow (id INTEGER)`)
+		return err
+	}})
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		db := openDB(t, path+"?_pragma=busy_timeout(5000)")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = Run(db, path, slow)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("expected both processes to open the database, got %v", err)
+		}
+	}
+	if n := runs.Load(); n != 1 {
+		t.Fatalf("expected the migration to run once, ran %d times", n)
+	}
+	if v, _ := Version(seed); v != 3 {
+		t.Fatalf("expected version 3, got %d", v)
+	}
+	backup := openDB(t, BackupPath(path, 1))
+	if v, err := Version(backup); err != nil || v != 1 {
+		t.Fatalf("expected a backup at version 1, got %d (%v)", v, err)
+	}
+	if matches, _ := filepath.Glob(BackupPath(path, 1) + ".*.tmp"); len(matches) != 0 {
+		t.Fatalf("expected no temporary backup left, got %v", matches)
+	}
+}